@@ -5,13 +5,19 @@ import { Instance } from "../project/instance"
 
 export namespace Bus {
   const log = Log.create({ service: "bus" })
-  type Subscription = (event: any) => void
+  type Subscription = (event: any, id: number) => void
+
+  // How many of the latest events are kept, for clients of the event stream
+  // to catch up on after reconnecting
+  const HISTORY = 1000
 
   const state = Instance.state(() => {
     const subscriptions = new Map<any, Subscription[]>()
 
     return {
       subscriptions,
+      sequence: 0,
+      history: [] as { id: number; event: any }[],
     }
   })
 
@@ -58,11 +64,15 @@ export namespace Bus {
     log.info("publishing", {
       type: def.type,
     })
+    const s = state()
+    const id = ++s.sequence
+    s.history.push({ id, event: payload })
+    if (s.history.length > HISTORY) s.history.shift()
     const pending = []
     for (const key of [def.type, "*"]) {
-      const match = state().subscriptions.get(key)
+      const match = s.subscriptions.get(key)
       for (const sub of match ?? []) {
-        pending.push(sub(payload))
+        pending.push(sub(payload, id))
       }
     }
     return Promise.all(pending)
@@ -87,11 +97,22 @@ export namespace Bus {
     })
   }
 
-  export function subscribeAll(callback: (event: any) => void) {
+  export function subscribeAll(callback: (event: any, id: number) => void) {
     return raw("*", callback)
   }
 
-  function raw(type: string, callback: (event: any) => void) {
+  // Returns the events published after the one with the given id, or
+  // undefined when they are no longer all kept, or the id is from before the
+  // server restarted.
+  export function since(id: number) {
+    const s = state()
+    if (id > s.sequence) return
+    const first = s.history[0]?.id ?? s.sequence + 1
+    if (id + 1 < first) return
+    return s.history.filter((x) => x.id > id)
+  }
+
+  function raw(type: string, callback: Subscription) {
     log.info("subscribing", { type })
     const subscriptions = state().subscriptions
     let match = subscriptions.get(type) ?? []
@@ -104,7 +104,7 @@ export const RunCommand = cmd({
           }
         }
 
-        if (args.session) return Session.get(args.session)
+        if (args.session) return Session.get(args.session).catch(() => undefined)
 
         return Session.create({})
       })()
@@ -327,15 +327,29 @@ export namespace Config {
     .object({
       leader: z.string().optional().default("ctrl+x").describe("Leader key for keybind combinations"),
       app_help: z.string().optional().default("<leader>h").describe("Show help dialog"),
+      app_usage: z.string().optional().default("none").describe("Show token usage and cost across sessions"),
       app_exit: z.string().optional().default("ctrl+c,<leader>q").describe("Exit the application"),
       editor_open: z.string().optional().default("<leader>e").describe("Open external editor"),
       theme_list: z.string().optional().default("<leader>t").describe("List available themes"),
       project_init: z.string().optional().default("<leader>i").describe("Create/update AGENTS.md"),
       tool_details: z.string().optional().default("<leader>d").describe("Toggle tool details"),
       thinking_blocks: z.string().optional().default("<leader>b").describe("Toggle thinking blocks"),
-      session_export: z.string().optional().default("<leader>x").describe("Export session to editor"),
+      vim_mode: z.string().optional().default("none").describe("Toggle Vim-style modal editing in the prompt"),
+      snippet_list: z.string().optional().default("none").describe("Manage prompt snippets"),
+      diagnostics_toggle: z.string().optional().default("none").describe("Toggle the diagnostics panel"),
+      todos_toggle: z.string().optional().default("none").describe("Collapse or expand the todo panel"),
+      changes_list: z.string().optional().default("none").describe("Review the files changed in the session"),
+      changes_review: z.string().optional().default("none").describe("Accept or reject the hunks changed in the session"),
+      permission_list: z.string().optional().default("<leader>p").describe("Review pending permissions and saved rules"),
+      session_export: z.string().optional().default("<leader>x").describe("Export session to a file"),
+      session_import: z.string().optional().default("none").describe("Import a session from an exported file"),
       session_new: z.string().optional().default("<leader>n").describe("Create a new session"),
       session_list: z.string().optional().default("<leader>l").describe("List all sessions"),
+      session_search: z.string().optional().default("<leader>f").describe("Search messages of all sessions"),
+      tab_new: z.string().optional().default("<leader>o").describe("Open a new session tab"),
+      tab_close: z.string().optional().default("<leader>w").describe("Close the current session tab"),
+      tab_next: z.string().optional().default("<leader>]").describe("Next session tab"),
+      tab_previous: z.string().optional().default("<leader>[").describe("Previous session tab"),
       session_timeline: z.string().optional().default("<leader>g").describe("Show session timeline"),
       session_share: z.string().optional().default("<leader>s").describe("Share current session"),
       session_unshare: z.string().optional().default("none").describe("Unshare current session"),
@@ -360,6 +374,7 @@ export namespace Config {
       messages_copy: z.string().optional().default("<leader>y").describe("Copy message"),
       messages_undo: z.string().optional().default("<leader>u").describe("Undo message"),
       messages_redo: z.string().optional().default("<leader>r").describe("Redo message"),
+      messages_search: z.string().optional().default("<leader>/").describe("Search messages"),
       model_list: z.string().optional().default("<leader>m").describe("List available models"),
       model_cycle_recent: z.string().optional().default("f2").describe("Next recent model"),
       model_cycle_recent_reverse: z.string().optional().default("shift+f2").describe("Previous recent model"),
@@ -397,8 +412,25 @@ export namespace Config {
       ref: "KeybindsConfig",
     })
 
+  export const NotificationMethod = z.enum(["osc9", "osc777", "bell", "command", "none"]).meta({
+    ref: "NotificationMethodConfig",
+  })
+  export type NotificationMethod = z.infer<typeof NotificationMethod>
+
   export const TUI = z.object({
     scroll_speed: z.number().min(1).optional().default(2).describe("TUI scroll speed"),
+    notifications: z
+      .object({
+        idle: NotificationMethod.optional().describe("How to notify when a session finishes its work"),
+        error: NotificationMethod.optional().describe("How to notify when a session fails"),
+        permission: NotificationMethod.optional().describe("How to notify when a permission is requested"),
+        command: z
+          .string()
+          .optional()
+          .describe("Command run by the command method with the title and message as arguments, e.g. notify-send"),
+      })
+      .optional()
+      .describe("Notifications sent while the terminal is not focused, by OSC 9 by default"),
   })
 
   export const Layout = z.enum(["auto", "stretch"]).meta({
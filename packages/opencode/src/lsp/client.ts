@@ -30,6 +30,17 @@ export namespace LSPClient {
       z.object({
         serverID: z.string(),
         path: z.string(),
+        diagnostics: z
+          .object({
+            range: z.object({
+              start: z.object({ line: z.number(), character: z.number() }),
+              end: z.object({ line: z.number(), character: z.number() }),
+            }),
+            severity: z.number().optional(),
+            source: z.string().optional(),
+            message: z.string(),
+          })
+          .array(),
       }),
     ),
   }
@@ -44,6 +55,7 @@ export namespace LSPClient {
     )
 
     const diagnostics = new Map<string, Diagnostic[]>()
+    const waiters = new Set<(path: string) => void>()
     connection.onNotification("textDocument/publishDiagnostics", (params) => {
       const path = new URL(params.uri).pathname
       l.info("textDocument/publishDiagnostics", {
@@ -51,8 +63,11 @@ export namespace LSPClient {
       })
       const exists = diagnostics.has(path)
       diagnostics.set(path, params.diagnostics)
+      Bus.publish(Event.Diagnostics, { path, serverID: input.serverID, diagnostics: params.diagnostics })
+      // typescript first publishes the syntactic diagnostics of a file, keep
+      // waiting for the semantic ones
       if (!exists && input.serverID === "typescript") return
-      Bus.publish(Event.Diagnostics, { path, serverID: input.serverID })
+      for (const waiter of waiters) waiter(path)
     })
     connection.onRequest("window/workDoneProgress/create", (params) => {
       l.info("window/workDoneProgress/create", params)
@@ -173,13 +188,15 @@ export namespace LSPClient {
         let unsub: () => void
         return await withTimeout(
           new Promise<void>((resolve) => {
-            unsub = Bus.subscribe(Event.Diagnostics, (event) => {
-              if (event.properties.path === input.path && event.properties.serverID === result.serverID) {
+            const waiter = (path: string) => {
+              if (path === input.path) {
                 log.info("got diagnostics", input)
                 unsub?.()
                 resolve()
               }
-            })
+            }
+            waiters.add(waiter)
+            unsub = () => waiters.delete(waiter)
           }),
           3000,
         )
@@ -5,6 +5,7 @@ import { Hono } from "hono"
 import { cors } from "hono/cors"
 import { streamSSE } from "hono/streaming"
 import { Session } from "../session"
+import { Storage } from "../storage/sqlite"
 import z from "zod/v4"
 import { Provider } from "../provider/provider"
 import { mapValues } from "remeda"
@@ -66,11 +67,21 @@ export namespace Server {
         log.error("failed", {
           error: err,
         })
+        if (err instanceof Storage.NotFoundError) {
+          return c.json(err.toObject(), {
+            status: 404,
+          })
+        }
         if (err instanceof NamedError) {
           return c.json(err.toObject(), {
             status: 400,
           })
         }
+        if (err instanceof Session.BusyError) {
+          return c.json(new NamedError.Unknown({ message: err.message }).toObject(), {
+            status: 409,
+          })
+        }
         return c.json(new NamedError.Unknown({ message: err.toString() }).toObject(), {
           status: 400,
         })
@@ -373,6 +384,35 @@ export namespace Server {
           return c.json(todos)
         },
       )
+      .get(
+        "/session/:id/diff",
+        describeRoute({
+          description: "Get the diff of the files changed since the start of a session",
+          operationId: "session.diff",
+          responses: {
+            200: {
+              description:
+                "Unified diff of the files changed by the session, against the snapshot taken before it changed files",
+              content: {
+                "application/json": {
+                  schema: resolver(z.string()),
+                },
+              },
+            },
+          },
+        }),
+        validator(
+          "param",
+          z.object({
+            id: z.string().meta({ description: "Session ID" }),
+          }),
+        ),
+        async (c) => {
+          const sessionID = c.req.valid("param").id
+          const diff = await Session.diff(sessionID)
+          return c.json(diff)
+        },
+      )
       .post(
         "/session",
         describeRoute({
@@ -525,6 +565,29 @@ export namespace Server {
           return c.json(result)
         },
       )
+      .post(
+        "/session/import",
+        describeRoute({
+          description: "Import messages into a new session",
+          operationId: "session.import",
+          responses: {
+            200: {
+              description: "200",
+              content: {
+                "application/json": {
+                  schema: resolver(Session.Info),
+                },
+              },
+            },
+          },
+        }),
+        validator("json", Session.importMessages.schema),
+        async (c) => {
+          const body = c.req.valid("json")
+          const result = await Session.importMessages(body)
+          return c.json(result)
+        },
+      )
       .post(
         "/session/:id/abort",
         describeRoute({
@@ -1439,7 +1502,10 @@ export namespace Server {
           },
         }),
         async (c) => {
-          log.info("event connected")
+          // A reconnecting client sends the id of the last event it received,
+          // and is sent the events it missed before the new ones
+          const lastEventID = c.req.header("Last-Event-ID")
+          log.info("event connected", { lastEventID })
           return streamSSE(c, async (stream) => {
             stream.writeSSE({
               data: JSON.stringify({
@@ -1447,8 +1513,17 @@ export namespace Server {
                 properties: {},
               }),
             })
-            const unsub = Bus.subscribeAll(async (event) => {
+            const missed = lastEventID ? Bus.since(Number(lastEventID)) : []
+            if (!missed) log.warn("events missed since the client disconnected are no longer kept", { lastEventID })
+            for (const item of missed ?? []) {
+              stream.writeSSE({
+                id: String(item.id),
+                data: JSON.stringify(item.event),
+              })
+            }
+            const unsub = Bus.subscribeAll(async (event, id) => {
               await stream.writeSSE({
+                id: String(id),
                 data: JSON.stringify(event),
               })
             })
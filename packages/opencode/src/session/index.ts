@@ -17,6 +17,7 @@ import { MessageV2 } from "./message-v2"
 import { Project } from "../project/project"
 import { Instance } from "../project/instance"
 import { SessionPrompt } from "./prompt"
+import { Snapshot } from "../snapshot"
 import { fn } from "@/util/fn"
 
 export namespace Session {
@@ -119,27 +120,51 @@ export namespace Session {
         directory: Instance.directory,
       })
       const msgs = await messages(input.sessionID)
-      for (const msg of msgs) {
-        if (input.messageID && msg.info.id >= input.messageID) break
-        const cloned = await updateMessage({
-          ...msg.info,
-          sessionID: session.id,
-          id: Identifier.ascending("message"),
-        })
+      await cloneMessages(
+        session.id,
+        msgs.filter((msg) => !input.messageID || msg.info.id < input.messageID),
+      )
+      return session
+    },
+  )
 
-        for (const part of msg.parts) {
-          await updatePart({
-            ...part,
-            id: Identifier.ascending("part"),
-            messageID: cloned.id,
-            sessionID: session.id,
-          })
-        }
-      }
+  export const importMessages = fn(
+    z.object({
+      title: z.string().optional(),
+      messages: MessageV2.WithParts.array(),
+    }),
+    async (input) => {
+      const session = await createNext({
+        directory: Instance.directory,
+        title: input.title,
+      })
+      await cloneMessages(session.id, input.messages)
       return session
     },
   )
 
+  // Copies the messages into the session under new IDs, pointing the replies
+  // at the copies of the user messages they answer.
+  async function cloneMessages(sessionID: string, msgs: MessageV2.WithParts[]) {
+    const ids = new Map<string, string>()
+    for (const msg of msgs) {
+      const id = Identifier.ascending("message")
+      ids.set(msg.info.id, id)
+      const info = { ...msg.info, sessionID, id }
+      if (info.role === "assistant" && info.parentID) info.parentID = ids.get(info.parentID)
+      const cloned = await updateMessage(info)
+
+      for (const part of msg.parts) {
+        await updatePart({
+          ...part,
+          id: Identifier.ascending("part"),
+          messageID: cloned.id,
+          sessionID,
+        })
+      }
+    }
+  }
+
   export const touch = fn(Identifier.schema("session"), async (sessionID) => {
     await update(sessionID, (draft) => {
       draft.time.updated = Date.now()
@@ -180,7 +205,8 @@ export namespace Session {
 
   export const get = fn(Identifier.schema("session"), async (id) => {
     const read = await Storage.read<Info>(["sessions", id])
-    return read as Info
+    if (!read) throw new Storage.NotFoundError({ message: `Session not found: ${id}` })
+    return read
   })
 
   export const getShare = fn(Identifier.schema("session"), async (id) => {
@@ -245,14 +271,34 @@ export namespace Session {
     return result
   })
 
+  // The diff of the files changed by the steps of the session, against the
+  // snapshot taken before the first of them. Files only edited outside the
+  // session are left out, but edits made by hand to the files the session
+  // changed are part of their diff.
+  export const diff = fn(Identifier.schema("session"), async (sessionID) => {
+    let hash: string | undefined
+    const files = new Set<string>()
+    for (const message of await messages(sessionID)) {
+      for (const part of message.parts) {
+        if (part.type !== "patch") continue
+        hash ??= part.hash
+        for (const file of part.files) files.add(file)
+      }
+    }
+    if (!hash) return ""
+    return Snapshot.diff(hash, [...files])
+  })
+
   export const getMessage = fn(
     z.object({
       sessionID: Identifier.schema("session"),
       messageID: Identifier.schema("message"),
     }),
     async (input) => {
+      const info = await Storage.read<MessageV2.Info>(["messages", input.sessionID, input.messageID])
+      if (!info) throw new Storage.NotFoundError({ message: `Message not found: ${input.messageID}` })
       return {
-        info: await Storage.read<MessageV2.Info>(["messages", input.sessionID, input.messageID]),
+        info,
         parts: await getParts(input.messageID),
       }
     },
@@ -284,7 +330,7 @@ export namespace Session {
 
   export const remove = fn(Identifier.schema("session"), async (sessionID) => {
     try {
-      const session = await get(sessionID)
+      const session = await get(sessionID).catch(() => undefined)
       if (!session) return;
       for (const child of await children(sessionID)) {
         await remove(child.id)
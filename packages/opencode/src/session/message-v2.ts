@@ -263,6 +263,9 @@ export namespace MessageV2 {
 
   export const Assistant = Base.extend({
     role: z.literal("assistant"),
+    // The user message this is a reply to. Messages stored before it was
+    // recorded, and compaction summaries, have none.
+    parentID: Identifier.schema("message").optional(),
     time: z.object({
       created: z.number(),
       completed: z.number().optional(),
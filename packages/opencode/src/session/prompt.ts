@@ -226,7 +226,11 @@ export namespace SessionPrompt {
           modelID: model.info.id,
         })
       step++
-      await processor.next()
+      // Once queued prompts are picked up, the replies answer the latest one
+      const parentID = (state().queued.get(input.sessionID) ?? [])
+        .map((item) => item.messageID)
+        .reduce((latest, id) => (id > latest ? id : latest), userMsg.info.id)
+      await processor.next(parentID)
       await using _ = defer(async () => {
         await processor.end()
       })
@@ -829,10 +833,11 @@ export namespace SessionPrompt {
     let snapshot: string | undefined
     let blocked = false
 
-    async function createMessage() {
+    async function createMessage(parentID: string) {
       const msg: MessageV2.Info = {
         id: Identifier.ascending("message"),
         role: "assistant",
+        parentID,
         system: input.system,
         mode: input.agent,
         path: {
@@ -867,11 +872,11 @@ export namespace SessionPrompt {
           assistantMsg = undefined
         }
       },
-      async next() {
+      async next(parentID: string) {
         if (assistantMsg) {
           throw new Error("end previous assistant message first")
         }
-        assistantMsg = await createMessage()
+        assistantMsg = await createMessage(parentID)
         return assistantMsg
       },
       get message() {
@@ -1182,6 +1187,10 @@ export namespace SessionPrompt {
     return state().pending.has(sessionID)
   }
 
+  export function assertNotBusy(sessionID: string) {
+    if (isBusy(sessionID)) throw new Session.BusyError(sessionID)
+  }
+
   export function abort(sessionID: string) {
     const controller = state().pending.get(sessionID)
     if (!controller) return false
@@ -1247,6 +1256,7 @@ export namespace SessionPrompt {
 
     const msg: MessageV2.Assistant = {
       id: Identifier.ascending("message"),
+      parentID: userMsg.id,
       sessionID: input.sessionID,
       system: [],
       mode: input.agent,
@@ -1497,6 +1507,7 @@ export namespace SessionPrompt {
 
       const assistantMsg: MessageV2.Assistant = {
         id: Identifier.ascending("message"),
+        parentID: userMsg.id,
         sessionID: input.sessionID,
         system: [],
         mode: agentName,
@@ -7,6 +7,7 @@ import { Log } from "../util/log"
 import { splitWhen } from "remeda"
 import { Storage } from "../storage/sqlite"
 import { Bus } from "../bus"
+import { SessionPrompt } from "./prompt"
 
 export namespace SessionRevert {
   const log = Log.create({ service: "session.revert" })
@@ -19,6 +20,7 @@ export namespace SessionRevert {
   export type RevertInput = z.infer<typeof RevertInput>
 
   export async function revert(input: RevertInput) {
+    SessionPrompt.assertNotBusy(input.sessionID)
     const all = await Session.messages(input.sessionID)
     let lastUser: MessageV2.User | undefined
     const session = await Session.get(input.sessionID)
@@ -64,6 +66,7 @@ export namespace SessionRevert {
 
   export async function unrevert(input: { sessionID: string }) {
     log.info("unreverting", input)
+    SessionPrompt.assertNotBusy(input.sessionID)
     const session = await Session.get(input.sessionID)
     if (!session.revert) return session
     if (session.revert.snapshot) await Snapshot.restore(session.revert.snapshot)
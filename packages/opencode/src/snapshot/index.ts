@@ -122,10 +122,13 @@ export namespace Snapshot {
     }
   }
 
-  export async function diff(hash: string) {
+  // The diff of the worktree against hash, limited to files when given
+  export async function diff(hash: string, files?: string[]) {
     const git = gitdir()
     await $`git --git-dir ${git} add .`.quiet().cwd(Instance.directory).nothrow()
-    const result = await $`git --git-dir=${git} diff ${hash} -- .`.quiet().cwd(Instance.worktree).nothrow()
+    const paths = files ? files.map((file) => path.relative(Instance.worktree, file)) : ["."]
+    if (paths.length === 0) return ""
+    const result = await $`git --git-dir=${git} diff ${hash} -- ${paths}`.quiet().cwd(Instance.worktree).nothrow()
 
     if (result.exitCode !== 0) {
       log.warn("failed to get diff", {
@@ -2,11 +2,20 @@ import { Database } from "bun:sqlite";
 import path from "path";
 import { Global } from "../global";
 import { Log } from "../util/log";
+import { NamedError } from "../util/error";
+import z from "zod/v4";
 
 export namespace Storage {
   const log = Log.create({ service: "storage-sqlite" });
   let db: Database;
 
+  export const NotFoundError = NamedError.create(
+    "NotFoundError",
+    z.object({
+      message: z.string(),
+    }),
+  );
+
   export function setDb(newDb: Database) {
     db = newDb;
   }
@@ -80,6 +89,7 @@ export namespace Storage {
             }
             return current;
         }
+        throw new NotFoundError({ message: `Resource not found: ${key.join("/")}` });
     });
     return transact() as T;
   }
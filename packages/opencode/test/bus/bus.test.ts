@@ -0,0 +1,47 @@
+import { describe, expect, test } from "bun:test"
+import path from "path"
+import z from "zod/v4"
+import { Bus } from "../../src/bus"
+import { Instance } from "../../src/project/instance"
+import { Log } from "../../src/util/log"
+
+const projectRoot = path.join(__dirname, "../..")
+Log.init({ print: false })
+
+const Ping = Bus.event("test.ping", z.object({ n: z.number() }))
+
+describe("Bus.since", () => {
+  test("returns the events published after an id", async () => {
+    await Instance.provide({
+      directory: projectRoot,
+      fn: async () => {
+        const ids: number[] = []
+        const unsub = Bus.subscribeAll((_, id) => ids.push(id))
+        for (const n of [1, 2, 3]) await Bus.publish(Ping, { n })
+        unsub()
+
+        const missed = Bus.since(ids[0])
+        expect(missed?.map((x) => x.event.properties.n)).toEqual([2, 3])
+        expect(Bus.since(ids[2])).toEqual([])
+        // An id the server never sent, e.g. from before a restart
+        expect(Bus.since(ids[2] + 1)).toBeUndefined()
+      },
+    })
+  })
+
+  test("returns undefined once the events are no longer kept", async () => {
+    await Instance.provide({
+      directory: projectRoot,
+      fn: async () => {
+        let first = 0
+        const unsub = Bus.subscribeAll((_, id) => {
+          if (!first) first = id
+        })
+        for (let n = 0; n < 1001; n++) await Bus.publish(Ping, { n })
+        unsub()
+        expect(Bus.since(first - 1)).toBeUndefined()
+        expect(Bus.since(first)?.length).toBe(1000)
+      },
+    })
+  })
+})
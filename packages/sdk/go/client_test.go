@@ -4,10 +4,17 @@ package opencode_test
 
 import (
 	"context"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -334,3 +341,212 @@ type readerFunc func([]byte) (int, error)
 
 func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
 func (f readerFunc) Close() error               { return nil }
+
+func TestUnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "opencode.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets are not supported: %v", err)
+	}
+	var paths []string
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	client := opencode.NewClient(option.WithUnixSocket(socketPath))
+	sessions, err := client.Session.List(context.Background(), opencode.SessionListParams{})
+	if err != nil {
+		t.Fatalf("err should be nil: %s", err.Error())
+	}
+	if sessions == nil || len(*sessions) != 0 {
+		t.Errorf("expected an empty session list, got %v", sessions)
+	}
+	if !reflect.DeepEqual(paths, []string{"/session"}) {
+		t.Errorf("expected request to /session, got %v", paths)
+	}
+}
+
+func TestUnixSocketWithTLS(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "opencode.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets are not supported: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	server.Listener = listener
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig, err := option.LoadTLSConfig(caFile, "", "")
+	if err != nil {
+		t.Fatalf("err should be nil: %s", err.Error())
+	}
+
+	// The test certificate is issued for example.com, which is never resolved.
+	client := opencode.NewClient(
+		option.WithBaseURL("https://example.com/"),
+		option.WithUnixSocket(socketPath),
+		option.WithTLSConfig(tlsConfig),
+		option.WithMaxRetries(0),
+	)
+	if _, err := client.Session.List(context.Background(), opencode.SessionListParams{}); err != nil {
+		t.Fatalf("err should be nil: %s", err.Error())
+	}
+}
+
+func TestTLSConfigAndBearerToken(t *testing.T) {
+	var authorization string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig, err := option.LoadTLSConfig(caFile, "", "")
+	if err != nil {
+		t.Fatalf("err should be nil: %s", err.Error())
+	}
+
+	// Without the CA bundle the server's certificate is not trusted.
+	client := opencode.NewClient(option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	if _, err := client.Session.List(context.Background(), opencode.SessionListParams{}); err == nil {
+		t.Fatal("expected a certificate error")
+	}
+
+	client = opencode.NewClient(
+		option.WithBaseURL(server.URL),
+		option.WithTLSConfig(tlsConfig),
+		option.WithBearerToken("secret"),
+	)
+	if _, err := client.Session.List(context.Background(), opencode.SessionListParams{}); err != nil {
+		t.Fatalf("err should be nil: %s", err.Error())
+	}
+	if authorization != "Bearer secret" {
+		t.Errorf("expected bearer token to be sent, got %q", authorization)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	streamOpen := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/event" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			close(streamOpen)
+			<-r.Context().Done()
+			return
+		}
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := opencode.NewClient(
+		option.WithBaseURL(server.URL),
+		option.WithLimits(option.Limits{
+			Requests: option.Budget{RequestsPerSecond: 100, Burst: 2, MaxInFlight: 2},
+			Streams:  option.Budget{MaxInFlight: 1},
+		}),
+	)
+
+	// An open stream doesn't take up the budget for ordinary requests.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := client.Event.ListStreaming(ctx, opencode.EventListParams{})
+	defer stream.Close()
+	<-streamOpen
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Session.List(context.Background(), opencode.SessionListParams{}); err != nil {
+				t.Errorf("err should be nil: %s", err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", maxInFlight)
+	}
+	// 2 requests start immediately and the other 6 wait for tokens at 100/s.
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected requests to be rate limited, took %s", elapsed)
+	}
+
+	// The stream budget is full, so a second stream waits until its context
+	// gives up.
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer waitCancel()
+	second := client.Event.ListStreaming(waitCtx, opencode.EventListParams{})
+	if err := second.Err(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the second stream to time out, got %v", err)
+	}
+}
+
+func TestLimitsLongPolls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tui/control/next" || r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := opencode.NewClient(
+		option.WithBaseURL(server.URL),
+		option.WithMaxRetries(0),
+		option.WithLimits(option.Limits{
+			Requests: option.Budget{MaxInFlight: 1},
+			Streams:  option.Budget{MaxInFlight: 2},
+		}),
+	)
+
+	// Neither the long-poll nor the request marked as streaming takes up the
+	// single slot of ordinary requests.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.Get(ctx, "/tui/control/next", nil, nil)
+	go client.Get(ctx, "/slow", nil, nil, option.WithStreaming())
+	time.Sleep(20 * time.Millisecond)
+
+	listCtx, listCancel := context.WithTimeout(context.Background(), time.Second)
+	defer listCancel()
+	if _, err := client.Session.List(listCtx, opencode.SessionListParams{}); err != nil {
+		t.Errorf("err should be nil: %s", err.Error())
+	}
+}
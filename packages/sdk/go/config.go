@@ -1755,6 +1755,8 @@ func (r ConfigShare) IsKnown() bool {
 
 // TUI specific settings
 type ConfigTui struct {
+	// Notifications sent while the terminal is not focused, by OSC 9 by default
+	Notifications ConfigTuiNotifications `json:"notifications"`
 	// TUI scroll speed
 	ScrollSpeed float64       `json:"scroll_speed"`
 	JSON        configTuiJSON `json:"-"`
@@ -1762,9 +1764,10 @@ type ConfigTui struct {
 
 // configTuiJSON contains the JSON metadata for the struct [ConfigTui]
 type configTuiJSON struct {
-	ScrollSpeed apijson.Field
-	raw         string
-	ExtraFields map[string]apijson.Field
+	Notifications apijson.Field
+	ScrollSpeed   apijson.Field
+	raw           string
+	ExtraFields   map[string]apijson.Field
 }
 
 func (r *ConfigTui) UnmarshalJSON(data []byte) (err error) {
@@ -1775,6 +1778,39 @@ func (r configTuiJSON) RawJSON() string {
 	return r.raw
 }
 
+// Notifications sent while the terminal is not focused, by OSC 9 by default
+type ConfigTuiNotifications struct {
+	// Command run by the command method with the title and message as arguments,
+	// e.g. notify-send
+	Command string `json:"command"`
+	// How to notify when a session fails
+	Error NotificationMethodConfig `json:"error"`
+	// How to notify when a session finishes its work
+	Idle NotificationMethodConfig `json:"idle"`
+	// How to notify when a permission is requested
+	Permission NotificationMethodConfig   `json:"permission"`
+	JSON       configTuiNotificationsJSON `json:"-"`
+}
+
+// configTuiNotificationsJSON contains the JSON metadata for the struct
+// [ConfigTuiNotifications]
+type configTuiNotificationsJSON struct {
+	Command     apijson.Field
+	Error       apijson.Field
+	Idle        apijson.Field
+	Permission  apijson.Field
+	raw         string
+	ExtraFields map[string]apijson.Field
+}
+
+func (r *ConfigTuiNotifications) UnmarshalJSON(data []byte) (err error) {
+	return apijson.UnmarshalRoot(data, r)
+}
+
+func (r configTuiNotificationsJSON) RawJSON() string {
+	return r.raw
+}
+
 type ConfigWatcher struct {
 	Ignore []string          `json:"ignore"`
 	JSON   configWatcherJSON `json:"-"`
@@ -1807,6 +1843,14 @@ type KeybindsConfig struct {
 	AppExit string `json:"app_exit"`
 	// Show help dialog
 	AppHelp string `json:"app_help"`
+	// Show token usage and cost across sessions
+	AppUsage string `json:"app_usage"`
+	// Review the files changed in the session
+	ChangesList string `json:"changes_list"`
+	// Accept or reject the hunks changed in the session
+	ChangesReview string `json:"changes_review"`
+	// Toggle the diagnostics panel
+	DiagnosticsToggle string `json:"diagnostics_toggle"`
 	// Open external editor
 	EditorOpen string `json:"editor_open"`
 	// @deprecated Close file
@@ -1851,6 +1895,8 @@ type KeybindsConfig struct {
 	MessagesRedo string `json:"messages_redo"`
 	// @deprecated use messages_undo. Revert message
 	MessagesRevert string `json:"messages_revert"`
+	// Search messages
+	MessagesSearch string `json:"messages_search"`
 	// Undo message
 	MessagesUndo string `json:"messages_undo"`
 	// Next recent model
@@ -1859,6 +1905,8 @@ type KeybindsConfig struct {
 	ModelCycleRecentReverse string `json:"model_cycle_recent_reverse"`
 	// List available models
 	ModelList string `json:"model_list"`
+	// Review pending permissions and saved rules
+	PermissionList string `json:"permission_list"`
 	// Create/update AGENTS.md
 	ProjectInit string `json:"project_init"`
 	// Cycle to next child session
@@ -1867,12 +1915,16 @@ type KeybindsConfig struct {
 	SessionChildCycleReverse string `json:"session_child_cycle_reverse"`
 	// Compact the session
 	SessionCompact string `json:"session_compact"`
-	// Export session to editor
+	// Export session to a file
 	SessionExport string `json:"session_export"`
+	// Import a session from an exported file
+	SessionImport string `json:"session_import"`
 	// Interrupt current session
 	SessionInterrupt string `json:"session_interrupt"`
 	// List all sessions
 	SessionList string `json:"session_list"`
+	// Search messages of all sessions
+	SessionSearch string `json:"session_search"`
 	// Create a new session
 	SessionNew string `json:"session_new"`
 	// Share current session
@@ -1881,6 +1933,8 @@ type KeybindsConfig struct {
 	SessionTimeline string `json:"session_timeline"`
 	// Unshare current session
 	SessionUnshare string `json:"session_unshare"`
+	// Manage prompt snippets
+	SnippetList string `json:"snippet_list"`
 	// @deprecated use agent_cycle. Next agent
 	SwitchAgent string `json:"switch_agent"`
 	// @deprecated use agent_cycle_reverse. Previous agent
@@ -1889,13 +1943,25 @@ type KeybindsConfig struct {
 	SwitchMode string `json:"switch_mode"`
 	// @deprecated use agent_cycle_reverse. Previous mode
 	SwitchModeReverse string `json:"switch_mode_reverse"`
+	// Close the current session tab
+	TabClose string `json:"tab_close"`
+	// Open a new session tab
+	TabNew string `json:"tab_new"`
+	// Next session tab
+	TabNext string `json:"tab_next"`
+	// Previous session tab
+	TabPrevious string `json:"tab_previous"`
 	// List available themes
 	ThemeList string `json:"theme_list"`
 	// Toggle thinking blocks
 	ThinkingBlocks string `json:"thinking_blocks"`
+	// Collapse or expand the todo panel
+	TodosToggle string `json:"todos_toggle"`
 	// Toggle tool details
-	ToolDetails string             `json:"tool_details"`
-	JSON        keybindsConfigJSON `json:"-"`
+	ToolDetails string `json:"tool_details"`
+	// Toggle Vim-style modal editing in the prompt
+	VimMode string             `json:"vim_mode"`
+	JSON    keybindsConfigJSON `json:"-"`
 }
 
 // keybindsConfigJSON contains the JSON metadata for the struct [KeybindsConfig]
@@ -1905,6 +1971,10 @@ type keybindsConfigJSON struct {
 	AgentList                apijson.Field
 	AppExit                  apijson.Field
 	AppHelp                  apijson.Field
+	AppUsage                 apijson.Field
+	ChangesList              apijson.Field
+	ChangesReview            apijson.Field
+	DiagnosticsToggle        apijson.Field
 	EditorOpen               apijson.Field
 	FileClose                apijson.Field
 	FileDiffToggle           apijson.Field
@@ -1927,28 +1997,39 @@ type keybindsConfigJSON struct {
 	MessagesPrevious         apijson.Field
 	MessagesRedo             apijson.Field
 	MessagesRevert           apijson.Field
+	MessagesSearch           apijson.Field
 	MessagesUndo             apijson.Field
 	ModelCycleRecent         apijson.Field
 	ModelCycleRecentReverse  apijson.Field
 	ModelList                apijson.Field
+	PermissionList           apijson.Field
 	ProjectInit              apijson.Field
 	SessionChildCycle        apijson.Field
 	SessionChildCycleReverse apijson.Field
 	SessionCompact           apijson.Field
 	SessionExport            apijson.Field
+	SessionImport            apijson.Field
 	SessionInterrupt         apijson.Field
 	SessionList              apijson.Field
+	SessionSearch            apijson.Field
 	SessionNew               apijson.Field
 	SessionShare             apijson.Field
 	SessionTimeline          apijson.Field
 	SessionUnshare           apijson.Field
+	SnippetList              apijson.Field
 	SwitchAgent              apijson.Field
 	SwitchAgentReverse       apijson.Field
 	SwitchMode               apijson.Field
 	SwitchModeReverse        apijson.Field
+	TabClose                 apijson.Field
+	TabNew                   apijson.Field
+	TabNext                  apijson.Field
+	TabPrevious              apijson.Field
 	ThemeList                apijson.Field
 	ThinkingBlocks           apijson.Field
+	TodosToggle              apijson.Field
 	ToolDetails              apijson.Field
+	VimMode                  apijson.Field
 	raw                      string
 	ExtraFields              map[string]apijson.Field
 }
@@ -2055,6 +2136,24 @@ func (r McpRemoteConfigType) IsKnown() bool {
 	return false
 }
 
+type NotificationMethodConfig string
+
+const (
+	NotificationMethodConfigOsc9    NotificationMethodConfig = "osc9"
+	NotificationMethodConfigOsc777  NotificationMethodConfig = "osc777"
+	NotificationMethodConfigBell    NotificationMethodConfig = "bell"
+	NotificationMethodConfigCommand NotificationMethodConfig = "command"
+	NotificationMethodConfigNone    NotificationMethodConfig = "none"
+)
+
+func (r NotificationMethodConfig) IsKnown() bool {
+	switch r {
+	case NotificationMethodConfigOsc9, NotificationMethodConfigOsc777, NotificationMethodConfigBell, NotificationMethodConfigCommand, NotificationMethodConfigNone:
+		return true
+	}
+	return false
+}
+
 type ConfigGetParams struct {
 	Directory param.Field[string] `query:"directory"`
 }
@@ -0,0 +1,99 @@
+package opencode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sst/opencode-sdk-go/internal/apierror"
+)
+
+// Kinds of failure, for use with errors.Is. They match both the [*Error]
+// returned for HTTP responses with the corresponding status code and the
+// [*SessionError] reported for a failed message:
+//
+//	if errors.Is(err, opencode.ErrNotFound) {
+//		// the session no longer exists
+//	}
+var (
+	// ErrNotFound matches 404 responses, which the server sends when a
+	// session or message doesn't exist.
+	ErrNotFound = apierror.ErrNotFound
+	// ErrConflict matches 409 responses, which the server sends when a
+	// session is reverted while it is busy.
+	ErrConflict = apierror.ErrConflict
+	// ErrAuth matches 401 and 403 responses, and session errors caused by the
+	// provider rejecting its credentials.
+	ErrAuth = apierror.ErrAuth
+	// ErrRateLimited matches 429 responses.
+	ErrRateLimited = apierror.ErrRateLimited
+	// ErrOutputLength matches session errors caused by the model's reply
+	// exceeding its maximum output length.
+	ErrOutputLength = apierror.ErrOutputLength
+	// ErrAborted matches session errors caused by the message being aborted.
+	ErrAborted = apierror.ErrAborted
+)
+
+// SessionError is a failure reported by the server while it was processing a
+// message, either in a session.error event or on the [AssistantMessage]
+// itself.
+type SessionError struct {
+	// Name is the kind of error reported by the server, such as
+	// "ProviderAuthError", "UnknownError", "MessageOutputLengthError" or
+	// "MessageAbortedError".
+	Name string
+	// Message describes the error. It may be empty.
+	Message string
+	// ProviderID is set for provider authentication errors.
+	ProviderID string
+	// SessionID is the session the error occurred in, if known.
+	SessionID string
+}
+
+func (e *SessionError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s: %s", e.Name, e.Message)
+	}
+	return e.Name
+}
+
+func (e *SessionError) Is(target error) bool {
+	switch target {
+	case ErrAuth:
+		return e.Name == "ProviderAuthError"
+	case ErrOutputLength:
+		return e.Name == "MessageOutputLengthError"
+	case ErrAborted:
+		return e.Name == "MessageAbortedError"
+	}
+	return false
+}
+
+// AsError returns the error as a [*SessionError], or nil if the message did
+// not fail.
+func (r AssistantMessageError) AsError() error {
+	return newSessionError(string(r.Name), r.JSON.Data.Raw(), "")
+}
+
+// AsError returns the error as a [*SessionError], or nil if the event carries
+// no error.
+func (r EventListResponseEventSessionErrorProperties) AsError() error {
+	return newSessionError(string(r.Error.Name), r.Error.JSON.Data.Raw(), r.SessionID)
+}
+
+func newSessionError(name string, rawData string, sessionID string) error {
+	if name == "" {
+		return nil
+	}
+	err := &SessionError{Name: name, SessionID: sessionID}
+	// The variants of the error union can't be told apart by their shape, so
+	// the data is decoded here instead of going through AsUnion.
+	var data struct {
+		Message    string `json:"message"`
+		ProviderID string `json:"providerID"`
+	}
+	if rawData != "" && json.Unmarshal([]byte(rawData), &data) == nil {
+		err.Message = data.Message
+		err.ProviderID = data.ProviderID
+	}
+	return err
+}
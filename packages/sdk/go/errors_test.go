@@ -0,0 +1,99 @@
+package opencode_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/option"
+)
+
+func TestErrorKindsFromResponses(t *testing.T) {
+	// The bodies are the ones the server's error handler sends.
+	cases := map[int]struct {
+		body string
+		kind error
+	}{
+		http.StatusNotFound:        {`{"name":"NotFoundError","data":{"message":"Session not found: ses_1"}}`, opencode.ErrNotFound},
+		http.StatusConflict:        {`{"name":"UnknownError","data":{"message":"Session ses_1 is busy"}}`, opencode.ErrConflict},
+		http.StatusUnauthorized:    {`{}`, opencode.ErrAuth},
+		http.StatusForbidden:       {`{}`, opencode.ErrAuth},
+		http.StatusTooManyRequests: {`{}`, opencode.ErrRateLimited},
+	}
+	for status, c := range cases {
+		kind := c.kind
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(c.body))
+		}))
+		client := opencode.NewClient(option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+		_, err := client.Session.Get(context.Background(), "ses_1", opencode.SessionGetParams{})
+		server.Close()
+
+		wrapped := fmt.Errorf("loading session: %w", err)
+		if !errors.Is(wrapped, kind) {
+			t.Errorf("expected %d to match %v, got %v", status, kind, err)
+		}
+		if errors.Is(wrapped, opencode.ErrAborted) {
+			t.Errorf("expected %d not to match %v", status, opencode.ErrAborted)
+		}
+		var apierr *opencode.Error
+		if !errors.As(wrapped, &apierr) || apierr.StatusCode != status {
+			t.Errorf("expected an *opencode.Error with status %d, got %v", status, err)
+		}
+	}
+}
+
+func TestErrorKindsFromBadRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"name":"ProviderModelNotFoundError","data":{"providerID":"p","modelID":"m"}}`))
+	}))
+	defer server.Close()
+	client := opencode.NewClient(option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	_, err := client.Session.Get(context.Background(), "ses_1", opencode.SessionGetParams{})
+	for _, kind := range []error{opencode.ErrNotFound, opencode.ErrConflict, opencode.ErrAuth, opencode.ErrRateLimited} {
+		if errors.Is(err, kind) {
+			t.Errorf("expected a bad request not to match %v", kind)
+		}
+	}
+}
+
+func TestErrorKindsFromSessionErrors(t *testing.T) {
+	cases := map[string]error{
+		`{"name":"ProviderAuthError","data":{"providerID":"anthropic","message":"bad key"}}`: opencode.ErrAuth,
+		`{"name":"MessageOutputLengthError","data":{}}`:                                     opencode.ErrOutputLength,
+		`{"name":"MessageAbortedError","data":{"message":"aborted"}}`:                       opencode.ErrAborted,
+	}
+	for raw, kind := range cases {
+		event := parseEvent(t, `{"type":"session.error","properties":{"sessionID":"ses_1","error":`+raw+`}}`)
+		err := event.(opencode.EventListResponseEventSessionError).Properties.AsError()
+		if !errors.Is(err, kind) {
+			t.Errorf("expected %s to match %v, got %v", raw, kind, err)
+		}
+		var sessionErr *opencode.SessionError
+		if !errors.As(err, &sessionErr) || sessionErr.SessionID != "ses_1" {
+			t.Errorf("expected a *opencode.SessionError for ses_1, got %v", err)
+		}
+	}
+
+	var info opencode.AssistantMessageError
+	if err := json.Unmarshal([]byte(`{"name":"ProviderAuthError","data":{"providerID":"anthropic","message":"bad key"}}`), &info); err != nil {
+		t.Fatal(err)
+	}
+	var sessionErr *opencode.SessionError
+	if err := info.AsError(); !errors.As(err, &sessionErr) || sessionErr.ProviderID != "anthropic" || sessionErr.Message != "bad key" {
+		t.Errorf("unexpected error %#v", err)
+	}
+
+	if err := (opencode.AssistantMessageError{}).AsError(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
@@ -298,14 +298,16 @@ func (r eventListResponseEventLspClientDiagnosticsJSON) RawJSON() string {
 func (r EventListResponseEventLspClientDiagnostics) implementsEventListResponse() {}
 
 type EventListResponseEventLspClientDiagnosticsProperties struct {
-	Path     string                                                   `json:"path,required"`
-	ServerID string                                                   `json:"serverID,required"`
-	JSON     eventListResponseEventLspClientDiagnosticsPropertiesJSON `json:"-"`
+	Diagnostics []EventListResponseEventLspClientDiagnosticsPropertiesDiagnostic `json:"diagnostics,required"`
+	Path        string                                                           `json:"path,required"`
+	ServerID    string                                                           `json:"serverID,required"`
+	JSON        eventListResponseEventLspClientDiagnosticsPropertiesJSON         `json:"-"`
 }
 
 // eventListResponseEventLspClientDiagnosticsPropertiesJSON contains the JSON
 // metadata for the struct [EventListResponseEventLspClientDiagnosticsProperties]
 type eventListResponseEventLspClientDiagnosticsPropertiesJSON struct {
+	Diagnostics apijson.Field
 	Path        apijson.Field
 	ServerID    apijson.Field
 	raw         string
@@ -320,6 +322,106 @@ func (r eventListResponseEventLspClientDiagnosticsPropertiesJSON) RawJSON() stri
 	return r.raw
 }
 
+type EventListResponseEventLspClientDiagnosticsPropertiesDiagnostic struct {
+	Message  string                                                              `json:"message,required"`
+	Range    EventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRange `json:"range,required"`
+	Severity float64                                                             `json:"severity"`
+	Source   string                                                              `json:"source"`
+	JSON     eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticJSON  `json:"-"`
+}
+
+// eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticJSON contains
+// the JSON metadata for the struct
+// [EventListResponseEventLspClientDiagnosticsPropertiesDiagnostic]
+type eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticJSON struct {
+	Message     apijson.Field
+	Range       apijson.Field
+	Severity    apijson.Field
+	Source      apijson.Field
+	raw         string
+	ExtraFields map[string]apijson.Field
+}
+
+func (r *EventListResponseEventLspClientDiagnosticsPropertiesDiagnostic) UnmarshalJSON(data []byte) (err error) {
+	return apijson.UnmarshalRoot(data, r)
+}
+
+func (r eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticJSON) RawJSON() string {
+	return r.raw
+}
+
+type EventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRange struct {
+	End   EventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeEnd   `json:"end,required"`
+	Start EventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeStart `json:"start,required"`
+	JSON  eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeJSON  `json:"-"`
+}
+
+// eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeJSON
+// contains the JSON metadata for the struct
+// [EventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRange]
+type eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeJSON struct {
+	End         apijson.Field
+	Start       apijson.Field
+	raw         string
+	ExtraFields map[string]apijson.Field
+}
+
+func (r *EventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRange) UnmarshalJSON(data []byte) (err error) {
+	return apijson.UnmarshalRoot(data, r)
+}
+
+func (r eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeJSON) RawJSON() string {
+	return r.raw
+}
+
+type EventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeEnd struct {
+	Character float64                                                                    `json:"character,required"`
+	Line      float64                                                                    `json:"line,required"`
+	JSON      eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeEndJSON `json:"-"`
+}
+
+// eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeEndJSON
+// contains the JSON metadata for the struct
+// [EventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeEnd]
+type eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeEndJSON struct {
+	Character   apijson.Field
+	Line        apijson.Field
+	raw         string
+	ExtraFields map[string]apijson.Field
+}
+
+func (r *EventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeEnd) UnmarshalJSON(data []byte) (err error) {
+	return apijson.UnmarshalRoot(data, r)
+}
+
+func (r eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeEndJSON) RawJSON() string {
+	return r.raw
+}
+
+type EventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeStart struct {
+	Character float64                                                                      `json:"character,required"`
+	Line      float64                                                                      `json:"line,required"`
+	JSON      eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeStartJSON `json:"-"`
+}
+
+// eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeStartJSON
+// contains the JSON metadata for the struct
+// [EventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeStart]
+type eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeStartJSON struct {
+	Character   apijson.Field
+	Line        apijson.Field
+	raw         string
+	ExtraFields map[string]apijson.Field
+}
+
+func (r *EventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeStart) UnmarshalJSON(data []byte) (err error) {
+	return apijson.UnmarshalRoot(data, r)
+}
+
+func (r eventListResponseEventLspClientDiagnosticsPropertiesDiagnosticRangeStartJSON) RawJSON() string {
+	return r.raw
+}
+
 type EventListResponseEventLspClientDiagnosticsType string
 
 const (
@@ -0,0 +1,48 @@
+package opencode
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/sst/opencode-sdk-go/internal/requestconfig"
+	"github.com/sst/opencode-sdk-go/option"
+	"github.com/sst/opencode-sdk-go/packages/ssestream"
+)
+
+// ListStreamingResumable is like [EventService.ListStreaming], but the returned
+// stream reconnects whenever the connection drops, sending the Last-Event-ID
+// header so the server can replay any events that were missed. The zero
+// [ssestream.ReconnectPolicy] reconnects until ctx is done, giving up early only
+// on client errors (4xx other than 408 and 429) that retrying cannot fix.
+func (r *EventService) ListStreamingResumable(ctx context.Context, query EventListParams, policy ssestream.ReconnectPolicy, opts ...option.RequestOption) (stream *ssestream.ReconnectingStream[EventListResponse]) {
+	opts = slices.Concat(r.Options, opts)
+	// The stream has its own backoff, so don't stack the request retries on top.
+	opts = append([]option.RequestOption{option.WithHeader("Accept", "text/event-stream"), option.WithMaxRetries(0)}, opts...)
+	if policy.ShouldReconnect == nil {
+		policy.ShouldReconnect = shouldReconnectEvents
+	}
+	path := "event"
+	connect := func(ctx context.Context, lastEventID string) (*http.Response, error) {
+		var raw *http.Response
+		reqOpts := opts
+		if lastEventID != "" {
+			reqOpts = append(slices.Clone(opts), option.WithHeader("Last-Event-ID", lastEventID))
+		}
+		err := requestconfig.ExecuteNewRequest(ctx, http.MethodGet, path, query, &raw, reqOpts...)
+		return raw, err
+	}
+	return ssestream.NewReconnectingStream[EventListResponse](ctx, connect, policy)
+}
+
+func shouldReconnectEvents(err error) bool {
+	var apierr *Error
+	if errors.As(err, &apierr) {
+		return apierr.StatusCode < 400 ||
+			apierr.StatusCode >= 500 ||
+			apierr.StatusCode == http.StatusRequestTimeout ||
+			apierr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
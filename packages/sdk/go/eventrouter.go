@@ -0,0 +1,176 @@
+package opencode
+
+import (
+	"reflect"
+	"sync"
+)
+
+// EventStream is satisfied by the streams returned from
+// [EventService.ListStreaming] and [EventService.ListStreamingResumable].
+type EventStream interface {
+	Next() bool
+	Current() EventListResponse
+	Err() error
+}
+
+// EventRouter dispatches events from the event stream to handlers registered
+// for a specific event type, so consumers don't need to write a type switch
+// over [EventListResponseUnion] themselves.
+//
+//	router := opencode.NewEventRouter()
+//	opencode.HandleEvent(router, func(e opencode.EventListResponseEventSessionIdle) {
+//		fmt.Println("session idle:", e.Properties.SessionID)
+//	}, opencode.ForSession(sessionID))
+//	err := router.Run(client.Event.ListStreaming(ctx, opencode.EventListParams{}))
+//
+// An EventRouter is safe for concurrent use. Handlers are called on the
+// goroutine that calls [EventRouter.Dispatch] or [EventRouter.Run], in the
+// order they were registered.
+type EventRouter struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[reflect.Type][]eventRoute
+	fallback []eventRoute
+}
+
+type eventRoute struct {
+	id      int
+	filters []EventFilter
+	fn      func(EventListResponseUnion)
+}
+
+// EventFilter restricts which events are delivered to a handler.
+type EventFilter func(EventListResponseUnion) bool
+
+// ForSession returns an [EventFilter] which only accepts events that belong to
+// the given session. Events which are not tied to any session, such as
+// installation.updated or file.edited, are never delivered.
+func ForSession(sessionID string) EventFilter {
+	return func(event EventListResponseUnion) bool {
+		id, ok := EventSessionID(event)
+		return ok && id == sessionID
+	}
+}
+
+func NewEventRouter() *EventRouter {
+	return &EventRouter{handlers: map[reflect.Type][]eventRoute{}}
+}
+
+// HandleEvent registers handler for events of type T, for example
+// [EventListResponseEventTodoUpdated]. The handler only receives events that
+// pass every filter. The returned function removes the handler.
+func HandleEvent[T EventListResponseUnion](r *EventRouter, handler func(T), filters ...EventFilter) (remove func()) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	fn := func(event EventListResponseUnion) {
+		if casted, ok := event.(T); ok {
+			handler(casted)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	id := r.nextID
+	r.handlers[typ] = append(r.handlers[typ], eventRoute{id: id, filters: filters, fn: fn})
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.handlers[typ] = removeEventRoute(r.handlers[typ], id)
+	}
+}
+
+// HandleAll registers handler for every event which passes the filters,
+// regardless of type. The returned function removes the handler.
+func (r *EventRouter) HandleAll(handler func(EventListResponseUnion), filters ...EventFilter) (remove func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	id := r.nextID
+	r.fallback = append(r.fallback, eventRoute{id: id, filters: filters, fn: handler})
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.fallback = removeEventRoute(r.fallback, id)
+	}
+}
+
+// Dispatch delivers a single event to the matching handlers.
+func (r *EventRouter) Dispatch(event EventListResponseUnion) {
+	if event == nil {
+		return
+	}
+
+	r.mu.RLock()
+	routes := make([]eventRoute, 0, len(r.handlers[reflect.TypeOf(event)])+len(r.fallback))
+	routes = append(routes, r.handlers[reflect.TypeOf(event)]...)
+	routes = append(routes, r.fallback...)
+	r.mu.RUnlock()
+
+	for _, route := range routes {
+		if route.accepts(event) {
+			route.fn(event)
+		}
+	}
+}
+
+// Run dispatches every event from stream until it ends, and returns the
+// stream's error.
+func (r *EventRouter) Run(stream EventStream) error {
+	for stream.Next() {
+		r.Dispatch(stream.Current().AsUnion())
+	}
+	return stream.Err()
+}
+
+func (route eventRoute) accepts(event EventListResponseUnion) bool {
+	for _, filter := range route.filters {
+		if filter != nil && !filter(event) {
+			return false
+		}
+	}
+	return true
+}
+
+func removeEventRoute(routes []eventRoute, id int) []eventRoute {
+	for i, route := range routes {
+		if route.id == id {
+			return append(routes[:i:i], routes[i+1:]...)
+		}
+	}
+	return routes
+}
+
+// EventSessionID returns the ID of the session an event belongs to. The
+// second result is false for events which are not tied to a session.
+func EventSessionID(event EventListResponseUnion) (string, bool) {
+	switch e := event.(type) {
+	case EventListResponseEventMessageUpdated:
+		return e.Properties.Info.SessionID, true
+	case EventListResponseEventMessageRemoved:
+		return e.Properties.SessionID, true
+	case EventListResponseEventMessagePartUpdated:
+		return e.Properties.Part.SessionID, true
+	case EventListResponseEventMessagePartRemoved:
+		return e.Properties.SessionID, true
+	case EventListResponseEventSessionCompacted:
+		return e.Properties.SessionID, true
+	case EventListResponseEventPermissionUpdated:
+		return e.Properties.SessionID, true
+	case EventListResponseEventPermissionReplied:
+		return e.Properties.SessionID, true
+	case EventListResponseEventTodoUpdated:
+		return e.Properties.SessionID, true
+	case EventListResponseEventSessionIdle:
+		return e.Properties.SessionID, true
+	case EventListResponseEventSessionUpdated:
+		return e.Properties.Info.ID, true
+	case EventListResponseEventSessionDeleted:
+		return e.Properties.Info.ID, true
+	case EventListResponseEventSessionError:
+		// Errors may be reported before a session exists.
+		return e.Properties.SessionID, e.Properties.SessionID != ""
+	}
+	return "", false
+}
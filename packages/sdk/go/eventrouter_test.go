@@ -0,0 +1,71 @@
+package opencode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+func parseEvent(t *testing.T, raw string) opencode.EventListResponseUnion {
+	t.Helper()
+	var event opencode.EventListResponse
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		t.Fatalf("failed to parse event: %v", err)
+	}
+	return event.AsUnion()
+}
+
+func TestEventRouterDispatchesByType(t *testing.T) {
+	router := opencode.NewEventRouter()
+
+	var idle []string
+	opencode.HandleEvent(router, func(e opencode.EventListResponseEventSessionIdle) {
+		idle = append(idle, e.Properties.SessionID)
+	})
+	var todos int
+	opencode.HandleEvent(router, func(e opencode.EventListResponseEventTodoUpdated) {
+		todos += len(e.Properties.Todos)
+	})
+	var all int
+	router.HandleAll(func(opencode.EventListResponseUnion) { all++ })
+
+	router.Dispatch(parseEvent(t, `{"type":"session.idle","properties":{"sessionID":"ses_1"}}`))
+	router.Dispatch(parseEvent(t, `{"type":"todo.updated","properties":{"sessionID":"ses_1","todos":[{"id":"1","content":"a","status":"pending","priority":"high"}]}}`))
+	router.Dispatch(parseEvent(t, `{"type":"file.edited","properties":{"file":"main.go"}}`))
+
+	if len(idle) != 1 || idle[0] != "ses_1" {
+		t.Errorf("expected one idle event for ses_1, got %v", idle)
+	}
+	if todos != 1 {
+		t.Errorf("expected 1 todo, got %d", todos)
+	}
+	if all != 3 {
+		t.Errorf("expected catch-all handler to see 3 events, got %d", all)
+	}
+}
+
+func TestEventRouterSessionFilter(t *testing.T) {
+	router := opencode.NewEventRouter()
+
+	var got []string
+	remove := router.HandleAll(func(event opencode.EventListResponseUnion) {
+		id, _ := opencode.EventSessionID(event)
+		got = append(got, id)
+	}, opencode.ForSession("ses_1"))
+
+	router.Dispatch(parseEvent(t, `{"type":"session.idle","properties":{"sessionID":"ses_1"}}`))
+	router.Dispatch(parseEvent(t, `{"type":"session.idle","properties":{"sessionID":"ses_2"}}`))
+	router.Dispatch(parseEvent(t, `{"type":"message.part.removed","properties":{"sessionID":"ses_1","messageID":"msg_1","partID":"prt_1"}}`))
+	router.Dispatch(parseEvent(t, `{"type":"installation.updated","properties":{"version":"1.0.0"}}`))
+
+	if len(got) != 2 {
+		t.Errorf("expected 2 events for ses_1, got %v", got)
+	}
+
+	remove()
+	router.Dispatch(parseEvent(t, `{"type":"session.idle","properties":{"sessionID":"ses_1"}}`))
+	if len(got) != 2 {
+		t.Errorf("expected removed handler not to be called, got %v", got)
+	}
+}
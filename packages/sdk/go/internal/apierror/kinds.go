@@ -0,0 +1,34 @@
+package apierror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Sentinel errors describing the kind of a failure. [Error] matches them with
+// errors.Is based on its status code, and they are re-exported by the opencode
+// package, which also matches them against session errors.
+var (
+	ErrNotFound     = errors.New("opencode: not found")
+	ErrConflict     = errors.New("opencode: conflict")
+	ErrAuth         = errors.New("opencode: authentication failed")
+	ErrRateLimited  = errors.New("opencode: rate limited")
+	ErrOutputLength = errors.New("opencode: message output length exceeded")
+	ErrAborted      = errors.New("opencode: aborted")
+)
+
+// Is reports whether the response's status code falls into the kind of failure
+// target describes.
+func (r *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return r.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return r.StatusCode == http.StatusConflict
+	case ErrAuth:
+		return r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return r.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
@@ -0,0 +1,380 @@
+// Package cassette records the HTTP traffic of an opencode client to a file
+// and replays it later, so integrations can be tested without a running
+// server.
+//
+//	c, err := cassette.New("testdata/prompt.json", cassette.ModeAuto)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer c.Close()
+//	client := opencode.NewClient(c.Option(), option.WithMaxRetries(0))
+//
+// In [ModeAuto] the cassette replays the file if it exists and records a new
+// one otherwise. Event streams from /event are recorded up to the point where
+// the client closed them, and replayed as a stream which ends after the last
+// recorded event, also when it is resumable.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sst/opencode-sdk-go/option"
+	"github.com/sst/opencode-sdk-go/packages/ssestream"
+)
+
+type Mode int
+
+const (
+	// ModeAuto replays the cassette if its file exists and records otherwise.
+	ModeAuto Mode = iota
+	// ModeRecord always sends requests to the server and overwrites the file.
+	ModeRecord
+	// ModeReplay never contacts the server and fails requests which have no
+	// matching interaction in the file.
+	ModeReplay
+)
+
+// ErrNoInteraction is returned during replay when no unused recorded
+// interaction matches a request.
+var ErrNoInteraction = errors.New("cassette: no matching interaction")
+
+// sensitiveHeaders are the response headers left out of recordings.
+var sensitiveHeaders = []string{"Set-Cookie", "Authorization", "Proxy-Authorization", "Www-Authenticate", "Proxy-Authenticate"}
+
+// Interaction is a single recorded request/response pair.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the recorded form of an HTTP request. Headers are not recorded,
+// so credentials sent by the client never end up on disk.
+type Request struct {
+	Method string `json:"method"`
+	// URL is the request path and query, without the scheme and host, so that
+	// cassettes replay against any base URL.
+	URL  string `json:"url"`
+	Body string `json:"body,omitempty"`
+}
+
+// Response is the recorded form of an HTTP response. Headers which carry
+// credentials, such as Set-Cookie, are not recorded.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Matcher reports whether a recorded request matches an outgoing one. body is
+// the outgoing request's body.
+type Matcher func(req *http.Request, body []byte, recorded Request) bool
+
+// MatchMethodAndURL matches requests by method, path and query only, which is
+// useful when request bodies contain generated IDs or timestamps.
+func MatchMethodAndURL(req *http.Request, body []byte, recorded Request) bool {
+	return req.Method == recorded.Method && req.URL.RequestURI() == recorded.URL
+}
+
+// MatchMethodURLAndBody additionally requires the bodies to be equal,
+// ignoring JSON formatting. It is the default matcher.
+func MatchMethodURLAndBody(req *http.Request, body []byte, recorded Request) bool {
+	return MatchMethodAndURL(req, body, recorded) && equalBodies(body, []byte(recorded.Body))
+}
+
+// Cassette is a record/replay middleware. It is safe for concurrent use.
+type Cassette struct {
+	path    string
+	mode    Mode
+	matcher Matcher
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+	// streams holds the event streams which are still being recorded.
+	streams map[*Interaction]*recordingBody
+}
+
+// New opens the cassette at path. In replay mode the file must exist.
+func New(path string, mode Mode) (*Cassette, error) {
+	c := &Cassette{
+		path:    path,
+		mode:    mode,
+		matcher: MatchMethodURLAndBody,
+		streams: map[*Interaction]*recordingBody{},
+	}
+
+	if c.mode == ModeAuto {
+		c.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			c.mode = ModeReplay
+		}
+	}
+
+	if c.mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: %w", err)
+		}
+		var file cassetteFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("cassette: failed to parse %s: %w", path, err)
+		}
+		c.interactions = file.Interactions
+		c.used = make([]bool, len(file.Interactions))
+	}
+
+	return c, nil
+}
+
+type cassetteFile struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// SetMatcher replaces the matcher used during replay.
+func (c *Cassette) SetMatcher(matcher Matcher) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.matcher = matcher
+}
+
+// Mode returns the mode the cassette resolved to, which is never [ModeAuto].
+func (c *Cassette) Mode() Mode {
+	return c.mode
+}
+
+// Option returns a RequestOption which installs the cassette's middleware.
+func (c *Cassette) Option() option.RequestOption {
+	return option.WithMiddleware(c.Middleware)
+}
+
+// Middleware records or replays a request depending on the cassette's mode.
+func (c *Cassette) Middleware(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if c.mode == ModeReplay {
+		return c.replay(req, body)
+	}
+	return c.record(req, body, next)
+}
+
+func (c *Cassette) replay(req *http.Request, body []byte) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, interaction := range c.interactions {
+		if c.used[i] || !c.matcher(req, body, interaction.Request) {
+			continue
+		}
+		c.used[i] = true
+
+		header := interaction.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	// A stream reconnecting after its recording was replayed ends there.
+	for i, interaction := range c.interactions {
+		if c.used[i] && isEventStream(interaction.Response.Header) && c.matcher(req, body, interaction.Request) {
+			return nil, fmt.Errorf("%w for %s %s: %w", ErrNoInteraction, req.Method, req.URL.RequestURI(), ssestream.ErrStreamEnded)
+		}
+	}
+	return nil, fmt.Errorf("%w for %s %s", ErrNoInteraction, req.Method, req.URL.RequestURI())
+}
+
+func (c *Cassette) record(req *http.Request, body []byte, next option.MiddlewareNext) (*http.Response, error) {
+	interaction := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.RequestURI(),
+			Body:   string(body),
+		},
+	}
+
+	res, err := next(req)
+	if err != nil {
+		return res, err
+	}
+
+	header := res.Header.Clone()
+	for _, key := range sensitiveHeaders {
+		header.Del(key)
+	}
+	interaction.Response = Response{
+		StatusCode: res.StatusCode,
+		Header:     header,
+	}
+	c.mu.Lock()
+	c.interactions = append(c.interactions, interaction)
+	c.mu.Unlock()
+
+	if res.Body == nil {
+		return res, nil
+	}
+
+	if isEventStream(res.Header) {
+		// Streams don't end on their own, so record whatever the client
+		// consumed before closing it.
+		body := &recordingBody{rc: res.Body}
+		body.onClose = func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			interaction.Response.Body = body.String()
+			delete(c.streams, interaction)
+		}
+		c.mu.Lock()
+		c.streams[interaction] = body
+		c.mu.Unlock()
+		res.Body = body
+		return res, nil
+	}
+
+	data, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	interaction.Response.Body = string(data)
+	c.mu.Unlock()
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	return res, nil
+}
+
+// Close saves the cassette if it was recording. Event streams which are still
+// open are saved with whatever has been read from them so far.
+func (c *Cassette) Close() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+	return c.Save()
+}
+
+// Save writes the recorded interactions to the cassette's file.
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	for interaction, body := range c.streams {
+		interaction.Response.Body = body.String()
+	}
+	data, err := json.MarshalIndent(cassetteFile{Interactions: c.interactions}, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
+
+// Unused returns the recorded interactions which have not been replayed,
+// which is handy for asserting that a test made every expected request.
+func (c *Cassette) Unused() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var result []Interaction
+	for i, interaction := range c.interactions {
+		if i < len(c.used) && !c.used[i] {
+			result = append(result, *interaction)
+		}
+	}
+	return result
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func isEventStream(header http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return mediaType == "text/event-stream"
+}
+
+func equalBodies(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var av, bv interface{}
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(b, &bv) != nil {
+		return false
+	}
+	an, _ := json.Marshal(av)
+	bn, _ := json.Marshal(bv)
+	return bytes.Equal(an, bn)
+}
+
+// recordingBody keeps a copy of everything read from a response body.
+type recordingBody struct {
+	rc      io.ReadCloser
+	buf     bytes.Buffer
+	mu      sync.Mutex
+	onClose func()
+	closed  bool
+}
+
+func (b *recordingBody) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	b.mu.Lock()
+	b.buf.Write(p[:n])
+	b.mu.Unlock()
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.mu.Lock()
+	first := !b.closed
+	b.closed = true
+	b.mu.Unlock()
+	if first {
+		b.onClose()
+	}
+	return b.rc.Close()
+}
@@ -0,0 +1,143 @@
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/lib/cassette"
+	"github.com/sst/opencode-sdk-go/option"
+	"github.com/sst/opencode-sdk-go/packages/ssestream"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/find":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Set-Cookie", "session=secret")
+			fmt.Fprint(w, `[{"absolute_offset":0,"line_number":1,"lines":{"text":"hello"},"path":{"text":"a.go"},"submatches":[]}]`)
+		case "/event":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"type\":\"session.idle\",\"properties\":{\"sessionID\":\"ses_1\"}}\n\n")
+			fmt.Fprint(w, "data: {\"type\":\"session.idle\",\"properties\":{\"sessionID\":\"ses_2\"}}\n\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	run := func(baseURL string) ([]string, error) {
+		c, err := cassette.New(path, cassette.ModeAuto)
+		if err != nil {
+			return nil, err
+		}
+		defer c.Close()
+		client := opencode.NewClient(option.WithBaseURL(baseURL), c.Option(), option.WithMaxRetries(0))
+
+		matches, err := client.Find.Text(context.Background(), opencode.FindTextParams{Pattern: opencode.F("hello")})
+		if err != nil {
+			return nil, err
+		}
+		got := []string{(*matches)[0].Path.Text}
+
+		stream := client.Event.ListStreaming(context.Background(), opencode.EventListParams{})
+		for stream.Next() {
+			if idle, ok := stream.Current().AsUnion().(opencode.EventListResponseEventSessionIdle); ok {
+				got = append(got, idle.Properties.SessionID)
+			}
+		}
+		stream.Close()
+		return got, stream.Err()
+	}
+
+	recorded, err := run(server.URL)
+	if err != nil {
+		t.Fatalf("err should be nil: %s", err.Error())
+	}
+	server.Close()
+
+	replayed, err := run("http://127.0.0.1:1")
+	if err != nil {
+		t.Fatalf("err should be nil: %s", err.Error())
+	}
+	if fmt.Sprint(recorded) != "[a.go ses_1 ses_2]" || fmt.Sprint(replayed) != fmt.Sprint(recorded) {
+		t.Errorf("expected replay %v to match recording %v", replayed, recorded)
+	}
+	if requests != 2 {
+		t.Errorf("expected the server to see 2 requests, got %d", requests)
+	}
+	if data, err := os.ReadFile(path); err != nil || strings.Contains(string(data), "secret") {
+		t.Errorf("expected the cookie to be left out of the cassette, got %s %v", data, err)
+	}
+}
+
+func TestReplayResumableStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "id: 1\ndata: {\"type\":\"session.idle\",\"properties\":{\"sessionID\":\"ses_1\"}}\n\n")
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c, err := cassette.New(path, cassette.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := opencode.NewClient(option.WithBaseURL(server.URL), c.Option())
+	stream := client.Event.ListStreaming(context.Background(), opencode.EventListParams{})
+	for stream.Next() {
+	}
+	stream.Close()
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = cassette.New(path, cassette.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client = opencode.NewClient(option.WithBaseURL("http://127.0.0.1:1"), c.Option())
+	resumable := client.Event.ListStreamingResumable(ctx, opencode.EventListParams{}, ssestream.ReconnectPolicy{})
+	defer resumable.Close()
+	var events int
+	for resumable.Next() {
+		events++
+	}
+	if err := resumable.Err(); err != nil || events != 1 || ctx.Err() != nil {
+		t.Errorf("expected the replay to end after 1 event, got %d events and %v", events, err)
+	}
+}
+
+func TestReplayWithoutMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c, err := cassette.New(path, cassette.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = cassette.New(path, cassette.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := opencode.NewClient(option.WithBaseURL("http://127.0.0.1:1"), c.Option(), option.WithMaxRetries(0))
+	_, err = client.Session.List(context.Background(), opencode.SessionListParams{})
+	if !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Errorf("expected ErrNoInteraction, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+// Package otelopencode instruments opencode clients with OpenTelemetry.
+//
+//	client := opencode.NewClient(otelopencode.WithTelemetry())
+//
+// Every HTTP attempt made by the client, including retries, becomes a client
+// span named after the request's method and path template, for example
+// "POST /session/{id}/message". The same attempts are counted and timed in the
+// metrics below, which share the span's method, path template and status
+// attributes:
+//
+//   - http.client.request.duration: a histogram of the time until the
+//     response headers arrived, in seconds
+//   - opencode.client.requests: a counter of attempts
+//   - opencode.client.retries: a counter of attempts which were retries
+//
+// Spans of streaming requests, such as the event stream, end when the
+// response body is closed. The trace context is propagated to the server
+// using the configured propagator.
+package otelopencode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sst/opencode-sdk-go/option"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/sst/opencode-sdk-go/lib/otelopencode"
+
+// Attribute keys recorded on spans and metrics. They follow the OpenTelemetry
+// semantic conventions for HTTP clients.
+const (
+	MethodKey     = attribute.Key("http.request.method")
+	TemplateKey   = attribute.Key("url.template")
+	StatusCodeKey = attribute.Key("http.response.status_code")
+	ResendKey     = attribute.Key("http.request.resend_count")
+	ErrorTypeKey  = attribute.Key("error.type")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// Option configures the instrumentation.
+type Option func(*config)
+
+// WithTracerProvider sets the provider spans are created with. Defaults to the
+// global provider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) { c.tracerProvider = provider }
+}
+
+// WithMeterProvider sets the provider metrics are recorded with. Defaults to
+// the global provider.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) { c.meterProvider = provider }
+}
+
+// WithPropagator sets the propagator used to send the trace context to the
+// server. Defaults to the global propagator.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) { c.propagator = propagator }
+}
+
+// WithTelemetry returns a RequestOption which installs [Middleware].
+func WithTelemetry(opts ...Option) option.RequestOption {
+	return option.WithMiddleware(Middleware(opts...))
+}
+
+// Middleware returns a middleware which traces and measures every request
+// passing through it.
+func Middleware(opts ...Option) option.Middleware {
+	cfg := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagator:     otel.GetTextMapPropagator(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	tracer := cfg.tracerProvider.Tracer(instrumentationName)
+	meter := cfg.meterProvider.Meter(instrumentationName)
+	// Creating instruments only fails for invalid names, and the meter hands
+	// back a no-op instrument alongside the error, so the errors are dropped.
+	duration, _ := meter.Float64Histogram(
+		"http.client.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of HTTP client requests until the response headers arrive."),
+	)
+	requests, _ := meter.Int64Counter(
+		"opencode.client.requests",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Number of HTTP requests sent by the opencode client, including retries."),
+	)
+	retries, _ := meter.Int64Counter(
+		"opencode.client.retries",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Number of HTTP requests sent by the opencode client which were retries."),
+	)
+
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		template := PathTemplate(req.URL.Path)
+		name := req.Method
+		if template != "" {
+			name += " " + template
+		}
+
+		attrs := []attribute.KeyValue{MethodKey.String(req.Method)}
+		if template != "" {
+			attrs = append(attrs, TemplateKey.String(template))
+		}
+		retryCount, _ := strconv.Atoi(req.Header.Get("X-Stainless-Retry-Count"))
+
+		ctx, span := tracer.Start(req.Context(), name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...),
+		)
+		if retryCount > 0 {
+			span.SetAttributes(ResendKey.Int(retryCount))
+		}
+		req = req.WithContext(ctx)
+		cfg.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		start := time.Now()
+		res, err := next(req)
+		elapsed := time.Since(start)
+
+		switch {
+		case err != nil:
+			attrs = append(attrs, ErrorTypeKey.String(errorType(err)))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		case res != nil:
+			attrs = append(attrs, StatusCodeKey.Int(res.StatusCode))
+			span.SetAttributes(StatusCodeKey.Int(res.StatusCode))
+			if res.StatusCode >= 400 {
+				attrs = append(attrs, ErrorTypeKey.String(strconv.Itoa(res.StatusCode)))
+				span.SetStatus(codes.Error, http.StatusText(res.StatusCode))
+			}
+		}
+
+		set := metric.WithAttributeSet(attribute.NewSet(attrs...))
+		duration.Record(ctx, elapsed.Seconds(), set)
+		requests.Add(ctx, 1, set)
+		if retryCount > 0 {
+			retries.Add(ctx, 1, set)
+		}
+
+		if err != nil || res == nil || res.Body == nil {
+			span.End()
+			return res, err
+		}
+		res.Body = &spanBody{ReadCloser: res.Body, end: sync.OnceFunc(func() { span.End() })}
+		return res, nil
+	}
+}
+
+// errorType describes err for the error.type attribute without including
+// anything as variable as the error's message.
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	return fmt.Sprintf("%T", err)
+}
+
+// spanBody ends the request's span once the response has been read to the end
+// or closed.
+type spanBody struct {
+	io.ReadCloser
+	end func()
+}
+
+func (b *spanBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.end()
+	}
+	return n, err
+}
+
+func (b *spanBody) Close() error {
+	b.end()
+	return b.ReadCloser.Close()
+}
+
+// idKinds maps the prefixes of opencode IDs to what they identify.
+var idKinds = map[string]string{
+	"ses": "session",
+	"msg": "message",
+	"per": "permission",
+	"prt": "part",
+	"usr": "user",
+}
+
+// PathTemplate returns the route template of an opencode API path, such as
+// "/session/{id}/message" for "/session/ses_123/message", so that spans and
+// metrics aren't keyed by IDs. Segments holding an opencode ID are replaced
+// following the server's naming of route parameters: the first is {id} and
+// later ones are named after their kind, as in {messageID}. Any other segment,
+// including those of a base URL with a path prefix, is kept as is.
+func PathTemplate(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	first := true
+	for i, segment := range segments {
+		kind, ok := idKind(segment)
+		if !ok {
+			continue
+		}
+		if first {
+			segments[i] = "{id}"
+			first = false
+		} else {
+			segments[i] = "{" + kind + "ID}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// idKind returns the kind of ID the segment is, if it is one.
+func idKind(segment string) (string, bool) {
+	prefix, rest, ok := strings.Cut(segment, "_")
+	if !ok || rest == "" {
+		return "", false
+	}
+	kind, ok := idKinds[prefix]
+	return kind, ok
+}
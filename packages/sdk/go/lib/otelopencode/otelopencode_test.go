@@ -0,0 +1,147 @@
+package otelopencode_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/lib/otelopencode"
+	"github.com/sst/opencode-sdk-go/option"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestPathTemplate(t *testing.T) {
+	cases := map[string]string{
+		"/session":                         "/session",
+		"/session/import":                  "/session/import",
+		"/session/ses_1":                   "/session/{id}",
+		"/session/ses_1/message":           "/session/{id}/message",
+		"/session/ses_1/message/msg_1":     "/session/{id}/message/{messageID}",
+		"/session/ses_1/permissions/per_1": "/session/{id}/permissions/{permissionID}",
+		"/find/file":                       "/find/file",
+		"/api/v1/session/ses_1/abort":      "/api/v1/session/{id}/abort",
+		"/tui/show-toast":                  "/tui/show-toast",
+	}
+	for path, expected := range cases {
+		if got := otelopencode.PathTemplate(path); got != expected {
+			t.Errorf("PathTemplate(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+}
+
+// TestPathTemplateRoutes checks every route of the SDK, as listed in api.md,
+// so that new routes can't be recorded under the wrong template.
+func TestPathTemplateRoutes(t *testing.T) {
+	api, err := os.ReadFile("../../api.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := strings.NewReplacer("{id}", "ses_01ABC", "{messageID}", "msg_01ABC", "{permissionID}", "per_01ABC")
+	routes := regexp.MustCompile(`title="[a-z]+ (/[^"]*)"`).FindAllStringSubmatch(string(api), -1)
+	if len(routes) == 0 {
+		t.Fatal("expected routes in api.md")
+	}
+	for _, route := range routes {
+		template := route[1]
+		if strings.Contains(ids.Replace(template), "{") {
+			t.Errorf("unknown route parameter in %q", template)
+			continue
+		}
+		if got := otelopencode.PathTemplate(ids.Replace(template)); got != template {
+			t.Errorf("PathTemplate(%q) = %q, expected %q", ids.Replace(template), got, template)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var attempts atomic.Int32
+	var traceparent atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent.Store(r.Header.Get("Traceparent"))
+		// Fail the first attempt so that the retry is recorded too.
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`true`))
+	}))
+	defer server.Close()
+
+	spans := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	client := opencode.NewClient(
+		option.WithBaseURL(server.URL),
+		otelopencode.WithTelemetry(
+			otelopencode.WithTracerProvider(tracerProvider),
+			otelopencode.WithMeterProvider(meterProvider),
+			otelopencode.WithPropagator(propagation.TraceContext{}),
+		),
+	)
+	if _, err := client.Session.Abort(context.Background(), "ses_1", opencode.SessionAbortParams{}); err != nil {
+		t.Fatalf("err should be nil: %s", err.Error())
+	}
+
+	ended := spans.GetSpans()
+	if len(ended) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(ended))
+	}
+	for i, span := range ended {
+		if span.Name != "POST /session/{id}/abort" {
+			t.Errorf("unexpected span name %q", span.Name)
+		}
+		attrs := attribute.NewSet(span.Attributes...)
+		status, _ := attrs.Value(otelopencode.StatusCodeKey)
+		resend, hasResend := attrs.Value(otelopencode.ResendKey)
+		switch i {
+		case 0:
+			if status.AsInt64() != http.StatusServiceUnavailable || hasResend {
+				t.Errorf("unexpected attributes on first attempt: %v", span.Attributes)
+			}
+		case 1:
+			if status.AsInt64() != http.StatusOK || resend.AsInt64() != 1 {
+				t.Errorf("unexpected attributes on retry: %v", span.Attributes)
+			}
+		}
+	}
+	if tp, _ := traceparent.Load().(string); tp == "" {
+		t.Errorf("expected the trace context to be propagated")
+	}
+
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &metrics); err != nil {
+		t.Fatal(err)
+	}
+	totals := map[string]int64{}
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, point := range data.DataPoints {
+					totals[m.Name] += point.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, point := range data.DataPoints {
+					totals[m.Name] += int64(point.Count)
+				}
+			}
+		}
+	}
+	if totals["opencode.client.requests"] != 2 || totals["opencode.client.retries"] != 1 || totals["http.client.request.duration"] != 2 {
+		t.Errorf("unexpected metric totals: %v", totals)
+	}
+}
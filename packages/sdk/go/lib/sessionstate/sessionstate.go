@@ -0,0 +1,459 @@
+// Package sessionstate keeps an in-memory copy of a session's messages and
+// parts up to date from the opencode event stream.
+//
+// A [Store] is seeded from [opencode.SessionService.Messages] and then applies
+// message.updated, message.removed, message.part.updated and
+// message.part.removed events as they arrive, keeping messages and parts
+// ordered by ID, which is the order the server created them in.
+//
+//	store := sessionstate.New(sessionID)
+//	store.OnChange(func(c sessionstate.Change) { redraw(store.Messages()) })
+//	err := store.Follow(ctx, client)
+package sessionstate
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/packages/ssestream"
+)
+
+// Message is a message together with its parts.
+type Message struct {
+	Info  opencode.MessageUnion
+	Parts []opencode.PartUnion
+}
+
+// ID returns the ID of the message.
+func (m Message) ID() string {
+	return MessageID(m.Info)
+}
+
+type ChangeKind string
+
+const (
+	// ChangeReset is reported when the store is (re)seeded from the server or
+	// the session is deleted.
+	ChangeReset          ChangeKind = "reset"
+	ChangeMessageAdded   ChangeKind = "message.added"
+	ChangeMessageUpdated ChangeKind = "message.updated"
+	ChangeMessageRemoved ChangeKind = "message.removed"
+	ChangePartAdded      ChangeKind = "part.added"
+	ChangePartUpdated    ChangeKind = "part.updated"
+	ChangePartRemoved    ChangeKind = "part.removed"
+)
+
+// Change describes a single modification of the store. PartID is empty for
+// message-level changes, and both IDs are empty for [ChangeReset].
+type Change struct {
+	Kind      ChangeKind
+	MessageID string
+	PartID    string
+}
+
+// Store holds the ordered message/part tree of a single session. It is safe
+// for concurrent use. Change callbacks run synchronously after the store has
+// been updated, without the store's lock held, so they may read from it.
+type Store struct {
+	sessionID string
+
+	mu       sync.RWMutex
+	loaded   bool
+	messages []Message
+	// orphans holds parts which arrived before their message.
+	orphans map[string][]opencode.PartUnion
+	// pending buffers events received before the store was seeded, so that
+	// nothing is lost between subscribing and loading the history.
+	pending []opencode.EventListResponseUnion
+
+	listenersMu sync.Mutex
+	nextID      int
+	listeners   map[int]func(Change)
+}
+
+func New(sessionID string) *Store {
+	return &Store{
+		sessionID: sessionID,
+		orphans:   map[string][]opencode.PartUnion{},
+		listeners: map[int]func(Change){},
+	}
+}
+
+// SessionID returns the ID of the session this store tracks.
+func (s *Store) SessionID() string {
+	return s.sessionID
+}
+
+// OnChange registers fn to be called after every change to the store. The
+// returned function removes the callback.
+func (s *Store) OnChange(fn func(Change)) (remove func()) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	s.nextID++
+	id := s.nextID
+	s.listeners[id] = fn
+	return func() {
+		s.listenersMu.Lock()
+		defer s.listenersMu.Unlock()
+		delete(s.listeners, id)
+	}
+}
+
+func (s *Store) notify(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+	s.listenersMu.Lock()
+	ids := make([]int, 0, len(s.listeners))
+	for id := range s.listeners {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	listeners := make([]func(Change), 0, len(ids))
+	for _, id := range ids {
+		listeners = append(listeners, s.listeners[id])
+	}
+	s.listenersMu.Unlock()
+
+	for _, change := range changes {
+		for _, fn := range listeners {
+			fn(change)
+		}
+	}
+}
+
+// Messages returns a snapshot of the session's messages, ordered by ID.
+func (s *Store) Messages() []Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]Message, len(s.messages))
+	for i, message := range s.messages {
+		result[i] = Message{Info: message.Info, Parts: slices.Clone(message.Parts)}
+	}
+	return result
+}
+
+// Message returns a snapshot of a single message.
+func (s *Store) Message(messageID string) (Message, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	index, found := s.findMessage(messageID)
+	if !found {
+		return Message{}, false
+	}
+	message := s.messages[index]
+	return Message{Info: message.Info, Parts: slices.Clone(message.Parts)}, true
+}
+
+// Loaded reports whether the store has been seeded.
+func (s *Store) Loaded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loaded
+}
+
+// Load seeds the store with the session's current history, replacing
+// anything already in it, and then replays events received via
+// [Store.Apply] while the store was not yet loaded.
+func (s *Store) Load(ctx context.Context, client *opencode.Client) error {
+	response, err := client.Session.Messages(ctx, s.sessionID, opencode.SessionMessagesParams{})
+	if err != nil {
+		return err
+	}
+	var history []opencode.SessionMessagesResponse
+	if response != nil {
+		history = *response
+	}
+	s.Reset(history)
+	return nil
+}
+
+// Reset replaces the store's contents with the given history, marks the
+// store as loaded and replays any buffered events.
+func (s *Store) Reset(history []opencode.SessionMessagesResponse) {
+	messages := make([]Message, 0, len(history))
+	for _, item := range history {
+		message := Message{Info: item.Info.AsUnion(), Parts: make([]opencode.PartUnion, 0, len(item.Parts))}
+		for _, part := range item.Parts {
+			message.Parts = insertPart(message.Parts, part.AsUnion())
+		}
+		messages = insertMessage(messages, message)
+	}
+
+	s.mu.Lock()
+	s.messages = messages
+	s.orphans = map[string][]opencode.PartUnion{}
+	s.loaded = true
+	pending := s.pending
+	s.pending = nil
+	changes := []Change{{Kind: ChangeReset}}
+	for _, event := range pending {
+		changes = append(changes, s.apply(event)...)
+	}
+	s.mu.Unlock()
+
+	s.notify(changes)
+}
+
+// Apply updates the store with a single event. Events for other sessions and
+// unrelated event types are ignored. Events applied before the store is
+// loaded are buffered and replayed by [Store.Load]. Apply reports whether the
+// event was relevant to this store.
+func (s *Store) Apply(event opencode.EventListResponseUnion) bool {
+	if id, ok := opencode.EventSessionID(event); !ok || id != s.sessionID {
+		return false
+	}
+
+	s.mu.Lock()
+	if !s.loaded {
+		s.pending = append(s.pending, event)
+		s.mu.Unlock()
+		return true
+	}
+	changes := s.apply(event)
+	s.mu.Unlock()
+
+	s.notify(changes)
+	return true
+}
+
+// Attach subscribes the store to the session's events on router. The
+// returned function unsubscribes it.
+func (s *Store) Attach(router *opencode.EventRouter) (detach func()) {
+	return router.HandleAll(func(event opencode.EventListResponseUnion) {
+		s.Apply(event)
+	}, opencode.ForSession(s.sessionID))
+}
+
+// Follow subscribes to the event stream, seeds the store and then keeps it
+// up to date until ctx is done or the stream fails. The history is loaded
+// every time the stream connects, including after a reconnect, and the
+// events received in the meantime are buffered and replayed on top of it, so
+// that no events are missed in between.
+func (s *Store) Follow(ctx context.Context, client *opencode.Client) error {
+	ctx, cancel := context.WithCancel(ctx)
+	reload := make(chan struct{}, 1)
+	stream := client.Event.ListStreamingResumable(ctx, opencode.EventListParams{}, ssestream.ReconnectPolicy{
+		OnConnect: func() {
+			s.mu.Lock()
+			s.loaded = false
+			s.mu.Unlock()
+			select {
+			case reload <- struct{}{}:
+			default:
+			}
+		},
+	})
+
+	events := make(chan opencode.EventListResponseUnion)
+	done := make(chan struct{})
+	// The stream may only be closed once the reader below has stopped using
+	// it, so cancel first and wait for the reader to exit.
+	defer func() {
+		cancel()
+		<-done
+		stream.Close()
+	}()
+	go func() {
+		defer close(done)
+		defer close(events)
+		for stream.Next() {
+			select {
+			case events <- stream.Current().AsUnion():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Loads run one at a time, a reconnect during a load starting another
+	// once it is done.
+	loaded := make(chan error, 1)
+	loading, again := false, false
+	load := func() {
+		loading = true
+		go func() {
+			loaded <- s.Load(ctx, client)
+		}()
+	}
+	for {
+		select {
+		case <-reload:
+			if loading {
+				again = true
+			} else {
+				load()
+			}
+		case err := <-loaded:
+			loading = false
+			if err != nil {
+				return err
+			}
+			if again {
+				again = false
+				load()
+			}
+		case event, ok := <-events:
+			if !ok {
+				return stream.Err()
+			}
+			s.Apply(event)
+		}
+	}
+}
+
+// apply must be called with s.mu held.
+func (s *Store) apply(event opencode.EventListResponseUnion) []Change {
+	switch e := event.(type) {
+	case opencode.EventListResponseEventMessageUpdated:
+		info := e.Properties.Info.AsUnion()
+		messageID := MessageID(info)
+		index, found := s.findMessage(messageID)
+		if found {
+			s.messages[index].Info = info
+			return []Change{{Kind: ChangeMessageUpdated, MessageID: messageID}}
+		}
+		message := Message{Info: info, Parts: []opencode.PartUnion{}}
+		changes := []Change{{Kind: ChangeMessageAdded, MessageID: messageID}}
+		for _, part := range s.orphans[messageID] {
+			message.Parts = insertPart(message.Parts, part)
+			changes = append(changes, Change{Kind: ChangePartAdded, MessageID: messageID, PartID: PartID(part)})
+		}
+		delete(s.orphans, messageID)
+		s.messages = insertMessage(s.messages, message)
+		return changes
+
+	case opencode.EventListResponseEventMessageRemoved:
+		messageID := e.Properties.MessageID
+		delete(s.orphans, messageID)
+		index, found := s.findMessage(messageID)
+		if !found {
+			return nil
+		}
+		s.messages = slices.Delete(s.messages, index, index+1)
+		return []Change{{Kind: ChangeMessageRemoved, MessageID: messageID}}
+
+	case opencode.EventListResponseEventMessagePartUpdated:
+		part := e.Properties.Part.AsUnion()
+		messageID := e.Properties.Part.MessageID
+		partID := e.Properties.Part.ID
+		index, found := s.findMessage(messageID)
+		if !found {
+			s.orphans[messageID] = upsertPart(s.orphans[messageID], part)
+			return nil
+		}
+		kind := ChangePartAdded
+		if _, exists := findPart(s.messages[index].Parts, partID); exists {
+			kind = ChangePartUpdated
+		}
+		s.messages[index].Parts = upsertPart(s.messages[index].Parts, part)
+		return []Change{{Kind: kind, MessageID: messageID, PartID: partID}}
+
+	case opencode.EventListResponseEventMessagePartRemoved:
+		messageID := e.Properties.MessageID
+		partID := e.Properties.PartID
+		if orphans, ok := s.orphans[messageID]; ok {
+			if i, found := findPart(orphans, partID); found {
+				s.orphans[messageID] = slices.Delete(orphans, i, i+1)
+			}
+		}
+		index, found := s.findMessage(messageID)
+		if !found {
+			return nil
+		}
+		partIndex, found := findPart(s.messages[index].Parts, partID)
+		if !found {
+			return nil
+		}
+		s.messages[index].Parts = slices.Delete(s.messages[index].Parts, partIndex, partIndex+1)
+		return []Change{{Kind: ChangePartRemoved, MessageID: messageID, PartID: partID}}
+
+	case opencode.EventListResponseEventSessionDeleted:
+		s.messages = nil
+		s.orphans = map[string][]opencode.PartUnion{}
+		return []Change{{Kind: ChangeReset}}
+	}
+	return nil
+}
+
+func (s *Store) findMessage(messageID string) (int, bool) {
+	return slices.BinarySearchFunc(s.messages, messageID, func(m Message, id string) int {
+		return strings.Compare(m.ID(), id)
+	})
+}
+
+func insertMessage(messages []Message, message Message) []Message {
+	index, found := slices.BinarySearchFunc(messages, message.ID(), func(m Message, id string) int {
+		return strings.Compare(m.ID(), id)
+	})
+	if found {
+		messages[index] = message
+		return messages
+	}
+	return slices.Insert(messages, index, message)
+}
+
+func findPart(parts []opencode.PartUnion, partID string) (int, bool) {
+	index := slices.IndexFunc(parts, func(p opencode.PartUnion) bool {
+		return PartID(p) == partID
+	})
+	return index, index > -1
+}
+
+// upsertPart replaces the part with the same ID, or inserts it in ID order.
+func upsertPart(parts []opencode.PartUnion, part opencode.PartUnion) []opencode.PartUnion {
+	if index, found := findPart(parts, PartID(part)); found {
+		parts[index] = part
+		return parts
+	}
+	return insertPart(parts, part)
+}
+
+func insertPart(parts []opencode.PartUnion, part opencode.PartUnion) []opencode.PartUnion {
+	id := PartID(part)
+	// Parts almost always arrive in order, so scan backwards from the end.
+	index := len(parts)
+	for index > 0 && PartID(parts[index-1]) > id {
+		index--
+	}
+	return slices.Insert(parts, index, part)
+}
+
+// MessageID returns the ID of a message of any role.
+func MessageID(message opencode.MessageUnion) string {
+	switch m := message.(type) {
+	case opencode.UserMessage:
+		return m.ID
+	case opencode.AssistantMessage:
+		return m.ID
+	}
+	return ""
+}
+
+// PartID returns the ID of a part of any type.
+func PartID(part opencode.PartUnion) string {
+	switch p := part.(type) {
+	case opencode.TextPart:
+		return p.ID
+	case opencode.ReasoningPart:
+		return p.ID
+	case opencode.FilePart:
+		return p.ID
+	case opencode.ToolPart:
+		return p.ID
+	case opencode.StepStartPart:
+		return p.ID
+	case opencode.StepFinishPart:
+		return p.ID
+	case opencode.SnapshotPart:
+		return p.ID
+	case opencode.PartPatchPart:
+		return p.ID
+	case opencode.AgentPart:
+		return p.ID
+	}
+	return ""
+}
@@ -0,0 +1,208 @@
+package sessionstate_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/lib/sessionstate"
+	"github.com/sst/opencode-sdk-go/option"
+)
+
+func event(t *testing.T, raw string) opencode.EventListResponseUnion {
+	t.Helper()
+	var evt opencode.EventListResponse
+	if err := json.Unmarshal([]byte(raw), &evt); err != nil {
+		t.Fatalf("failed to parse event: %v", err)
+	}
+	return evt.AsUnion()
+}
+
+const userMessage = `{"id":"msg_1","role":"user","sessionID":"ses_1","time":{"created":1}}`
+const assistantMessage = `{"id":"msg_2","role":"assistant","sessionID":"ses_1","time":{"created":2},"cost":0,"mode":"build","modelID":"m","providerID":"p","path":{"cwd":"/","root":"/"},"system":[],"tokens":{"input":0,"output":0,"reasoning":0,"cache":{"read":0,"write":0}}}`
+
+func textPart(id, messageID, text string) string {
+	return `{"id":"` + id + `","messageID":"` + messageID + `","sessionID":"ses_1","type":"text","text":"` + text + `"}`
+}
+
+func partIDs(message sessionstate.Message) []string {
+	ids := []string{}
+	for _, part := range message.Parts {
+		ids = append(ids, sessionstate.PartID(part))
+	}
+	return ids
+}
+
+func TestStoreAppliesEvents(t *testing.T) {
+	store := sessionstate.New("ses_1")
+	var changes []sessionstate.ChangeKind
+	store.OnChange(func(c sessionstate.Change) { changes = append(changes, c.Kind) })
+
+	// Events before the store is loaded are buffered.
+	store.Apply(event(t, `{"type":"message.updated","properties":{"info":`+assistantMessage+`}}`))
+	if len(store.Messages()) != 0 {
+		t.Fatalf("expected events to be buffered until loaded")
+	}
+
+	var history []opencode.SessionMessagesResponse
+	if err := json.Unmarshal([]byte(`[{"info":`+userMessage+`,"parts":[`+textPart("prt_1", "msg_1", "hi")+`]}]`), &history); err != nil {
+		t.Fatal(err)
+	}
+	store.Reset(history)
+
+	messages := store.Messages()
+	if len(messages) != 2 || messages[0].ID() != "msg_1" || messages[1].ID() != "msg_2" {
+		t.Fatalf("expected [msg_1 msg_2], got %v", messages)
+	}
+
+	// A part for a message the store hasn't seen yet is kept until it arrives.
+	store.Apply(event(t, `{"type":"message.part.updated","properties":{"part":`+textPart("prt_4", "msg_3", "later")+`}}`))
+	store.Apply(event(t, `{"type":"message.part.updated","properties":{"part":`+textPart("prt_3", "msg_2", "b")+`}}`))
+	store.Apply(event(t, `{"type":"message.part.updated","properties":{"part":`+textPart("prt_2", "msg_2", "a")+`}}`))
+	store.Apply(event(t, `{"type":"message.part.updated","properties":{"part":`+textPart("prt_2", "msg_2", "a2")+`}}`))
+	store.Apply(event(t, `{"type":"message.updated","properties":{"info":{"id":"msg_3","role":"user","sessionID":"ses_1","time":{"created":3}}}}`))
+	// Events for other sessions are ignored.
+	if store.Apply(event(t, `{"type":"message.removed","properties":{"sessionID":"ses_2","messageID":"msg_1"}}`)) {
+		t.Errorf("expected event for another session to be ignored")
+	}
+
+	msg2, _ := store.Message("msg_2")
+	if ids := partIDs(msg2); len(ids) != 2 || ids[0] != "prt_2" || ids[1] != "prt_3" {
+		t.Errorf("expected parts [prt_2 prt_3], got %v", ids)
+	}
+	if text := msg2.Parts[0].(opencode.TextPart).Text; text != "a2" {
+		t.Errorf("expected updated text a2, got %q", text)
+	}
+	msg3, ok := store.Message("msg_3")
+	if !ok || len(msg3.Parts) != 1 {
+		t.Errorf("expected orphaned part to be attached to msg_3, got %v", msg3)
+	}
+
+	store.Apply(event(t, `{"type":"message.part.removed","properties":{"sessionID":"ses_1","messageID":"msg_2","partID":"prt_3"}}`))
+	store.Apply(event(t, `{"type":"message.removed","properties":{"sessionID":"ses_1","messageID":"msg_1"}}`))
+
+	messages = store.Messages()
+	if len(messages) != 2 || messages[0].ID() != "msg_2" || len(messages[0].Parts) != 1 {
+		t.Errorf("expected msg_1 and prt_3 to be removed, got %v", messages)
+	}
+
+	expected := []sessionstate.ChangeKind{
+		sessionstate.ChangeReset,
+		sessionstate.ChangeMessageAdded,
+		sessionstate.ChangePartAdded,
+		sessionstate.ChangePartAdded,
+		sessionstate.ChangePartUpdated,
+		sessionstate.ChangeMessageAdded,
+		sessionstate.ChangePartAdded,
+		sessionstate.ChangePartRemoved,
+		sessionstate.ChangeMessageRemoved,
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected changes %v, got %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("expected changes %v, got %v", expected, changes)
+			break
+		}
+	}
+}
+
+func TestStoreLoad(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/session/ses_1/message" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"info":` + userMessage + `,"parts":[]},{"info":` + assistantMessage + `,"parts":[]}]`))
+	}))
+	defer server.Close()
+
+	client := opencode.NewClient(option.WithBaseURL(server.URL))
+	store := sessionstate.New("ses_1")
+	if err := store.Load(context.Background(), client); err != nil {
+		t.Fatalf("err should be nil: %s", err.Error())
+	}
+	if !store.Loaded() || len(store.Messages()) != 2 {
+		t.Errorf("expected 2 messages, got %v", store.Messages())
+	}
+}
+
+func TestStoreFollowLoadError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/event" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+		http.Error(w, `{"error":"boom"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := opencode.NewClient(option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	store := sessionstate.New("ses_1")
+	if err := store.Follow(context.Background(), client); err == nil {
+		t.Fatalf("expected the load error to be returned")
+	}
+}
+
+func TestStoreFollowReloadsAfterReconnect(t *testing.T) {
+	var connections, loads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/event":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			// Reconnect right away
+			fmt.Fprint(w, "retry: 1\n\n")
+			w.(http.Flusher).Flush()
+			if connections.Add(1) == 1 {
+				// The first connection drops, the assistant message is
+				// created while the client is away.
+				return
+			}
+			<-r.Context().Done()
+		case "/session/ses_1/message":
+			w.Header().Set("Content-Type", "application/json")
+			if loads.Add(1) == 1 {
+				w.Write([]byte(`[{"info":` + userMessage + `,"parts":[]}]`))
+				return
+			}
+			w.Write([]byte(`[{"info":` + userMessage + `,"parts":[]},{"info":` + assistantMessage + `,"parts":[]}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := opencode.NewClient(option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	store := sessionstate.New("ses_1")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var resets int
+	store.OnChange(func(change sessionstate.Change) {
+		if change.Kind == sessionstate.ChangeReset {
+			resets++
+			if resets == 2 {
+				cancel()
+			}
+		}
+	})
+	store.Follow(ctx, client)
+
+	if resets != 2 || connections.Load() != 2 {
+		t.Fatalf("expected a reload after the reconnect, got %d resets over %d connections", resets, connections.Load())
+	}
+	if messages := store.Messages(); len(messages) != 2 {
+		t.Errorf("expected the message created while disconnected, got %v", messages)
+	}
+}
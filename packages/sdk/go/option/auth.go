@@ -0,0 +1,20 @@
+package option
+
+import (
+	"github.com/sst/opencode-sdk-go/internal/requestconfig"
+)
+
+// WithBearerToken returns a RequestOption that authenticates requests with the
+// given token in an "Authorization: Bearer" header.
+func WithBearerToken(token string) RequestOption {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithBasicAuth returns a RequestOption that authenticates requests with HTTP
+// basic authentication.
+func WithBasicAuth(username, password string) RequestOption {
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		r.Request.SetBasicAuth(username, password)
+		return nil
+	})
+}
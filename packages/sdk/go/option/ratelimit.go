@@ -0,0 +1,211 @@
+package option
+
+import (
+	"context"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sst/opencode-sdk-go/internal/requestconfig"
+)
+
+// Budget limits how fast and how many requests of one kind a client sends.
+// Zero fields are unlimited.
+type Budget struct {
+	// RequestsPerSecond is the sustained rate at which requests may start.
+	RequestsPerSecond float64
+	// Burst is the number of requests which may start at once after the
+	// client has been idle. Defaults to 1 when RequestsPerSecond is set.
+	Burst int
+	// MaxInFlight is the number of requests which may be running at once.
+	// A request counts until its response body is closed.
+	MaxInFlight int
+}
+
+// Limits are the budgets enforced by [WithLimits].
+type Limits struct {
+	// Requests applies to ordinary request/response calls.
+	Requests Budget
+	// Streams applies to long-lived requests, such as the event stream and
+	// the long-polls of the TUI control API, so that they neither hold up nor
+	// are held up by ordinary calls. Other long-lived requests can be moved
+	// to this budget with [WithStreaming].
+	Streams Budget
+}
+
+// WithLimits returns a RequestOption which makes requests wait until they fit
+// within the given budgets, so that callers firing many requests in parallel
+// don't overwhelm the server. Retries count as new requests.
+//
+// The budgets are shared by every request made with the returned option, so it
+// should be created once and passed to opencode.NewClient:
+//
+//	client := opencode.NewClient(option.WithLimits(option.Limits{
+//		Requests: option.Budget{RequestsPerSecond: 50, Burst: 10, MaxInFlight: 8},
+//		Streams:  option.Budget{MaxInFlight: 2},
+//	}))
+//
+// A request which is still waiting when its context is done fails with the
+// context's error.
+func WithLimits(limits Limits) RequestOption {
+	requests := newLimiter(limits.Requests)
+	streams := newLimiter(limits.Streams)
+	return WithMiddleware(func(req *http.Request, next MiddlewareNext) (*http.Response, error) {
+		limiter := requests
+		if isStreamingRequest(req) {
+			limiter = streams
+		}
+		return limiter.do(req, next)
+	})
+}
+
+type streamingKey struct{}
+
+// WithStreaming returns a RequestOption which counts the request against the
+// Streams budget of [WithLimits] rather than the Requests one. Event streams
+// and the known long-polls of the API are counted as streams already.
+func WithStreaming() RequestOption {
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		r.Request = r.Request.WithContext(context.WithValue(r.Request.Context(), streamingKey{}, true))
+		return nil
+	})
+}
+
+// longPollPaths are the routes which hold the request open until the server
+// has something to send.
+var longPollPaths = []string{
+	"/tui/control/next",
+}
+
+func isStreamingRequest(req *http.Request) bool {
+	if streaming, _ := req.Context().Value(streamingKey{}).(bool); streaming {
+		return true
+	}
+	for _, path := range longPollPaths {
+		// Matched as a suffix to allow for base URLs with a path prefix.
+		if strings.HasSuffix(req.URL.Path, path) {
+			return true
+		}
+	}
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accept); err == nil && mediaType == "text/event-stream" {
+			return true
+		}
+	}
+	return false
+}
+
+type limiter struct {
+	bucket *tokenBucket
+	slots  chan struct{}
+}
+
+func newLimiter(budget Budget) *limiter {
+	l := &limiter{}
+	if budget.RequestsPerSecond > 0 {
+		burst := budget.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		l.bucket = &tokenBucket{rate: budget.RequestsPerSecond, burst: float64(burst), tokens: float64(burst)}
+	}
+	if budget.MaxInFlight > 0 {
+		l.slots = make(chan struct{}, budget.MaxInFlight)
+	}
+	return l
+}
+
+func (l *limiter) do(req *http.Request, next MiddlewareNext) (*http.Response, error) {
+	ctx := req.Context()
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := sync.OnceFunc(func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	})
+
+	if l.bucket != nil {
+		if err := l.bucket.wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	res, err := next(req)
+	if err != nil || res == nil || res.Body == nil {
+		release()
+		return res, err
+	}
+	res.Body = &releasingBody{ReadCloser: res.Body, release: release}
+	return res, nil
+}
+
+// tokenBucket hands out tokens at a fixed rate, holding at most burst of them.
+// Waiters reserve a token up front, letting the balance go negative, so they
+// are served in the order they arrived.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reservation back to the requests queued behind this one.
+		b.mu.Lock()
+		b.tokens = math.Min(b.burst, b.tokens+1)
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// releasingBody frees a request's in-flight slot once its response has been
+// read to the end or closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *releasingBody) Close() error {
+	b.release()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,134 @@
+package option
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/sst/opencode-sdk-go/internal/requestconfig"
+)
+
+type transportKey struct {
+	base       *http.Client
+	socketPath string
+	tlsConfig  *tls.Config
+}
+
+// transportClients caches one client per base client and transport settings,
+// so that options applied to every request reuse the same connections.
+var transportClients sync.Map
+
+// withTransport returns a RequestOption that replaces the http client with a
+// copy whose transport is changed by configure. Options built on it combine:
+// each one starts from the client left by the previous one.
+func withTransport(key transportKey, configure func(*http.Transport)) RequestOption {
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		if r.CustomHTTPDoer != nil {
+			return fmt.Errorf("requestoption: transport options require an *http.Client, got %T", r.CustomHTTPDoer)
+		}
+		key.base = r.HTTPClient
+		if client, ok := transportClients.Load(key); ok {
+			r.HTTPClient = client.(*http.Client)
+			return nil
+		}
+
+		var transport *http.Transport
+		switch t := r.HTTPClient.Transport.(type) {
+		case nil:
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+		case *http.Transport:
+			transport = t.Clone()
+		default:
+			return fmt.Errorf("requestoption: transport options require an *http.Transport, got %T", r.HTTPClient.Transport)
+		}
+		configure(transport)
+
+		client := *r.HTTPClient
+		client.Transport = transport
+		actual, _ := transportClients.LoadOrStore(key, &client)
+		r.HTTPClient = actual.(*http.Client)
+		return nil
+	})
+}
+
+// WithUnixSocket returns a RequestOption that connects to a server listening
+// on the unix domain socket at path, instead of the address in the base URL.
+// [WithBaseURL] doesn't understand unix:// URLs, this option takes their
+// place. The base URL, http://localhost/ unless one is given, still sets the
+// scheme, the Host header and the path prefix of requests; it must use https
+// for [WithTLSConfig] to apply.
+//
+// It combines with [WithTLSConfig], and must come after [WithHTTPClient] when
+// both are given.
+func WithUnixSocket(path string) RequestOption {
+	dial := withTransport(transportKey{socketPath: path}, func(t *http.Transport) {
+		t.Proxy = nil
+		t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		}
+	})
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		if r.BaseURL == nil {
+			// The host is never resolved, it only fills the Host header.
+			r.BaseURL = &url.URL{Scheme: "http", Host: "localhost", Path: "/"}
+		}
+		return dial.Apply(r)
+	})
+}
+
+// WithTLSConfig returns a RequestOption that makes requests with the given TLS
+// configuration, e.g. to trust a custom certificate authority or to present a
+// client certificate. See [LoadTLSConfig].
+//
+// The configuration replaces the TLS configuration of the client's
+// [*http.Transport]. It combines with [WithUnixSocket], and must come after
+// [WithHTTPClient] when both are given. The config should be created once and shared, since a client is kept
+// for every distinct config.
+func WithTLSConfig(config *tls.Config) RequestOption {
+	return withTransport(transportKey{tlsConfig: config}, func(t *http.Transport) {
+		t.TLSClientConfig = config
+	})
+}
+
+// LoadTLSConfig builds a TLS configuration for [WithTLSConfig] from PEM files.
+// caFile, if non-empty, is a bundle of certificate authorities trusted in
+// addition to the system roots. certFile and keyFile, if non-empty, are the
+// client certificate and private key presented to the server.
+func LoadTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("requestoption: failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("requestoption: no certificates found in CA bundle %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("requestoption: client certificate and key must be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("requestoption: failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
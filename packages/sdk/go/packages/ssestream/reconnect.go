@@ -0,0 +1,422 @@
+package ssestream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTooManyReconnects is returned by [ReconnectingStream.Err] when the stream
+// gives up after [ReconnectPolicy.MaxAttempts] consecutive failures.
+var ErrTooManyReconnects = errors.New("ssestream: too many reconnection attempts")
+
+// ErrStreamEnded, returned by a [Connector] (possibly wrapped), ends a
+// [ReconnectingStream] cleanly instead of reconnecting: Next returns false and
+// Err returns nil. Replayed recordings use it to end where the recording did.
+var ErrStreamEnded = errors.New("ssestream: stream ended")
+
+// Connector opens a new event stream. When lastEventID is non-empty the
+// connector should send it in the Last-Event-ID header so the server can
+// replay the events that were missed while disconnected.
+type Connector func(ctx context.Context, lastEventID string) (*http.Response, error)
+
+// ReconnectPolicy configures how a [ReconnectingStream] recovers from dropped
+// connections. The zero value is a valid policy which reconnects forever.
+type ReconnectPolicy struct {
+	// MinDelay is the delay before the first reconnection attempt when the
+	// server has not sent a "retry:" hint. Defaults to 500ms.
+	MinDelay time.Duration
+	// MaxDelay caps the exponential backoff between consecutive failed
+	// attempts. Defaults to 30s.
+	MaxDelay time.Duration
+	// MaxAttempts is the number of consecutive failed attempts after which
+	// the stream gives up. Zero means retry until the context is done.
+	MaxAttempts int
+	// LastEventID seeds the stream with a previously seen event ID, so that
+	// even the first connection resumes where an earlier stream left off.
+	LastEventID string
+	// ShouldReconnect reports whether the stream should try again after the
+	// given error. Defaults to always reconnecting.
+	ShouldReconnect func(err error) bool
+	// OnConnect, if set, is called every time a connection is established,
+	// before any of its events are read.
+	OnConnect func()
+	// OnReconnect, if set, is called before every reconnection attempt with
+	// the attempt number (starting at 1) and the error that ended the
+	// previous connection, which is nil if the server closed it cleanly.
+	OnReconnect func(attempt int, err error)
+}
+
+// ReconnectingStream is a [Stream] which transparently reconnects when the
+// underlying connection drops, resuming from the last event ID it has seen.
+// It only stops when its context is done, the policy gives up, or an event
+// cannot be decoded.
+//
+// Close may be called from another goroutine to stop a stream that is blocked
+// in Next.
+type ReconnectingStream[T any] struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	connect     Connector
+	policy      ReconnectPolicy
+	decoder     Decoder
+	cur         T
+	err         error
+	lastErr     error
+	lastEventID string
+	retry       time.Duration
+	attempt     int
+	dialed      bool
+
+	// mu is held by Next for as long as it uses the decoder, so that Close
+	// only tears the decoder down itself when no Next call is in flight.
+	mu     sync.Mutex
+	closed atomic.Bool
+}
+
+func NewReconnectingStream[T any](ctx context.Context, connect Connector, policy ReconnectPolicy) *ReconnectingStream[T] {
+	if policy.MinDelay <= 0 {
+		policy.MinDelay = 500 * time.Millisecond
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = 30 * time.Second
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &ReconnectingStream[T]{
+		ctx:         ctx,
+		cancel:      cancel,
+		connect:     connect,
+		policy:      policy,
+		lastEventID: policy.LastEventID,
+	}
+}
+
+// Next blocks until the next event is available, reconnecting as needed. It
+// returns false once the stream has ended; call ReconnectingStream.Err() to
+// find out why.
+func (s *ReconnectingStream[T]) Next() bool {
+	s.mu.Lock()
+	defer func() {
+		// Close could not tear the decoder down while we were using it.
+		if s.closed.Load() && s.decoder != nil {
+			s.decoder.Close()
+			s.decoder = nil
+		}
+		s.mu.Unlock()
+	}()
+
+	for {
+		if s.err != nil || s.closed.Load() {
+			return false
+		}
+
+		if s.decoder == nil && !s.dial() {
+			return false
+		}
+
+		for s.decoder.Next() {
+			evt := s.decoder.Event()
+			if rd, ok := s.decoder.(ResumableDecoder); ok && rd.LastEventID() != "" {
+				s.lastEventID = rd.LastEventID()
+			}
+			s.attempt = 0
+
+			var nxt T
+			s.err = json.Unmarshal(evt.Data, &nxt)
+			if s.err != nil {
+				return false
+			}
+			s.cur = nxt
+			return true
+		}
+
+		s.lastErr = s.decoder.Err()
+		s.drop()
+	}
+}
+
+// dial connects to the server, waiting between attempts according to the
+// policy. It returns false and sets s.err if the stream should stop.
+func (s *ReconnectingStream[T]) dial() bool {
+	for {
+		if s.closed.Load() {
+			return false
+		}
+		if err := s.ctx.Err(); err != nil {
+			s.err = err
+			return false
+		}
+
+		if s.dialed {
+			s.attempt++
+			if s.policy.MaxAttempts > 0 && s.attempt > s.policy.MaxAttempts {
+				s.err = ErrTooManyReconnects
+				if s.lastErr != nil {
+					s.err = fmt.Errorf("%w: %w", ErrTooManyReconnects, s.lastErr)
+				}
+				return false
+			}
+			if s.policy.OnReconnect != nil {
+				s.policy.OnReconnect(s.attempt, s.lastErr)
+			}
+
+			timer := time.NewTimer(s.delay())
+			select {
+			case <-s.ctx.Done():
+				timer.Stop()
+				if !s.closed.Load() {
+					s.err = s.ctx.Err()
+				}
+				return false
+			case <-timer.C:
+			}
+		}
+		s.dialed = true
+
+		res, err := s.connect(s.ctx, s.lastEventID)
+		if err != nil {
+			if res != nil && res.Body != nil {
+				res.Body.Close()
+			}
+			if s.closed.Load() {
+				return false
+			}
+			if s.ctx.Err() != nil {
+				s.err = s.ctx.Err()
+				return false
+			}
+			if errors.Is(err, ErrStreamEnded) {
+				return false
+			}
+			if s.policy.ShouldReconnect != nil && !s.policy.ShouldReconnect(err) {
+				s.err = err
+				return false
+			}
+			s.lastErr = err
+			continue
+		}
+
+		decoder := newResumableDecoder(res)
+		if decoder == nil {
+			s.lastErr = nil
+			continue
+		}
+		s.decoder = decoder
+		if s.policy.OnConnect != nil {
+			s.policy.OnConnect()
+		}
+		return true
+	}
+}
+
+// drop closes the current connection, remembering the server's state so the
+// next connection can resume from it.
+func (s *ReconnectingStream[T]) drop() {
+	if rd, ok := s.decoder.(ResumableDecoder); ok {
+		if id := rd.LastEventID(); id != "" {
+			s.lastEventID = id
+		}
+		if retry := rd.Retry(); retry > 0 {
+			s.retry = retry
+		}
+	}
+	s.decoder.Close()
+	s.decoder = nil
+}
+
+// delay returns how long to wait before the current attempt. The server's
+// "retry:" hint replaces the minimum delay, and consecutive failures back off
+// exponentially from there.
+func (s *ReconnectingStream[T]) delay() time.Duration {
+	base := s.policy.MinDelay
+	if s.retry > 0 {
+		base = s.retry
+	}
+	if s.attempt <= 1 {
+		return base
+	}
+
+	delay := time.Duration(float64(base) * math.Pow(2, float64(s.attempt-1)))
+	if delay > s.policy.MaxDelay || delay <= 0 {
+		delay = s.policy.MaxDelay
+	}
+	if base > delay {
+		delay = base
+	}
+
+	jitter := rand.Int63n(int64(delay/4) + 1)
+	return delay - time.Duration(jitter)
+}
+
+func (s *ReconnectingStream[T]) Current() T {
+	return s.cur
+}
+
+func (s *ReconnectingStream[T]) Err() error {
+	return s.err
+}
+
+// LastEventID returns the ID of the most recent event received, which can be
+// persisted and passed back via [ReconnectPolicy.LastEventID] later.
+func (s *ReconnectingStream[T]) LastEventID() string {
+	return s.lastEventID
+}
+
+// Close stops the stream. It is safe to call while Next is blocked in another
+// goroutine: the connection is cancelled and that Next call returns false
+// without an error.
+func (s *ReconnectingStream[T]) Close() error {
+	s.closed.Store(true)
+	s.cancel()
+	if !s.mu.TryLock() {
+		// Next is running and will tear down the decoder before it returns.
+		return nil
+	}
+	defer s.mu.Unlock()
+	if s.decoder == nil {
+		return nil
+	}
+	err := s.decoder.Close()
+	s.decoder = nil
+	return err
+}
+
+// ResumableDecoder is implemented by decoders that track the "id:" and
+// "retry:" fields of a text/event-stream, which a [ReconnectingStream] needs
+// to resume where the previous connection left off.
+type ResumableDecoder interface {
+	Decoder
+	// LastEventID returns the last event ID received from the server, to be
+	// sent in the Last-Event-ID header when reconnecting.
+	LastEventID() string
+	// Retry returns the reconnection delay requested by the server, or zero.
+	Retry() time.Duration
+}
+
+// newResumableDecoder returns a decoder for res. Decoders registered with
+// [RegisterDecoder] take precedence; otherwise the body is decoded as a
+// text/event-stream which tracks the "id:" and "retry:" fields.
+func newResumableDecoder(res *http.Response) Decoder {
+	if res == nil || res.Body == nil {
+		return nil
+	}
+	if _, ok := decoderTypes[res.Header.Get("content-type")]; ok {
+		return NewDecoder(res)
+	}
+	scn := bufio.NewScanner(res.Body)
+	scn.Buffer(nil, bufio.MaxScanTokenSize<<9)
+	return &resumableDecoder{rc: res.Body, scn: scn}
+}
+
+// resumableDecoder is the text/event-stream decoder used by
+// [ReconnectingStream]. Unlike the base decoder it remembers the last event ID
+// and retry hint, and does not dispatch blocks without any data.
+type resumableDecoder struct {
+	evt    Event
+	rc     io.ReadCloser
+	scn    *bufio.Scanner
+	err    error
+	lastID string
+	retry  time.Duration
+}
+
+func (s *resumableDecoder) Next() bool {
+	if s.err != nil {
+		return false
+	}
+
+	event := ""
+	data := bytes.NewBuffer(nil)
+	hasData := false
+
+	for s.scn.Scan() {
+		txt := s.scn.Bytes()
+
+		// Dispatch event on an empty line
+		if len(txt) == 0 {
+			// A block without any data lines (e.g. one only carrying "id:" or
+			// "retry:") updates the stream state but is not an event.
+			if !hasData {
+				event = ""
+				continue
+			}
+			s.evt = Event{
+				Type: event,
+				Data: data.Bytes(),
+			}
+			return true
+		}
+
+		// Split a string like "event: bar" into name="event" and value=" bar".
+		name, value, _ := bytes.Cut(txt, []byte(":"))
+
+		// Consume an optional space after the colon if it exists.
+		if len(value) > 0 && value[0] == ' ' {
+			value = value[1:]
+		}
+
+		switch string(name) {
+		case "":
+			// An empty line in the for ": something" is a comment and should be ignored.
+			continue
+		case "event":
+			event = string(value)
+		case "id":
+			// Per the spec, ids containing NULL are ignored.
+			if !bytes.ContainsRune(value, 0) {
+				s.lastID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 63); err == nil {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		case "data":
+			hasData = true
+			_, s.err = data.Write(value)
+			if s.err != nil {
+				break
+			}
+			_, s.err = data.WriteRune('\n')
+			if s.err != nil {
+				break
+			}
+		}
+	}
+
+	if s.scn.Err() != nil {
+		s.err = s.scn.Err()
+	}
+
+	return false
+}
+
+func (s *resumableDecoder) Event() Event {
+	return s.evt
+}
+
+func (s *resumableDecoder) Close() error {
+	return s.rc.Close()
+}
+
+func (s *resumableDecoder) Err() error {
+	return s.err
+}
+
+func (s *resumableDecoder) LastEventID() string {
+	return s.lastID
+}
+
+func (s *resumableDecoder) Retry() time.Duration {
+	return s.retry
+}
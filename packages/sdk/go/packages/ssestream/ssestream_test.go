@@ -0,0 +1,191 @@
+package ssestream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestDecoderIDAndRetry(t *testing.T) {
+	body := "retry: 1500\n\n" +
+		"id: 1\nevent: a\ndata: {\"n\":1}\n\n" +
+		": comment\n\n" +
+		"data: {\"n\":2}\n\n" +
+		"id: 3\ndata: {\"n\":3}\n\n"
+	decoder := newResumableDecoder(newTestResponse(body))
+	rd, ok := decoder.(ResumableDecoder)
+	if !ok {
+		t.Fatalf("expected event stream decoder to be resumable")
+	}
+
+	var ids []string
+	for decoder.Next() {
+		ids = append(ids, rd.LastEventID())
+	}
+	if err := decoder.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := strings.Join(ids, ","), "1,1,3"; got != want {
+		t.Errorf("expected ids %q, got %q", want, got)
+	}
+
+	if rd.LastEventID() != "3" {
+		t.Errorf("expected last event id 3, got %q", rd.LastEventID())
+	}
+	if rd.Retry() != 1500*time.Millisecond {
+		t.Errorf("expected retry 1.5s, got %v", rd.Retry())
+	}
+}
+
+func TestReconnectingStreamResumes(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		headers []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Get("Last-Event-ID"))
+		n := len(headers)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		// Each connection sends two events and then drops.
+		fmt.Fprintf(w, "retry: 1\n\n")
+		for i := 0; i < 2; i++ {
+			id := (n-1)*2 + i + 1
+			fmt.Fprintf(w, "id: %d\ndata: %d\n\n", id, id)
+		}
+	}))
+	defer server.Close()
+
+	connect := func(ctx context.Context, lastEventID string) (*http.Response, error) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		return http.DefaultClient.Do(req)
+	}
+
+	var reconnects int
+	stream := NewReconnectingStream[int](context.Background(), connect, ReconnectPolicy{
+		OnReconnect: func(attempt int, err error) { reconnects++ },
+	})
+	defer stream.Close()
+
+	var got []int
+	for len(got) < 5 && stream.Next() {
+		got = append(got, stream.Current())
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fmt.Sprint(got) != "[1 2 3 4 5]" {
+		t.Errorf("expected events [1 2 3 4 5], got %v", got)
+	}
+	if reconnects != 2 {
+		t.Errorf("expected 2 reconnects, got %d", reconnects)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(headers) != "[ 2 4]" {
+		t.Errorf("expected Last-Event-ID headers [ 2 4], got %q", headers)
+	}
+	if stream.LastEventID() != "5" {
+		t.Errorf("expected last event id 5, got %q", stream.LastEventID())
+	}
+}
+
+func TestReconnectingStreamGivesUp(t *testing.T) {
+	errRefused := errors.New("connection refused")
+	attempts := 0
+	connect := func(ctx context.Context, lastEventID string) (*http.Response, error) {
+		attempts++
+		return nil, errRefused
+	}
+
+	stream := NewReconnectingStream[int](context.Background(), connect, ReconnectPolicy{
+		MinDelay:    time.Millisecond,
+		MaxDelay:    time.Millisecond,
+		MaxAttempts: 3,
+	})
+	if stream.Next() {
+		t.Fatalf("expected stream to end")
+	}
+	if !errors.Is(stream.Err(), ErrTooManyReconnects) || !errors.Is(stream.Err(), errRefused) {
+		t.Errorf("expected wrapped ErrTooManyReconnects, got %v", stream.Err())
+	}
+	if attempts != 4 {
+		t.Errorf("expected 4 connection attempts, got %d", attempts)
+	}
+}
+
+func TestReconnectingStreamStopsOnContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	connect := func(ctx context.Context, lastEventID string) (*http.Response, error) {
+		cancel()
+		return nil, ctx.Err()
+	}
+
+	stream := NewReconnectingStream[int](ctx, connect, ReconnectPolicy{})
+	if stream.Next() {
+		t.Fatalf("expected stream to end")
+	}
+	if !errors.Is(stream.Err(), context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", stream.Err())
+	}
+}
+
+func TestReconnectingStreamCloseWhileBlocked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "id: 1\ndata: 1\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	connect := func(ctx context.Context, lastEventID string) (*http.Response, error) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		return http.DefaultClient.Do(req)
+	}
+
+	stream := NewReconnectingStream[int](context.Background(), connect, ReconnectPolicy{})
+	if !stream.Next() {
+		t.Fatalf("expected first event, got %v", stream.Err())
+	}
+
+	done := make(chan bool)
+	go func() { done <- stream.Next() }()
+	time.Sleep(50 * time.Millisecond)
+	if err := stream.Close(); err != nil {
+		t.Fatalf("unexpected error closing: %v", err)
+	}
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Errorf("expected Next to return false after Close")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Next did not return after Close")
+	}
+	if err := stream.Err(); err != nil {
+		t.Errorf("expected no error after Close, got %v", err)
+	}
+}
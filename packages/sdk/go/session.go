@@ -117,6 +117,30 @@ func (r *SessionService) Command(ctx context.Context, id string, params SessionC
 	return
 }
 
+// Get the diff of the files changed since the start of a session
+func (r *SessionService) Diff(ctx context.Context, id string, query SessionDiffParams, opts ...option.RequestOption) (res *string, err error) {
+	opts = slices.Concat(r.Options, opts)
+	if id == "" {
+		err = errors.New("missing required id parameter")
+		return
+	}
+	path := fmt.Sprintf("session/%s/diff", id)
+	err = requestconfig.ExecuteNewRequest(ctx, http.MethodGet, path, query, &res, opts...)
+	return
+}
+
+// Fork an existing session at a specific message
+func (r *SessionService) Fork(ctx context.Context, id string, params SessionForkParams, opts ...option.RequestOption) (res *Session, err error) {
+	opts = slices.Concat(r.Options, opts)
+	if id == "" {
+		err = errors.New("missing required id parameter")
+		return
+	}
+	path := fmt.Sprintf("session/%s/fork", id)
+	err = requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, params, &res, opts...)
+	return
+}
+
 // Get session
 func (r *SessionService) Get(ctx context.Context, id string, query SessionGetParams, opts ...option.RequestOption) (res *Session, err error) {
 	opts = slices.Concat(r.Options, opts)
@@ -129,6 +153,14 @@ func (r *SessionService) Get(ctx context.Context, id string, query SessionGetPar
 	return
 }
 
+// Import messages into a new session
+func (r *SessionService) Import(ctx context.Context, params SessionImportParams, opts ...option.RequestOption) (res *Session, err error) {
+	opts = slices.Concat(r.Options, opts)
+	path := "session/import"
+	err = requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, params, &res, opts...)
+	return
+}
+
 // Analyze the app and create an AGENTS.md file
 func (r *SessionService) Init(ctx context.Context, id string, params SessionInitParams, opts ...option.RequestOption) (res *bool, err error) {
 	opts = slices.Concat(r.Options, opts)
@@ -373,6 +405,7 @@ type AssistantMessage struct {
 	Time       AssistantMessageTime   `json:"time,required"`
 	Tokens     AssistantMessageTokens `json:"tokens,required"`
 	Error      AssistantMessageError  `json:"error"`
+	ParentID   string                 `json:"parentID"`
 	Summary    bool                   `json:"summary"`
 	JSON       assistantMessageJSON   `json:"-"`
 }
@@ -392,6 +425,7 @@ type assistantMessageJSON struct {
 	Time        apijson.Field
 	Tokens      apijson.Field
 	Error       apijson.Field
+	ParentID    apijson.Field
 	Summary     apijson.Field
 	raw         string
 	ExtraFields map[string]apijson.Field
@@ -918,9 +952,10 @@ type Message struct {
 	Time interface{} `json:"time,required"`
 	Cost float64     `json:"cost"`
 	// This field can have the runtime type of [AssistantMessageError].
-	Error   interface{} `json:"error"`
-	Mode    string      `json:"mode"`
-	ModelID string      `json:"modelID"`
+	Error    interface{} `json:"error"`
+	Mode     string      `json:"mode"`
+	ModelID  string      `json:"modelID"`
+	ParentID string      `json:"parentID"`
 	// This field can have the runtime type of [AssistantMessagePath].
 	Path       interface{} `json:"path"`
 	ProviderID string      `json:"providerID"`
@@ -943,6 +978,7 @@ type messageJSON struct {
 	Error       apijson.Field
 	Mode        apijson.Field
 	ModelID     apijson.Field
+	ParentID    apijson.Field
 	Path        apijson.Field
 	ProviderID  apijson.Field
 	Summary     apijson.Field
@@ -2481,6 +2517,35 @@ func (r SessionCommandParams) URLQuery() (v url.Values) {
 	})
 }
 
+type SessionDiffParams struct {
+	Directory param.Field[string] `query:"directory"`
+}
+
+// URLQuery serializes [SessionDiffParams]'s query parameters as `url.Values`.
+func (r SessionDiffParams) URLQuery() (v url.Values) {
+	return apiquery.MarshalWithSettings(r, apiquery.QuerySettings{
+		ArrayFormat:  apiquery.ArrayQueryFormatComma,
+		NestedFormat: apiquery.NestedQueryFormatBrackets,
+	})
+}
+
+type SessionForkParams struct {
+	Directory param.Field[string] `query:"directory"`
+	MessageID param.Field[string] `json:"messageID"`
+}
+
+func (r SessionForkParams) MarshalJSON() (data []byte, err error) {
+	return apijson.MarshalRoot(r)
+}
+
+// URLQuery serializes [SessionForkParams]'s query parameters as `url.Values`.
+func (r SessionForkParams) URLQuery() (v url.Values) {
+	return apiquery.MarshalWithSettings(r, apiquery.QuerySettings{
+		ArrayFormat:  apiquery.ArrayQueryFormatComma,
+		NestedFormat: apiquery.NestedQueryFormatBrackets,
+	})
+}
+
 type SessionGetParams struct {
 	Directory param.Field[string] `query:"directory"`
 }
@@ -2493,6 +2558,33 @@ func (r SessionGetParams) URLQuery() (v url.Values) {
 	})
 }
 
+type SessionImportParams struct {
+	Messages  param.Field[[]SessionImportParamsMessage] `json:"messages,required"`
+	Directory param.Field[string]                       `query:"directory"`
+	Title     param.Field[string]                       `json:"title"`
+}
+
+func (r SessionImportParams) MarshalJSON() (data []byte, err error) {
+	return apijson.MarshalRoot(r)
+}
+
+// URLQuery serializes [SessionImportParams]'s query parameters as `url.Values`.
+func (r SessionImportParams) URLQuery() (v url.Values) {
+	return apiquery.MarshalWithSettings(r, apiquery.QuerySettings{
+		ArrayFormat:  apiquery.ArrayQueryFormatComma,
+		NestedFormat: apiquery.NestedQueryFormatBrackets,
+	})
+}
+
+type SessionImportParamsMessage struct {
+	Info  param.Field[interface{}]   `json:"info,required"`
+	Parts param.Field[[]interface{}] `json:"parts,required"`
+}
+
+func (r SessionImportParamsMessage) MarshalJSON() (data []byte, err error) {
+	return apijson.MarshalRoot(r)
+}
+
 type SessionInitParams struct {
 	MessageID  param.Field[string] `json:"messageID,required"`
 	ModelID    param.Field[string] `json:"modelID,required"`
@@ -0,0 +1,306 @@
+package opencode
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sst/opencode-sdk-go/option"
+	"github.com/sst/opencode-sdk-go/packages/ssestream"
+)
+
+// PromptUpdate is a single change to one of the parts of the assistant's reply
+// to a prompt started with [SessionService.PromptStreaming].
+type PromptUpdate struct {
+	// MessageID is the ID of the assistant message the part belongs to. A
+	// single prompt may produce several assistant messages, one per step.
+	MessageID string
+	// Part is the current state of the part: a [TextPart], [ReasoningPart] or
+	// [ToolPart].
+	Part PartUnion
+	// Delta is the text appended to a text or reasoning part since its
+	// previous update. It is empty for tool parts, and for text which was
+	// rewritten rather than appended to.
+	Delta string
+}
+
+// PromptStream yields the updates for a prompt until the session goes idle.
+//
+//	stream := client.Session.PromptStreaming(ctx, sessionID, params)
+//	defer stream.Close()
+//	for stream.Next() {
+//		fmt.Print(stream.Current().Delta)
+//	}
+//	if err := stream.Err(); err != nil {
+//		...
+//	}
+type PromptStream struct {
+	updates chan PromptUpdate
+	cancel  context.CancelFunc
+
+	cur PromptUpdate
+
+	mu       sync.Mutex
+	err      error
+	response *SessionPromptResponse
+}
+
+// PromptStreaming starts a prompt like [SessionService.Prompt], but instead of
+// blocking until the reply is complete it returns a stream of updates to the
+// reply's text, reasoning and tool parts. The events are read with
+// [EventService.ListStreamingResumable], so a dropped connection is resumed
+// without losing updates. The stream ends once the session goes idle. If ctx
+// is cancelled, or the stream is closed, before that happens the session is
+// aborted.
+//
+// The reply is told apart from the replies to other prompts by the ID of the
+// prompt's user message, which is generated when params.MessageID is unset.
+func (r *SessionService) PromptStreaming(ctx context.Context, id string, params SessionPromptParams, opts ...option.RequestOption) *PromptStream {
+	if params.MessageID.Value == "" {
+		params.MessageID = F(newMessageID())
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s := &PromptStream{
+		updates: make(chan PromptUpdate),
+		cancel:  cancel,
+	}
+
+	connected := make(chan struct{})
+	events := NewEventService(r.Options...).ListStreamingResumable(ctx, EventListParams{Directory: params.Directory}, ssestream.ReconnectPolicy{
+		MaxAttempts: promptReconnectAttempts,
+		OnConnect:   sync.OnceFunc(func() { close(connected) }),
+	}, opts...)
+
+	go s.run(ctx, r, id, params, events, connected, opts)
+	return s
+}
+
+// promptReconnectAttempts is how many times in a row the event stream of a
+// prompt may fail to reconnect before the stream gives up.
+const promptReconnectAttempts = 5
+
+type promptResult struct {
+	res *SessionPromptResponse
+	err error
+}
+
+func (s *PromptStream) run(ctx context.Context, r *SessionService, id string, params SessionPromptParams, events *ssestream.ReconnectingStream[EventListResponse], connected <-chan struct{}, opts []option.RequestOption) {
+	defer close(s.updates)
+	defer s.cancel()
+
+	incoming := make(chan EventListResponseUnion)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer close(incoming)
+		defer events.Close()
+		for events.Next() {
+			select {
+			case incoming <- events.Current().AsUnion():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Subscribe before sending the prompt so that no events are missed.
+	select {
+	case <-connected:
+	case <-stopped:
+		s.finish(nil, events.Err())
+		return
+	case <-ctx.Done():
+		<-stopped
+		s.finish(nil, ctx.Err())
+		return
+	}
+
+	prompt := make(chan promptResult, 1)
+	go func() {
+		res, err := r.Prompt(ctx, id, params, opts...)
+		prompt <- promptResult{res, err}
+	}()
+
+	var (
+		result     *promptResult
+		idle       bool
+		sessionErr error
+		streamErr  error
+		replies    = map[string]bool{}
+		texts      = map[string]string{}
+	)
+
+	for result == nil || !idle {
+		select {
+		case <-ctx.Done():
+			s.abort(r, id, params, opts)
+			s.finish(nil, ctx.Err())
+			return
+
+		case res := <-prompt:
+			if res.err != nil && ctx.Err() != nil {
+				s.abort(r, id, params, opts)
+				s.finish(nil, ctx.Err())
+				return
+			}
+			if res.err != nil {
+				s.finish(nil, res.err)
+				return
+			}
+			result = &res
+			if incoming == nil {
+				idle = true
+			}
+
+		case event, ok := <-incoming:
+			if !ok {
+				// The event stream gave up. There is nothing left to stream,
+				// but the prompt itself may still complete.
+				incoming = nil
+				streamErr = events.Err()
+				idle = result != nil
+				continue
+			}
+			if sid, ok := EventSessionID(event); !ok || sid != id {
+				continue
+			}
+
+			switch e := event.(type) {
+			case EventListResponseEventMessageUpdated:
+				info := e.Properties.Info
+				// Every step of the reply is an assistant message answering
+				// the prompt's user message.
+				if info.Role == MessageRoleAssistant && info.ParentID == params.MessageID.Value {
+					replies[info.ID] = true
+				}
+			case EventListResponseEventMessagePartUpdated:
+				part := e.Properties.Part
+				if !replies[part.MessageID] {
+					continue
+				}
+				update := PromptUpdate{MessageID: part.MessageID, Part: part.AsUnion()}
+				switch part.Type {
+				case PartTypeText, PartTypeReasoning:
+					previous := texts[part.ID]
+					texts[part.ID] = part.Text
+					if strings.HasPrefix(part.Text, previous) {
+						update.Delta = part.Text[len(previous):]
+					}
+				case PartTypeTool:
+				default:
+					continue
+				}
+				select {
+				case s.updates <- update:
+				case <-ctx.Done():
+					s.abort(r, id, params, opts)
+					s.finish(nil, ctx.Err())
+					return
+				}
+			case EventListResponseEventSessionError:
+				sessionErr = e.Properties.AsError()
+			case EventListResponseEventSessionIdle:
+				idle = true
+			}
+		}
+	}
+
+	if sessionErr == nil {
+		sessionErr = streamErr
+	}
+	s.finish(result.res, sessionErr)
+}
+
+var (
+	messageIDMu   sync.Mutex
+	lastTimestamp int64
+	counter       int64
+)
+
+// newMessageID generates an ascending message ID in the format of the server,
+// so that the prompt sorts after the messages already in the session.
+func newMessageID() string {
+	messageIDMu.Lock()
+	timestamp := time.Now().UnixMilli()
+	if timestamp != lastTimestamp {
+		lastTimestamp = timestamp
+		counter = 0
+	}
+	counter++
+	now := uint64(timestamp)*0x1000 + uint64(counter)
+	messageIDMu.Unlock()
+
+	var timeBytes [6]byte
+	for i := range timeBytes {
+		timeBytes[i] = byte(now >> (40 - 8*i))
+	}
+
+	const chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	random := make([]byte, 14)
+	rand.Read(random)
+	for i, b := range random {
+		random[i] = chars[b%62]
+	}
+	return "msg_" + hex.EncodeToString(timeBytes[:]) + string(random)
+}
+
+// abort stops the prompt on the server. ctx is already done at this point, so
+// the request gets a context of its own.
+func (s *PromptStream) abort(r *SessionService, id string, params SessionPromptParams, opts []option.RequestOption) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r.Abort(ctx, id, SessionAbortParams{Directory: params.Directory}, opts...)
+}
+
+func (s *PromptStream) finish(res *SessionPromptResponse, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.response = res
+	s.err = err
+}
+
+// Next blocks until the next update is available. It returns false once the
+// session has gone idle, or the stream failed; call [PromptStream.Err] to find
+// out which.
+func (s *PromptStream) Next() bool {
+	update, ok := <-s.updates
+	if !ok {
+		return false
+	}
+	s.cur = update
+	return true
+}
+
+func (s *PromptStream) Current() PromptUpdate {
+	return s.cur
+}
+
+// Err returns the error which ended the stream, if any. It is the context's
+// error if the prompt was aborted, a [*SessionError] if the session reported
+// one, and the event stream's error if it gave up before the session went
+// idle.
+func (s *PromptStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Response returns the completed reply once [PromptStream.Next] has returned
+// false, or nil if the prompt did not complete.
+func (s *PromptStream) Response() *SessionPromptResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.response
+}
+
+// Close stops the stream, aborting the prompt if it is still running, and
+// waits for it to shut down.
+func (s *PromptStream) Close() error {
+	s.cancel()
+	for range s.updates {
+	}
+	return nil
+}
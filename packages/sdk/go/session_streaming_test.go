@@ -0,0 +1,187 @@
+package opencode_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/option"
+)
+
+const streamingAssistant = `{"id":"msg_2","parentID":"msg_1","role":"assistant","sessionID":"ses_1","time":{"created":2},"cost":0,"mode":"build","modelID":"m","providerID":"p","path":{"cwd":"/","root":"/"},"system":[],"tokens":{"input":0,"output":0,"reasoning":0,"cache":{"read":0,"write":0}}}`
+
+func streamingPart(session, message, id, typ, extra string) string {
+	return `{"type":"message.part.updated","properties":{"part":{"id":"` + id + `","messageID":"` + message + `","sessionID":"` + session + `","type":"` + typ + `"` + extra + `}}}`
+}
+
+func TestSessionPromptStreaming(t *testing.T) {
+	prompted := make(chan struct{})
+	var prompt struct {
+		MessageID string `json:"messageID"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/event":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-prompted
+			assistant := strings.Replace(streamingAssistant, "msg_1", prompt.MessageID, 1)
+			for _, event := range []string{
+				// Another client's prompt, seen before this one.
+				`{"type":"message.updated","properties":{"info":{"id":"msg_0","role":"user","sessionID":"ses_1","time":{"created":1}}}}`,
+				`{"type":"message.updated","properties":{"info":{"id":"` + prompt.MessageID + `","role":"user","sessionID":"ses_1","time":{"created":1}}}}`,
+				streamingPart("ses_1", prompt.MessageID, "prt_1", "text", `,"text":"prompt"`),
+				`{"type":"message.updated","properties":{"info":` + assistant + `}}`,
+				// A reply to another prompt, whose ID sorts after the prompt's.
+				`{"type":"message.updated","properties":{"info":` + strings.Replace(strings.Replace(streamingAssistant, "msg_2", "msg_3", 1), "msg_1", "msg_0", 1) + `}}`,
+				streamingPart("ses_1", "msg_3", "prt_8", "text", `,"text":"other reply"`),
+				streamingPart("ses_1", "msg_2", "prt_2", "step-start", ""),
+				streamingPart("ses_1", "msg_2", "prt_3", "text", `,"text":"Hel"`),
+				streamingPart("ses_2", "msg_9", "prt_9", "text", `,"text":"other session"`),
+				streamingPart("ses_1", "msg_2", "prt_3", "text", `,"text":"Hello"`),
+				streamingPart("ses_1", "msg_2", "prt_4", "tool", `,"callID":"call_1","tool":"bash","state":{"status":"pending"}`),
+				`{"type":"session.idle","properties":{"sessionID":"ses_1"}}`,
+			} {
+				fmt.Fprintf(w, "data: %s\n\n", event)
+				w.(http.Flusher).Flush()
+			}
+			<-r.Context().Done()
+		case "/session/ses_1/message":
+			json.NewDecoder(r.Body).Decode(&prompt)
+			close(prompted)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"info":` + streamingAssistant + `,"parts":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := opencode.NewClient(option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	stream := client.Session.PromptStreaming(context.Background(), "ses_1", opencode.SessionPromptParams{
+		Parts: opencode.F([]opencode.SessionPromptParamsPartUnion{opencode.TextPartInputParam{
+			Type: opencode.F(opencode.TextPartInputTypeText),
+			Text: opencode.F("prompt"),
+		}}),
+	})
+	defer stream.Close()
+
+	var deltas []string
+	var tools int
+	for stream.Next() {
+		update := stream.Current()
+		if update.MessageID != "msg_2" {
+			t.Errorf("unexpected update for message %s", update.MessageID)
+		}
+		switch update.Part.(type) {
+		case opencode.TextPart:
+			deltas = append(deltas, update.Delta)
+		case opencode.ToolPart:
+			tools++
+		default:
+			t.Errorf("unexpected part %T", update.Part)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("err should be nil: %s", err.Error())
+	}
+	if len(deltas) != 2 || deltas[0] != "Hel" || deltas[1] != "lo" {
+		t.Errorf("expected deltas [Hel lo], got %q", deltas)
+	}
+	if tools != 1 {
+		t.Errorf("expected 1 tool update, got %d", tools)
+	}
+	if res := stream.Response(); res == nil || res.Info.ID != "msg_2" {
+		t.Errorf("expected response for msg_2, got %v", res)
+	}
+	if !strings.HasPrefix(prompt.MessageID, "msg_") {
+		t.Errorf("expected the prompt to be sent with a message ID, got %q", prompt.MessageID)
+	}
+}
+
+func TestSessionPromptStreamingAbortsOnCancel(t *testing.T) {
+	var aborted atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/event":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case "/session/ses_1/message":
+			// The server only notices the client went away once the body
+			// has been read.
+			io.ReadAll(r.Body)
+			<-r.Context().Done()
+		case "/session/ses_1/abort":
+			aborted.Store(true)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`true`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := opencode.NewClient(option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	stream := client.Session.PromptStreaming(ctx, "ses_1", opencode.SessionPromptParams{})
+	for stream.Next() {
+	}
+	if err := stream.Err(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if !aborted.Load() {
+		t.Errorf("expected the session to be aborted")
+	}
+}
+
+func TestSessionPromptStreamingStreamError(t *testing.T) {
+	var connections atomic.Int32
+	prompted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/event":
+			// The first connection drops and the server refuses the next.
+			if connections.Add(1) > 1 {
+				http.Error(w, `{"error":"gone"}`, http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-prompted
+		case "/session/ses_1/message":
+			close(prompted)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"info":` + streamingAssistant + `,"parts":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := opencode.NewClient(option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	stream := client.Session.PromptStreaming(context.Background(), "ses_1", opencode.SessionPromptParams{})
+	defer stream.Close()
+	for stream.Next() {
+	}
+	var apierr *opencode.Error
+	if err := stream.Err(); !errors.As(err, &apierr) || apierr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected the stream error, got %v", err)
+	}
+	if res := stream.Response(); res == nil {
+		t.Errorf("expected the response of the completed prompt")
+	}
+}
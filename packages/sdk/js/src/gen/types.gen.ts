@@ -303,6 +303,8 @@ export type McpRemoteConfig = {
  */
 export type LayoutConfig = "auto" | "stretch"
 
+export type NotificationMethodConfig = "osc9" | "osc777" | "bell" | "command" | "none"
+
 export type Config = {
   /**
    * JSON schema reference for configuration validation
@@ -321,6 +323,27 @@ export type Config = {
      * TUI scroll speed
      */
     scroll_speed?: number
+    /**
+     * Notifications sent while the terminal is not focused, by OSC 9 by default
+     */
+    notifications?: {
+      /**
+       * How to notify when a session finishes its work
+       */
+      idle?: NotificationMethodConfig
+      /**
+       * How to notify when a session fails
+       */
+      error?: NotificationMethodConfig
+      /**
+       * How to notify when a permission is requested
+       */
+      permission?: NotificationMethodConfig
+      /**
+       * Command run by the command method with the title and message as arguments, e.g. notify-send
+       */
+      command?: string
+    }
   }
   /**
    * Command configuration, see https://opencode.ai/docs/commands
@@ -615,6 +638,7 @@ export type AssistantMessage = {
   id: string
   sessionID: string
   role: "assistant"
+  parentID?: string
   time: {
     created: number
     completed?: number
@@ -1035,6 +1059,21 @@ export type EventLspClientDiagnostics = {
   properties: {
     serverID: string
     path: string
+    diagnostics: Array<{
+      range: {
+        start: {
+          line: number
+          character: number
+        }
+        end: {
+          line: number
+          character: number
+        }
+      }
+      severity?: number
+      source?: string
+      message: string
+    }>
   }
 }
 
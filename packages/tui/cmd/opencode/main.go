@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	flag "github.com/spf13/pflag"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/packages/ssestream"
+	"github.com/sst/opencode/internal/api"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/clipboard"
+	"github.com/sst/opencode/internal/exporter"
+	"github.com/sst/opencode/internal/headless"
+	"github.com/sst/opencode/internal/tui"
+	"github.com/sst/opencode/internal/util"
+	"golang.org/x/sync/errgroup"
+)
+
+var Version = "dev"
+
+func main() {
+	version := Version
+	if version != "dev" && !strings.HasPrefix(Version, "v") {
+		version = "v" + Version
+	}
+
+	var model *string = flag.String("model", "", "model to begin with")
+	var prompt *string = flag.String("prompt", "", "prompt to begin with")
+	var agent *string = flag.String("agent", "", "agent to begin with")
+	var sessionID *string = flag.String("session", "", "session ID")
+	var serverTokenFile *string = flag.String("server-token-file", "", "file holding the bearer token for the server (or OPENCODE_SERVER_TOKEN)")
+	var serverCACert *string = flag.String("server-ca-cert", "", "PEM bundle of CAs to trust for the server (or OPENCODE_SERVER_CA_CERT)")
+	var serverClientCert *string = flag.String("server-client-cert", "", "PEM client certificate for the server (or OPENCODE_SERVER_CLIENT_CERT)")
+	var serverClientKey *string = flag.String("server-client-key", "", "PEM client key for the server (or OPENCODE_SERVER_CLIENT_KEY)")
+	var headlessMode *bool = flag.Bool("headless", false, "send the prompt without the TUI and stream the reply to stdout (same as the run command)")
+	var format *string = flag.String("format", "text", "headless output format: text or json (one JSON object per line)")
+	var approve *string = flag.String("approve", "never", "how headless mode answers permission requests: never, once or always")
+	var exportPath *string = flag.String("export", "", "export the session to this file after a headless run, or right away with --session and no prompt")
+	var exportFormat *string = flag.String("export-format", "", "export format: json, markdown or html (defaults to the file extension)")
+	var usageDays *int = flag.Int("days", 30, "days covered by the usage command, 0 for all time")
+	var usageCSV *string = flag.String("csv", "", "export the usage command's aggregated rows to this CSV file, - for stdout")
+	flag.Parse()
+
+	// "opencode run [message...]" is shorthand for --headless, with the
+	// remaining arguments as the prompt.
+	args := flag.Args()
+	// "opencode usage" reports the tokens and cost of every session.
+	usageMode := len(args) > 0 && args[0] == "usage"
+	if len(args) > 0 && args[0] == "run" {
+		*headlessMode = true
+		if message := strings.Join(args[1:], " "); message != "" {
+			if *prompt != "" {
+				message = *prompt + "\n" + message
+			}
+			prompt = &message
+		}
+	}
+	outputFormat, err := headless.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(headless.ExitSetupError)
+	}
+	approval, err := headless.ParseApproval(*approve)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(headless.ExitSetupError)
+	}
+	var sessionExportFormat exporter.Format
+	if *exportPath != "" {
+		// Exporting is done without the TUI.
+		*headlessMode = true
+		sessionExportFormat = exporter.FormatMarkdown
+		if format, ok := exporter.FormatForPath(*exportPath); ok {
+			sessionExportFormat = format
+		}
+		if *exportFormat != "" {
+			sessionExportFormat, err = exporter.ParseFormat(*exportFormat)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(headless.ExitSetupError)
+			}
+		}
+	}
+
+	connection := api.ConnectionFromEnv()
+	if *serverTokenFile != "" {
+		connection.Token, err = api.ReadTokenFile(*serverTokenFile)
+		if err != nil {
+			slog.Error("Failed to read the server token", "error", err)
+			os.Exit(1)
+		}
+	}
+	if *serverCACert != "" {
+		connection.CACert = *serverCACert
+	}
+	if *serverClientCert != "" {
+		connection.ClientCert = *serverClientCert
+	}
+	if *serverClientKey != "" {
+		connection.ClientKey = *serverClientKey
+	}
+	clientOptions, err := connection.ClientOptions()
+	if err != nil {
+		slog.Error("Invalid server connection settings", "error", err)
+		os.Exit(1)
+	}
+
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		slog.Error("Failed to stat stdin", "error", err)
+		os.Exit(1)
+	}
+
+	// Check if there's data piped to stdin
+	if (stat.Mode() & os.ModeCharDevice) == 0 {
+		stdin, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			slog.Error("Failed to read stdin", "error", err)
+			os.Exit(1)
+		}
+		stdinContent := strings.TrimSpace(string(stdin))
+		if stdinContent != "" {
+			if prompt == nil || *prompt == "" {
+				prompt = &stdinContent
+			} else {
+				combined := *prompt + "\n" + stdinContent
+				prompt = &combined
+			}
+		}
+	}
+
+	httpClient := opencode.NewClient(clientOptions...)
+
+	var agents []opencode.Agent
+	var path *opencode.Path
+	var project *opencode.Project
+
+	batch := errgroup.Group{}
+
+	batch.Go(func() error {
+		result, err := httpClient.Project.Current(context.Background(), opencode.ProjectCurrentParams{})
+		if err != nil {
+			return err
+		}
+		project = result
+		return nil
+	})
+
+	batch.Go(func() error {
+		result, err := httpClient.Agent.List(context.Background(), opencode.AgentListParams{})
+		if err != nil {
+			return err
+		}
+		agents = *result
+		return nil
+	})
+
+	batch.Go(func() error {
+		result, err := httpClient.Path.Get(context.Background(), opencode.PathGetParams{})
+		if err != nil {
+			return err
+		}
+		path = result
+		return nil
+	})
+
+	err = batch.Wait()
+	if err != nil {
+		if *headlessMode || usageMode {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(headless.ExitSetupError)
+		}
+		panic(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	apiHandler := util.NewAPILogHandler(ctx, httpClient, "tui", slog.LevelDebug)
+	logger := slog.New(apiHandler)
+	slog.SetDefault(logger)
+
+	if usageMode {
+		code := headless.Usage(ctx, httpClient, headless.UsageOptions{
+			Format: outputFormat,
+			Days:   *usageDays,
+			CSV:    *usageCSV,
+		})
+		cancel()
+		os.Exit(code)
+	}
+
+	if *headlessMode {
+		app_, err := app.New(ctx, version, project, path, agents, httpClient, model, prompt, agent, sessionID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(headless.ExitSetupError)
+		}
+		runCtx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+		code := headless.Run(runCtx, app_, *prompt, headless.Options{
+			Format:       outputFormat,
+			Approve:      approval,
+			Export:       *exportPath,
+			ExportFormat: sessionExportFormat,
+		})
+		stop()
+		cancel()
+		os.Exit(code)
+	}
+
+	slog.Debug("TUI launched")
+
+	go func() {
+		err = clipboard.Init()
+		if err != nil {
+			slog.Error("Failed to initialize clipboard", "error", err)
+		}
+	}()
+
+	// Create main context for the application
+	app_, err := app.New(ctx, version, project, path, agents, httpClient, model, prompt, agent, sessionID)
+	if err != nil {
+		panic(err)
+	}
+
+	tuiModel := tui.NewModel(app_).(*tui.Model)
+	program := tea.NewProgram(
+		tuiModel,
+		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
+	)
+
+	// Set up signal handling for graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+
+	go func() {
+		stream := httpClient.Event.ListStreamingResumable(ctx, opencode.EventListParams{}, ssestream.ReconnectPolicy{
+			OnReconnect: func(attempt int, err error) {
+				slog.Warn("Event stream disconnected, reconnecting", "attempt", attempt, "error", err)
+			},
+		})
+		defer stream.Close()
+		for stream.Next() {
+			evt := stream.Current().AsUnion()
+			program.Send(evt)
+		}
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			slog.Error("Error streaming events", "error", err)
+			program.Send(err)
+		}
+	}()
+
+	go api.Start(ctx, program, httpClient)
+
+	// Handle signals in a separate goroutine
+	go func() {
+		sig := <-sigChan
+		slog.Info("Received signal, shutting down gracefully", "signal", sig)
+		tuiModel.Cleanup()
+		program.Quit()
+	}()
+
+	// Run the TUI
+	result, err := program.Run()
+	if err != nil {
+		slog.Error("TUI error", "error", err)
+	}
+
+	tuiModel.Cleanup()
+	slog.Info("TUI exited", "result", result)
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sst/opencode-sdk-go/option"
+)
+
+// Connection describes how to reach and authenticate with the opencode
+// server. The same options are used for regular requests, the event stream,
+// the TUI control long-poll and log shipping.
+type Connection struct {
+	URL string
+	// Token is sent as a bearer token. It takes precedence over basic auth.
+	Token    string
+	Username string
+	Password string
+	// CACert is a PEM bundle of additional certificate authorities to trust.
+	CACert string
+	// ClientCert and ClientKey are a PEM client certificate and key to
+	// present to the server.
+	ClientCert string
+	ClientKey  string
+}
+
+// ConnectionFromEnv reads the connection settings from the OPENCODE_SERVER*
+// environment variables.
+func ConnectionFromEnv() Connection {
+	return Connection{
+		URL:        os.Getenv("OPENCODE_SERVER"),
+		Token:      os.Getenv("OPENCODE_SERVER_TOKEN"),
+		Username:   os.Getenv("OPENCODE_SERVER_USERNAME"),
+		Password:   os.Getenv("OPENCODE_SERVER_PASSWORD"),
+		CACert:     os.Getenv("OPENCODE_SERVER_CA_CERT"),
+		ClientCert: os.Getenv("OPENCODE_SERVER_CLIENT_CERT"),
+		ClientKey:  os.Getenv("OPENCODE_SERVER_CLIENT_KEY"),
+	}
+}
+
+// ReadTokenFile reads a bearer token from a file. Unlike a command line flag,
+// the token then doesn't show up in the process list or the shell history.
+func ReadTokenFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", path)
+	}
+	return token, nil
+}
+
+// ClientOptions returns the SDK options for the connection. A URL of the form
+// unix:///path/to/sock connects to a server listening on that unix socket.
+func (c Connection) ClientOptions() ([]option.RequestOption, error) {
+	var opts []option.RequestOption
+	tls := c.CACert != "" || c.ClientCert != "" || c.ClientKey != ""
+	if socketPath, ok := strings.CutPrefix(c.URL, "unix://"); ok {
+		if socketPath == "" {
+			return nil, fmt.Errorf("server url %s is missing a socket path", c.URL)
+		}
+		if tls {
+			opts = append(opts, option.WithBaseURL("https://localhost/"))
+		}
+		opts = append(opts, option.WithUnixSocket(socketPath))
+	} else {
+		opts = append(opts, option.WithBaseURL(c.URL))
+	}
+
+	switch {
+	case c.Token != "":
+		opts = append(opts, option.WithBearerToken(c.Token))
+	case c.Username != "" || c.Password != "":
+		opts = append(opts, option.WithBasicAuth(c.Username, c.Password))
+	}
+
+	if tls {
+		tlsConfig, err := option.LoadTLSConfig(c.CACert, c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, option.WithTLSConfig(tlsConfig))
+	}
+
+	return opts, nil
+}
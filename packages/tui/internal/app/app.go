@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -52,6 +53,14 @@ type App struct {
 	IsLeaderSequence  bool
 	IsBashMode        bool
 	ScrollSpeed       int
+	Tabs              []*Tab
+	activeTab         int
+	// diagnostics are the diagnostics of each language server and file
+	diagnostics map[string][]Diagnostic
+	// todos are the todo lists of the sessions, by session ID
+	todos map[string][]Todo
+	// changes are the files changed in the sessions, by session ID
+	changes map[string][]FileChange
 }
 
 func (a *App) Agent() *opencode.Agent {
@@ -205,6 +214,7 @@ func New(
 		AgentIndex:     agentIndex,
 		Session:        &opencode.Session{},
 		Messages:       []Message{},
+		Tabs:           []*Tab{{}},
 		Commands:       commands.LoadFromConfig(configInfo, *customCommands),
 		InitialModel:   initialModel,
 		InitialPrompt:  initialPrompt,
@@ -461,16 +471,67 @@ func findProviderByID(providers []opencode.Provider, providerID string) *opencod
 }
 
 func (a *App) InitializeProvider() tea.Cmd {
-	providersResponse, err := a.Client.App.Providers(context.Background(), opencode.AppProvidersParams{})
+	selectedProvider, selectedModel, err := a.selectModel(context.Background())
 	if err != nil {
-		slog.Error("Failed to list providers", "error", err)
+		slog.Error("Failed to select a model", "error", err)
 		// TODO: notify user
 		return nil
 	}
+
+	var cmds []tea.Cmd
+	cmds = append(cmds, util.CmdHandler(ModelSelectedMsg{
+		Provider: *selectedProvider,
+		Model:    *selectedModel,
+	}))
+
+	// Load initial session if provided
+	if a.InitialSession != nil && *a.InitialSession != "" {
+		cmds = append(cmds, func() tea.Msg {
+			// Find the session by ID
+			sessions, err := a.ListSessions(context.Background())
+			if err != nil {
+				slog.Error("Failed to list sessions for initial session", "error", err)
+				return toast.NewErrorToast("Failed to load initial session")()
+			}
+
+			for _, session := range sessions {
+				if session.ID == *a.InitialSession {
+					return SessionSelectedMsg(&session)
+				}
+			}
+
+			slog.Warn("Initial session not found", "sessionID", *a.InitialSession)
+			return toast.NewErrorToast("Session not found: " + *a.InitialSession)()
+		})
+	}
+
+	if a.InitialPrompt != nil && *a.InitialPrompt != "" {
+		cmds = append(cmds, util.CmdHandler(SendPrompt{Text: *a.InitialPrompt}))
+	}
+	return tea.Sequence(cmds...)
+}
+
+// InitializeModel selects the initial provider and model the same way as
+// InitializeProvider, but applies them directly instead of through the Bubble
+// Tea runtime, for use without a TUI.
+func (a *App) InitializeModel(ctx context.Context) error {
+	provider, model, err := a.selectModel(ctx)
+	if err != nil {
+		return err
+	}
+	a.Provider = provider
+	a.Model = model
+	return nil
+}
+
+func (a *App) selectModel(ctx context.Context) (*opencode.Provider, *opencode.Model, error) {
+	providersResponse, err := a.Client.App.Providers(ctx, opencode.AppProvidersParams{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list providers: %w", err)
+	}
 	providers := providersResponse.Providers
 	if len(providers) == 0 {
-		slog.Error("No providers configured")
-		return nil
+		return nil, nil, errors.New("no providers configured")
 	}
 
 	a.Providers = providers
@@ -601,41 +662,9 @@ func (a *App) InitializeProvider() tea.Cmd {
 
 	// Final safety check
 	if selectedProvider == nil || selectedModel == nil {
-		slog.Error("Failed to select any model")
-		return nil
+		return nil, nil, errors.New("no model available")
 	}
-
-	var cmds []tea.Cmd
-	cmds = append(cmds, util.CmdHandler(ModelSelectedMsg{
-		Provider: *selectedProvider,
-		Model:    *selectedModel,
-	}))
-
-	// Load initial session if provided
-	if a.InitialSession != nil && *a.InitialSession != "" {
-		cmds = append(cmds, func() tea.Msg {
-			// Find the session by ID
-			sessions, err := a.ListSessions(context.Background())
-			if err != nil {
-				slog.Error("Failed to list sessions for initial session", "error", err)
-				return toast.NewErrorToast("Failed to load initial session")()
-			}
-
-			for _, session := range sessions {
-				if session.ID == *a.InitialSession {
-					return SessionSelectedMsg(&session)
-				}
-			}
-
-			slog.Warn("Initial session not found", "sessionID", *a.InitialSession)
-			return toast.NewErrorToast("Session not found: " + *a.InitialSession)()
-		})
-	}
-
-	if a.InitialPrompt != nil && *a.InitialPrompt != "" {
-		cmds = append(cmds, util.CmdHandler(SendPrompt{Text: *a.InitialPrompt}))
-	}
-	return tea.Sequence(cmds...)
+	return selectedProvider, selectedModel, nil
 }
 
 func getDefaultModel(
@@ -660,11 +689,7 @@ func (a *App) IsBusy() bool {
 	if a.IsCompacting() {
 		return true
 	}
-	lastMessage := a.Messages[len(a.Messages)-1]
-	if casted, ok := lastMessage.Info.(opencode.AssistantMessage); ok {
-		return casted.Time.Completed == 0
-	}
-	return false
+	return isBusy(a.Messages)
 }
 
 func (a *App) IsCompacting() bool {
@@ -780,6 +805,26 @@ func (a *App) CreateSession(ctx context.Context) (*opencode.Session, error) {
 	return session, nil
 }
 
+// ForkSession creates a new session with a copy of the current session's
+// messages before beforeMessageID, or all of them if it is empty.
+func (a *App) ForkSession(ctx context.Context, beforeMessageID string) (*opencode.Session, error) {
+	params := opencode.SessionForkParams{}
+	if beforeMessageID != "" {
+		params.MessageID = opencode.F(beforeMessageID)
+	}
+	session, err := a.Client.Session.Fork(ctx, a.Session.ID, params)
+	if err != nil {
+		return nil, err
+	}
+	if a.Session.Title != "" {
+		title := a.Session.Title + " (fork)"
+		if err := a.UpdateSession(ctx, session.ID, title); err == nil {
+			session.Title = title
+		}
+	}
+	return session, nil
+}
+
 func (a *App) SendPrompt(ctx context.Context, prompt Prompt) (*App, tea.Cmd) {
 	var cmds []tea.Cmd
 	if a.Session.ID == "" {
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/components/diff"
+)
+
+// FileChange is a file changed since the start of a session, with its diff
+// against the snapshot taken before the session first changed files
+type FileChange struct {
+	Path    string
+	Diff    string
+	Added   int
+	Removed int
+}
+
+// FileChangesMsg is sent with the files changed in a session
+type FileChangesMsg struct {
+	SessionID string
+	Changes   []FileChange
+}
+
+// FetchFileChanges fetches the diff of the files changed in the session
+func (a *App) FetchFileChanges(sessionID string) tea.Cmd {
+	return func() tea.Msg {
+		changes, err := a.fileChanges(context.Background(), sessionID)
+		if err != nil {
+			slog.Error("Failed to fetch session diff", "error", err)
+			return nil
+		}
+		return FileChangesMsg{SessionID: sessionID, Changes: changes}
+	}
+}
+
+func (a *App) fileChanges(ctx context.Context, sessionID string) ([]FileChange, error) {
+	response, err := a.Client.Session.Diff(ctx, sessionID, opencode.SessionDiffParams{})
+	if err != nil {
+		return nil, err
+	}
+	return ParseFileChanges(*response)
+}
+
+// UpdateFileChanges replaces the files changed in a session
+func (a *App) UpdateFileChanges(msg FileChangesMsg) {
+	if a.changes == nil {
+		a.changes = map[string][]FileChange{}
+	}
+	a.changes[msg.SessionID] = msg.Changes
+}
+
+// FileChanges returns the files changed in the current session
+func (a *App) FileChanges() []FileChange {
+	if a.Session == nil || a.Session.ID == "" {
+		return nil
+	}
+	return a.changes[a.Session.ID]
+}
+
+// ParseFileChanges splits a git diff of several files into the diff of each,
+// counting its added and removed lines
+func ParseFileChanges(diffText string) ([]FileChange, error) {
+	var changes []FileChange
+	var current []string
+	flush := func() error {
+		if len(current) == 0 {
+			return nil
+		}
+		text := strings.Join(current, "\n") + "\n"
+		change := FileChange{Path: diffPath(current[0]), Diff: text}
+		stats, err := diff.ParseStats(text)
+		if err != nil {
+			return err
+		}
+		for _, stat := range stats {
+			change.Added += stat.Added
+			change.Removed += stat.Removed
+		}
+		changes = append(changes, change)
+		current = nil
+		return nil
+	}
+
+	for line := range strings.SplitSeq(diffText, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		if len(current) > 0 || strings.HasPrefix(line, "diff --git ") {
+			current = append(current, line)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// diffPath returns the path of the file of a "diff --git a/path b/path"
+// header
+func diffPath(header string) string {
+	header = strings.TrimPrefix(header, "diff --git ")
+	if i := strings.LastIndex(header, " b/"); i >= 0 {
+		return header[i+3:]
+	}
+	return strings.TrimPrefix(header, "a/")
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFileChanges(t *testing.T) {
+	text := strings.Join([]string{
+		"diff --git a/src/main.go b/src/main.go",
+		"index 1111111..2222222 100644",
+		"--- a/src/main.go",
+		"+++ b/src/main.go",
+		"@@ -1,3 +1,4 @@",
+		" package main",
+		"-func old() {}",
+		"+func new() {}",
+		"+func other() {}",
+		" ",
+		"diff --git a/README.md b/README.md",
+		"deleted file mode 100644",
+		"index 3333333..0000000",
+		"--- a/README.md",
+		"+++ /dev/null",
+		"@@ -1,2 +0,0 @@",
+		"-# Title",
+		"-text",
+	}, "\n")
+
+	changes, err := ParseFileChanges(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+	if c := changes[0]; c.Path != "src/main.go" || c.Added != 2 || c.Removed != 1 {
+		t.Errorf("unexpected first change %+v", c)
+	}
+	if c := changes[1]; c.Path != "README.md" || c.Added != 0 || c.Removed != 2 {
+		t.Errorf("unexpected second change %+v", c)
+	}
+	if !strings.HasPrefix(changes[1].Diff, "diff --git a/README.md") || strings.Contains(changes[1].Diff, "main.go") {
+		t.Errorf("expected the diff of the second file only, got %q", changes[1].Diff)
+	}
+
+	if changes, _ := ParseFileChanges(""); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
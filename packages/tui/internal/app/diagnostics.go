@@ -0,0 +1,126 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// DiagnosticSeverity is the severity of a diagnostic, as numbered by the
+// language server protocol
+type DiagnosticSeverity int
+
+const (
+	SeverityError DiagnosticSeverity = iota + 1
+	SeverityWarning
+	SeverityInformation
+	SeverityHint
+)
+
+// Severities are the severities from the most to the least severe
+var Severities = []DiagnosticSeverity{SeverityError, SeverityWarning, SeverityInformation, SeverityHint}
+
+func (s DiagnosticSeverity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInformation:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	}
+	return "error"
+}
+
+// Diagnostic is a problem a language server reported in a file
+type Diagnostic struct {
+	Path     string
+	ServerID string
+	Severity DiagnosticSeverity
+	// Line and Column are 1-based
+	Line    int
+	Column  int
+	Message string
+	Source  string
+}
+
+// Key identifies the diagnostic among the current ones
+func (d Diagnostic) Key() string {
+	return fmt.Sprintf("%s:%s:%d:%d:%s", d.ServerID, d.Path, d.Line, d.Column, d.Message)
+}
+
+// UpdateDiagnostics replaces the diagnostics a language server reported for a
+// file
+func (a *App) UpdateDiagnostics(event opencode.EventListResponseEventLspClientDiagnosticsProperties) {
+	if a.diagnostics == nil {
+		a.diagnostics = map[string][]Diagnostic{}
+	}
+	key := event.ServerID + "\x00" + event.Path
+	if len(event.Diagnostics) == 0 {
+		delete(a.diagnostics, key)
+		return
+	}
+
+	diagnostics := make([]Diagnostic, 0, len(event.Diagnostics))
+	for _, d := range event.Diagnostics {
+		// Servers may leave the severity out, it is then an error
+		severity := DiagnosticSeverity(d.Severity)
+		if severity < SeverityError || severity > SeverityHint {
+			severity = SeverityError
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Path:     event.Path,
+			ServerID: event.ServerID,
+			Severity: severity,
+			Line:     int(d.Range.Start.Line) + 1,
+			Column:   int(d.Range.Start.Character) + 1,
+			Message:  d.Message,
+			Source:   d.Source,
+		})
+	}
+	a.diagnostics[key] = diagnostics
+}
+
+// Diagnostics returns the current diagnostics of every file, by severity,
+// path and position
+func (a *App) Diagnostics() []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, file := range a.diagnostics {
+		diagnostics = append(diagnostics, file...)
+	}
+	slices.SortStableFunc(diagnostics, func(x, y Diagnostic) int {
+		switch {
+		case x.Severity != y.Severity:
+			return int(x.Severity) - int(y.Severity)
+		case x.Path != y.Path:
+			return strings.Compare(x.Path, y.Path)
+		case x.Line != y.Line:
+			return x.Line - y.Line
+		}
+		return x.Column - y.Column
+	})
+	return diagnostics
+}
+
+// FormatDiagnostics writes the diagnostics for the prompt, a line each with
+// the path relative to the root
+func FormatDiagnostics(diagnostics []Diagnostic, root string) string {
+	var b strings.Builder
+	b.WriteString("Fix these diagnostics:\n")
+	for _, d := range diagnostics {
+		path := d.Path
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		message := strings.Join(strings.Fields(d.Message), " ")
+		fmt.Fprintf(&b, "- %s:%d:%d %s: %s", path, d.Line, d.Column, d.Severity, message)
+		if d.Source != "" {
+			fmt.Fprintf(&b, " (%s)", d.Source)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
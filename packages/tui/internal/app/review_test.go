@@ -0,0 +1,127 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRevertRejected(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("kept.txt", "one\nTWO\nthree\nfour\nfive\nsix\nseven\neight\nNINE\n")
+	write("new.txt", "hello\n")
+
+	changes, err := ParseFileChanges(strings.Join([]string{
+		"diff --git a/kept.txt b/kept.txt",
+		"--- a/kept.txt",
+		"+++ b/kept.txt",
+		"@@ -1,3 +1,3 @@",
+		" one",
+		"-two",
+		"+TWO",
+		" three",
+		"@@ -8,2 +8,2 @@",
+		" eight",
+		"-nine",
+		"+NINE",
+		"diff --git a/new.txt b/new.txt",
+		"new file mode 100644",
+		"--- /dev/null",
+		"+++ b/new.txt",
+		"@@ -0,0 +1 @@",
+		"+hello",
+		"diff --git a/gone.txt b/gone.txt",
+		"deleted file mode 100644",
+		"--- a/gone.txt",
+		"+++ /dev/null",
+		"@@ -1 +0,0 @@",
+		"-bye",
+	}, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hunks, err := ReviewHunks(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hunks) != 4 {
+		t.Fatalf("expected 4 hunks, got %d", len(hunks))
+	}
+	hunks[1].Rejected = true
+	hunks[2].Rejected = true
+	hunks[3].Rejected = true
+
+	reverted, err := RevertRejected(root, hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 3 {
+		t.Errorf("expected 3 reverted hunks, got %d", len(reverted))
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "kept.txt")); string(data) != "one\nTWO\nthree\nfour\nfive\nsix\nseven\neight\nnine\n" {
+		t.Errorf("expected only the second hunk reverted, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected the created file removed, got %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "gone.txt")); string(data) != "bye\n" {
+		t.Errorf("expected the deleted file restored, got %q", data)
+	}
+
+	summary := FormatRejected(hunks)
+	if strings.Contains(summary, "TWO") || !strings.Contains(summary, "kept.txt @@ -8,2 +8,2 @@\n```diff\n eight\n-nine\n+NINE\n```") {
+		t.Errorf("unexpected summary %q", summary)
+	}
+}
+
+func TestRevertRejectedPartially(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "ok.txt"), []byte("NEW\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// moved.txt no longer holds the lines of its hunk
+	if err := os.WriteFile(filepath.Join(root, "moved.txt"), []byte("other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := ParseFileChanges(strings.Join([]string{
+		"diff --git a/ok.txt b/ok.txt",
+		"--- a/ok.txt",
+		"+++ b/ok.txt",
+		"@@ -1 +1 @@",
+		"-old",
+		"+NEW",
+		"diff --git a/moved.txt b/moved.txt",
+		"--- a/moved.txt",
+		"+++ b/moved.txt",
+		"@@ -1 +1 @@",
+		"-old",
+		"+NEW",
+	}, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hunks, err := ReviewHunks(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range hunks {
+		hunks[i].Rejected = true
+	}
+
+	reverted, err := RevertRejected(root, hunks)
+	if err == nil || !strings.Contains(err.Error(), "moved.txt") {
+		t.Errorf("expected an error for moved.txt, got %v", err)
+	}
+	if len(reverted) != 1 || reverted[0].Path != "ok.txt" {
+		t.Fatalf("expected only ok.txt reverted, got %+v", reverted)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "ok.txt")); string(data) != "old\n" {
+		t.Errorf("expected ok.txt reverted, got %q", data)
+	}
+}
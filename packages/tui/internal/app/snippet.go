@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Snippet is a prompt saved by the user and inserted with # in the editor.
+// Its body can hold variables such as {{file}}, and tab-stops such as $1 or
+// ${2:default} to fill in after it's inserted, $0 being where the cursor ends.
+type Snippet struct {
+	Name        string `toml:"name"`
+	Description string `toml:"description,omitempty"`
+	Body        string `toml:"body"`
+}
+
+// SnippetVariables are the variables the editor gives a value to: the system
+// clipboard, the last file attached to the prompt and the current git branch
+var SnippetVariables = []string{"selection", "file", "branch"}
+
+// SnippetStop is a tab-stop of an expanded snippet, at a rune offset of its
+// text and spanning its default text
+type SnippetStop struct {
+	Offset int
+	Length int
+}
+
+// ExpandSnippet replaces the variables of the body with their values and
+// removes the markers of its tab-stops. Variables without a value become
+// tab-stops with their name as default text, after the numbered ones. The
+// stops are returned in the order they are visited, the last one being $0 or
+// the end of the text.
+func ExpandSnippet(body string, values map[string]string) (string, []SnippetStop) {
+	type stop struct {
+		SnippetStop
+		number int
+	}
+	var (
+		text  []rune
+		stops []stop
+		runes = []rune(body)
+	)
+	add := func(number int, value string) {
+		stops = append(stops, stop{SnippetStop{len(text), len([]rune(value))}, number})
+		text = append(text, []rune(value)...)
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		rest := string(runes[i:])
+		switch {
+		case r == '\\' && i+1 < len(runes) && runes[i+1] == '$':
+			text = append(text, '$')
+			i++
+		case strings.HasPrefix(rest, "{{"):
+			end := strings.Index(rest, "}}")
+			if end < 0 {
+				text = append(text, r)
+				continue
+			}
+			name := strings.TrimSpace(rest[2:end])
+			if value := values[name]; value != "" {
+				text = append(text, []rune(value)...)
+			} else {
+				add(-1, name)
+			}
+			i += len([]rune(rest[:end+2])) - 1
+		case r == '$' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			j := i + 1
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			number, _ := strconv.Atoi(string(runes[i+1 : j]))
+			add(number, "")
+			i = j - 1
+		case strings.HasPrefix(rest, "${") && i+2 < len(runes) && unicode.IsDigit(runes[i+2]):
+			end := strings.Index(rest, "}")
+			if end < 0 {
+				text = append(text, r)
+				continue
+			}
+			marker := rest[2:end]
+			number, value, _ := strings.Cut(marker, ":")
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				text = append(text, r)
+				continue
+			}
+			add(n, value)
+			i += len([]rune(rest[:end+1])) - 1
+		default:
+			text = append(text, r)
+		}
+	}
+
+	// Numbered stops first, then the variables without a value, then $0. A
+	// number repeated is only visited once.
+	rank := func(s stop) int {
+		switch {
+		case s.number == 0:
+			return 2
+		case s.number < 0:
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(stops, func(i, j int) bool {
+		if rank(stops[i]) != rank(stops[j]) {
+			return rank(stops[i]) < rank(stops[j])
+		}
+		return rank(stops[i]) == 0 && stops[i].number < stops[j].number
+	})
+	var ordered []SnippetStop
+	for i, s := range stops {
+		if s.number >= 0 && i > 0 && stops[i-1].number == s.number {
+			continue
+		}
+		ordered = append(ordered, s.SnippetStop)
+	}
+	if len(stops) == 0 || stops[len(stops)-1].number != 0 {
+		ordered = append(ordered, SnippetStop{Offset: len(text)})
+	}
+	return string(text), ordered
+}
+
+// SaveSnippet saves a snippet, replacing the one with the same name
+func (s *State) SaveSnippet(snippet Snippet) {
+	s.RemoveSnippet(snippet.Name)
+	s.Snippets = append(s.Snippets, snippet)
+	slices.SortFunc(s.Snippets, func(a, b Snippet) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// RemoveSnippet removes the snippet with the given name
+func (s *State) RemoveSnippet(name string) {
+	s.Snippets = slices.DeleteFunc(s.Snippets, func(snippet Snippet) bool {
+		return snippet.Name == name
+	})
+}
+
+// CurrentBranch returns the git branch checked out in the directory, empty
+// outside of a repository or on a detached head
+func CurrentBranch(dir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	branch := strings.TrimSpace(string(output))
+	if branch == "HEAD" {
+		return ""
+	}
+	return branch
+}
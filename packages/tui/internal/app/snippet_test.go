@@ -0,0 +1,72 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandSnippet(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		text  string
+		stops []SnippetStop
+	}{
+		{
+			name:  "plain text",
+			body:  "explain this",
+			text:  "explain this",
+			stops: []SnippetStop{{12, 0}},
+		},
+		{
+			name:  "variables",
+			body:  "review {{file}} on {{ branch }}",
+			text:  "review main.go on dev",
+			stops: []SnippetStop{{21, 0}},
+		},
+		{
+			name:  "numbered stops",
+			body:  "${2:b} $1 ${1:x}$0 end",
+			text:  "b  x end",
+			stops: []SnippetStop{{2, 0}, {0, 1}, {4, 0}},
+		},
+		{
+			name:  "variables without a value",
+			body:  "{{selection}} in {{file}} $1",
+			text:  "selection in main.go ",
+			stops: []SnippetStop{{21, 0}, {0, 9}, {21, 0}},
+		},
+		{
+			name:  "escapes and stray markers",
+			body:  "cost \\$1, ${x} {{ $",
+			text:  "cost $1, ${x} {{ $",
+			stops: []SnippetStop{{18, 0}},
+		},
+	}
+	values := map[string]string{"file": "main.go", "branch": "dev", "selection": ""}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, stops := ExpandSnippet(tt.body, values)
+			if text != tt.text {
+				t.Errorf("text = %q, want %q", text, tt.text)
+			}
+			if !reflect.DeepEqual(stops, tt.stops) {
+				t.Errorf("stops = %v, want %v", stops, tt.stops)
+			}
+		})
+	}
+}
+
+func TestSnippets(t *testing.T) {
+	s := NewState()
+	s.SaveSnippet(Snippet{Name: "review", Body: "review"})
+	s.SaveSnippet(Snippet{Name: "explain", Body: "explain"})
+	s.SaveSnippet(Snippet{Name: "review", Body: "review {{file}}"})
+	if len(s.Snippets) != 2 || s.Snippets[0].Name != "explain" || s.Snippets[1].Body != "review {{file}}" {
+		t.Fatalf("unexpected snippets %v", s.Snippets)
+	}
+	s.RemoveSnippet("explain")
+	if len(s.Snippets) != 1 || s.Snippets[0].Name != "review" {
+		t.Fatalf("unexpected snippets %v", s.Snippets)
+	}
+}
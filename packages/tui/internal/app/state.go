@@ -37,6 +37,12 @@ type State struct {
 	MessageHistory     []Prompt              `toml:"message_history"`
 	ShowToolDetails    *bool                 `toml:"show_tool_details"`
 	ShowThinkingBlocks *bool                 `toml:"show_thinking_blocks"`
+	ExportFormat       string                `toml:"export_format"`
+	PermissionRules    []PermissionRule      `toml:"permission_rules"`
+	PermissionLog      []PermissionDecision  `toml:"permission_log"`
+	VimMode            bool                  `toml:"vim_mode"`
+	Snippets           []Snippet             `toml:"snippets"`
+	TodosCollapsed     bool                  `toml:"todos_collapsed"`
 }
 
 func NewState() *State {
@@ -0,0 +1,239 @@
+package app
+
+import (
+	"slices"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// Tab is a session open in the workspace. The session of the active tab is
+// App.Session, with its messages in App.Messages, the others are kept up to
+// date from the events in the background.
+type Tab struct {
+	Session  *opencode.Session
+	Messages []Message
+	// Unread counts the replies finished since the tab was last active
+	Unread int
+}
+
+// Busy returns whether the session of the tab is working on a reply
+func (t *Tab) Busy() bool {
+	return isBusy(t.Messages)
+}
+
+// OpenTabMsg opens a session in a new tab, or a new session when Session is
+// nil
+type OpenTabMsg struct {
+	Session *opencode.Session
+}
+
+// ActiveTab returns the index of the active tab
+func (a *App) ActiveTab() int {
+	return a.activeTab
+}
+
+// Tab returns the background tab of a session, or nil if the session isn't
+// open in one
+func (a *App) Tab(sessionID string) *Tab {
+	for i, tab := range a.Tabs {
+		if i != a.activeTab && tab.Session.ID != "" && tab.Session.ID == sessionID {
+			return tab
+		}
+	}
+	return nil
+}
+
+// TabIndex returns the index of the tab holding the session, or -1
+func (a *App) TabIndex(sessionID string) int {
+	if a.Session.ID == sessionID {
+		return a.activeTab
+	}
+	for i, tab := range a.Tabs {
+		if i != a.activeTab && tab.Session.ID != "" && tab.Session.ID == sessionID {
+			return i
+		}
+	}
+	return -1
+}
+
+// OpenTab opens the session, with its messages, in a new tab after the
+// active one and switches to it
+func (a *App) OpenTab(session *opencode.Session, messages []Message) {
+	if session == nil {
+		session = &opencode.Session{}
+	}
+	a.stashTab()
+	a.activeTab++
+	a.Tabs = slices.Insert(a.Tabs, a.activeTab, &Tab{Session: session})
+	a.Session = session
+	a.Messages = messages
+}
+
+// SwitchTab makes the tab at index the active one
+func (a *App) SwitchTab(index int) {
+	if index < 0 || index >= len(a.Tabs) || index == a.activeTab {
+		return
+	}
+	a.stashTab()
+	a.activeTab = index
+	tab := a.Tabs[index]
+	a.Session = tab.Session
+	a.Messages = tab.Messages
+	tab.Unread = 0
+}
+
+// CycleTab switches to the next tab, or the previous one when going backward
+func (a *App) CycleTab(forward bool) {
+	if len(a.Tabs) < 2 {
+		return
+	}
+	step := 1
+	if !forward {
+		step = len(a.Tabs) - 1
+	}
+	a.SwitchTab((a.activeTab + step) % len(a.Tabs))
+}
+
+// CloseTab closes the tab at index. The last tab is never closed.
+func (a *App) CloseTab(index int) {
+	if len(a.Tabs) < 2 || index < 0 || index >= len(a.Tabs) {
+		return
+	}
+	if index == a.activeTab {
+		next := index + 1
+		if next == len(a.Tabs) {
+			next = index - 1
+		}
+		a.SwitchTab(next)
+	}
+	a.Tabs = slices.Delete(a.Tabs, index, index+1)
+	if index < a.activeTab {
+		a.activeTab--
+	}
+}
+
+// stashTab keeps the active session in its tab, before another one becomes
+// active
+func (a *App) stashTab() {
+	if len(a.Tabs) == 0 {
+		a.Tabs = []*Tab{{}}
+	}
+	tab := a.Tabs[a.activeTab]
+	tab.Session = a.Session
+	tab.Messages = a.Messages
+	tab.Unread = 0
+}
+
+func isBusy(messages []Message) bool {
+	if len(messages) == 0 {
+		return false
+	}
+	lastMessage := messages[len(messages)-1]
+	if casted, ok := lastMessage.Info.(opencode.AssistantMessage); ok {
+		return casted.Time.Completed == 0
+	}
+	return false
+}
+
+func messageIDOf(info opencode.MessageUnion) string {
+	switch casted := info.(type) {
+	case opencode.UserMessage:
+		return casted.ID
+	case opencode.AssistantMessage:
+		return casted.ID
+	}
+	return ""
+}
+
+func partIDOf(part opencode.PartUnion) string {
+	switch casted := part.(type) {
+	case opencode.TextPart:
+		return casted.ID
+	case opencode.ReasoningPart:
+		return casted.ID
+	case opencode.FilePart:
+		return casted.ID
+	case opencode.ToolPart:
+		return casted.ID
+	case opencode.StepStartPart:
+		return casted.ID
+	case opencode.StepFinishPart:
+		return casted.ID
+	}
+	return ""
+}
+
+// UpdateMessage replaces the info of a message, or inserts the message in
+// order of ID if it's new
+func UpdateMessage(messages []Message, info opencode.MessageUnion) []Message {
+	id := messageIDOf(info)
+	matchIndex := slices.IndexFunc(messages, func(m Message) bool {
+		return id != "" && messageIDOf(m.Info) == id
+	})
+	if matchIndex > -1 {
+		messages[matchIndex] = Message{
+			Info:  info,
+			Parts: messages[matchIndex].Parts,
+		}
+		return messages
+	}
+
+	// Find the correct insertion index by scanning backwards
+	// Most messages are added to the end, so start from the end
+	insertIndex := len(messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messageIDOf(messages[i].Info) < id {
+			insertIndex = i + 1
+			break
+		}
+	}
+	return slices.Insert(messages, insertIndex, Message{
+		Info:  info,
+		Parts: []opencode.PartUnion{},
+	})
+}
+
+// RemoveMessage removes a message
+func RemoveMessage(messages []Message, id string) []Message {
+	return slices.DeleteFunc(messages, func(m Message) bool {
+		return messageIDOf(m.Info) == id
+	})
+}
+
+// UpdatePart replaces a part of a message, or appends it if it's new
+func UpdatePart(messages []Message, messageID string, part opencode.PartUnion) []Message {
+	messageIndex := slices.IndexFunc(messages, func(m Message) bool {
+		return messageIDOf(m.Info) == messageID
+	})
+	if messageIndex == -1 {
+		return messages
+	}
+	message := messages[messageIndex]
+	id := partIDOf(part)
+	partIndex := slices.IndexFunc(message.Parts, func(p opencode.PartUnion) bool {
+		return id != "" && partIDOf(p) == id
+	})
+	if partIndex > -1 {
+		message.Parts[partIndex] = part
+	} else {
+		message.Parts = append(message.Parts, part)
+	}
+	messages[messageIndex] = message
+	return messages
+}
+
+// RemovePart removes a part of a message
+func RemovePart(messages []Message, messageID string, id string) []Message {
+	messageIndex := slices.IndexFunc(messages, func(m Message) bool {
+		return messageIDOf(m.Info) == messageID
+	})
+	if messageIndex == -1 {
+		return messages
+	}
+	message := messages[messageIndex]
+	message.Parts = slices.DeleteFunc(message.Parts, func(p opencode.PartUnion) bool {
+		return id != "" && partIDOf(p) == id
+	})
+	messages[messageIndex] = message
+	return messages
+}
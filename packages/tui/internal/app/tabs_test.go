@@ -0,0 +1,76 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+func TestTabs(t *testing.T) {
+	a := &App{Session: &opencode.Session{ID: "ses_1"}, Tabs: []*Tab{{}}}
+
+	a.OpenTab(&opencode.Session{ID: "ses_2"}, nil)
+	a.OpenTab(nil, nil)
+	if len(a.Tabs) != 3 || a.ActiveTab() != 2 || a.Session.ID != "" {
+		t.Fatalf("unexpected tabs after opening, active %d of %d", a.ActiveTab(), len(a.Tabs))
+	}
+	if a.TabIndex("ses_1") != 0 || a.TabIndex("ses_2") != 1 || a.TabIndex("ses_3") != -1 {
+		t.Error("unexpected tab indexes")
+	}
+	if a.Tab("ses_2") == nil || a.Tab("") != nil {
+		t.Error("expected only the background session to have a tab")
+	}
+
+	a.CycleTab(true)
+	if a.ActiveTab() != 0 || a.Session.ID != "ses_1" {
+		t.Fatalf("expected to wrap to the first tab, got %d", a.ActiveTab())
+	}
+	a.CycleTab(false)
+	if a.ActiveTab() != 2 {
+		t.Fatalf("expected to wrap to the last tab, got %d", a.ActiveTab())
+	}
+
+	a.Tabs[1].Unread = 2
+	a.CloseTab(2)
+	if len(a.Tabs) != 2 || a.ActiveTab() != 1 || a.Session.ID != "ses_2" || a.Tabs[1].Unread != 0 {
+		t.Fatalf("expected to switch to the previous tab, got %d of %d", a.ActiveTab(), len(a.Tabs))
+	}
+	a.CloseTab(0)
+	a.CloseTab(0)
+	if len(a.Tabs) != 1 || a.ActiveTab() != 0 || a.Session.ID != "ses_2" {
+		t.Fatalf("expected the last tab to stay open, got %d tabs", len(a.Tabs))
+	}
+}
+
+func TestUpdateMessages(t *testing.T) {
+	var messages []Message
+	messages = UpdateMessage(messages, opencode.UserMessage{ID: "msg_1"})
+	messages = UpdateMessage(messages, opencode.AssistantMessage{ID: "msg_3"})
+	messages = UpdateMessage(messages, opencode.UserMessage{ID: "msg_2"})
+	if len(messages) != 3 || messageIDOf(messages[1].Info) != "msg_2" || messageIDOf(messages[2].Info) != "msg_3" {
+		t.Fatalf("expected the messages in order, got %+v", messages)
+	}
+	if !isBusy(messages) {
+		t.Error("expected an incomplete reply to be busy")
+	}
+
+	messages = UpdatePart(messages, "msg_3", opencode.TextPart{ID: "prt_1", Text: "a"})
+	messages = UpdatePart(messages, "msg_3", opencode.TextPart{ID: "prt_1", Text: "b"})
+	messages = UpdatePart(messages, "msg_3", opencode.TextPart{ID: "prt_2", Text: "c"})
+	if parts := messages[2].Parts; len(parts) != 2 || parts[0].(opencode.TextPart).Text != "b" {
+		t.Fatalf("unexpected parts %+v", parts)
+	}
+	messages = RemovePart(messages, "msg_3", "prt_1")
+	if len(messages[2].Parts) != 1 {
+		t.Fatalf("expected a part to be removed, got %+v", messages[2].Parts)
+	}
+
+	messages = UpdateMessage(messages, opencode.AssistantMessage{ID: "msg_3", Time: opencode.AssistantMessageTime{Completed: 1}})
+	if isBusy(messages) || len(messages[2].Parts) != 1 {
+		t.Error("expected the completed reply to keep its parts")
+	}
+	messages = RemoveMessage(messages, "msg_2")
+	if len(messages) != 2 {
+		t.Errorf("expected a message to be removed, got %d", len(messages))
+	}
+}
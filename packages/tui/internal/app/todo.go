@@ -0,0 +1,86 @@
+package app
+
+import (
+	"github.com/sst/opencode-sdk-go"
+)
+
+// TodoStatuses are the statuses of the todos of the agent, in the order they
+// are counted
+var TodoStatuses = []string{"in_progress", "pending", "completed", "cancelled"}
+
+// Todo is an item of the todo list the agent keeps for a session
+type Todo struct {
+	ID       string
+	Content  string
+	Priority string
+	Status   string
+}
+
+// UpdateTodos replaces the todo list of a session with the one of a
+// todo.updated event
+func (a *App) UpdateTodos(event opencode.EventListResponseEventTodoUpdatedProperties) {
+	if a.todos == nil {
+		a.todos = map[string][]Todo{}
+	}
+	todos := make([]Todo, 0, len(event.Todos))
+	for _, todo := range event.Todos {
+		todos = append(todos, Todo{
+			ID:       todo.ID,
+			Content:  todo.Content,
+			Priority: todo.Priority,
+			Status:   todo.Status,
+		})
+	}
+	a.todos[event.SessionID] = todos
+}
+
+// Todos returns the todo list of the current session. Until an event updated
+// it, the list is the one the agent last wrote in the messages.
+func (a *App) Todos() []Todo {
+	if a.Session == nil || a.Session.ID == "" {
+		return nil
+	}
+	if todos, ok := a.todos[a.Session.ID]; ok {
+		return todos
+	}
+	return TodosFromMessages(a.Messages)
+}
+
+// TodosFromMessages returns the todo list of the last todowrite tool call of
+// the messages
+func TodosFromMessages(messages []Message) []Todo {
+	for i := len(messages) - 1; i >= 0; i-- {
+		parts := messages[i].Parts
+		for j := len(parts) - 1; j >= 0; j-- {
+			part, ok := parts[j].(opencode.ToolPart)
+			if !ok || part.Tool != "todowrite" || part.State.Status != opencode.ToolPartStateStatusCompleted {
+				continue
+			}
+			metadata, _ := part.State.Metadata.(map[string]any)
+			items, _ := metadata["todos"].([]any)
+			var todos []Todo
+			for _, item := range items {
+				todo, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+				id, _ := todo["id"].(string)
+				content, _ := todo["content"].(string)
+				priority, _ := todo["priority"].(string)
+				status, _ := todo["status"].(string)
+				todos = append(todos, Todo{ID: id, Content: content, Priority: priority, Status: status})
+			}
+			return todos
+		}
+	}
+	return nil
+}
+
+// CountTodos counts the todos by status
+func CountTodos(todos []Todo) map[string]int {
+	counts := map[string]int{}
+	for _, todo := range todos {
+		counts[todo.Status]++
+	}
+	return counts
+}
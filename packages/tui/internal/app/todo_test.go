@@ -0,0 +1,46 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+func TestTodos(t *testing.T) {
+	written := opencode.ToolPart{
+		Tool: "todowrite",
+		State: opencode.ToolPartState{
+			Status: opencode.ToolPartStateStatusCompleted,
+			Metadata: map[string]any{"todos": []any{
+				map[string]any{"id": "1", "content": "read", "status": "completed", "priority": "high"},
+				map[string]any{"id": "2", "content": "write", "status": "in_progress", "priority": "low"},
+			}},
+		},
+	}
+	a := &App{
+		Session:  &opencode.Session{ID: "ses_1"},
+		Messages: []Message{{Parts: []opencode.PartUnion{written}}, {Parts: []opencode.PartUnion{opencode.TextPart{}}}},
+	}
+
+	todos := a.Todos()
+	if len(todos) != 2 || todos[1].Content != "write" || todos[1].Status != "in_progress" {
+		t.Fatalf("expected the todos of the messages, got %v", todos)
+	}
+
+	var event opencode.EventListResponseEventTodoUpdatedProperties
+	data := `{"sessionID":"ses_1","todos":[{"id":"1","content":"read","status":"completed","priority":"high"}]}`
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatal(err)
+	}
+	a.UpdateTodos(event)
+	if todos := a.Todos(); len(todos) != 1 || CountTodos(todos)["completed"] != 1 {
+		t.Fatalf("expected the todos of the event, got %v", todos)
+	}
+
+	a.Session = &opencode.Session{ID: "ses_2"}
+	a.Messages = nil
+	if todos := a.Todos(); len(todos) != 0 {
+		t.Fatalf("expected no todos, got %v", todos)
+	}
+}
@@ -121,19 +121,33 @@ const (
 	AgentCycleCommand               CommandName = "agent_cycle"
 	AgentCycleReverseCommand        CommandName = "agent_cycle_reverse"
 	AppHelpCommand                  CommandName = "app_help"
+	AppUsageCommand                 CommandName = "app_usage"
 	SwitchAgentCommand              CommandName = "switch_agent"
 	SwitchAgentReverseCommand       CommandName = "switch_agent_reverse"
 	EditorOpenCommand               CommandName = "editor_open"
 	SessionNewCommand               CommandName = "session_new"
 	SessionListCommand              CommandName = "session_list"
+	SessionSearchCommand            CommandName = "session_search"
+	TabNewCommand                   CommandName = "tab_new"
+	TabCloseCommand                 CommandName = "tab_close"
+	TabNextCommand                  CommandName = "tab_next"
+	TabPreviousCommand              CommandName = "tab_previous"
 	SessionTimelineCommand          CommandName = "session_timeline"
 	SessionShareCommand             CommandName = "session_share"
 	SessionUnshareCommand           CommandName = "session_unshare"
 	SessionInterruptCommand         CommandName = "session_interrupt"
 	SessionCompactCommand           CommandName = "session_compact"
 	SessionExportCommand            CommandName = "session_export"
+	SessionImportCommand            CommandName = "session_import"
 	ToolDetailsCommand              CommandName = "tool_details"
 	ThinkingBlocksCommand           CommandName = "thinking_blocks"
+	VimModeCommand                  CommandName = "vim_mode"
+	SnippetListCommand              CommandName = "snippet_list"
+	DiagnosticsCommand              CommandName = "diagnostics_toggle"
+	TodosCommand                    CommandName = "todos_toggle"
+	ChangesCommand                  CommandName = "changes_list"
+	ReviewCommand                   CommandName = "changes_review"
+	PermissionListCommand           CommandName = "permission_list"
 	ModelListCommand                CommandName = "model_list"
 	AgentListCommand                CommandName = "agent_list"
 	ModelCycleRecentCommand         CommandName = "model_cycle_recent"
@@ -159,6 +173,7 @@ const (
 	MessagesCopyCommand             CommandName = "messages_copy"
 	MessagesUndoCommand             CommandName = "messages_undo"
 	MessagesRedoCommand             CommandName = "messages_redo"
+	MessagesSearchCommand           CommandName = "messages_search"
 	AppExitCommand                  CommandName = "app_exit"
 )
 
@@ -198,6 +213,12 @@ func LoadFromConfig(config *opencode.Config, customCommands []opencode.Command)
 			Keybindings: parseBindings("<leader>h"),
 			Trigger:     []string{"help"},
 		},
+		{
+			Name:        AppUsageCommand,
+			Description: "usage and cost",
+			Keybindings: parseBindings("none"),
+			Trigger:     []string{"usage", "cost"},
+		},
 		{
 			Name:        EditorOpenCommand,
 			Description: "open editor",
@@ -210,6 +231,12 @@ func LoadFromConfig(config *opencode.Config, customCommands []opencode.Command)
 			Keybindings: parseBindings("<leader>x"),
 			Trigger:     []string{"export"},
 		},
+		{
+			Name:        SessionImportCommand,
+			Description: "import session",
+			Keybindings: parseBindings("none"),
+			Trigger:     []string{"import"},
+		},
 		{
 			Name:        SessionNewCommand,
 			Description: "new session",
@@ -222,6 +249,33 @@ func LoadFromConfig(config *opencode.Config, customCommands []opencode.Command)
 			Keybindings: parseBindings("<leader>l"),
 			Trigger:     []string{"sessions", "resume", "continue"},
 		},
+		{
+			Name:        SessionSearchCommand,
+			Description: "search sessions",
+			Keybindings: parseBindings("<leader>f"),
+			Trigger:     []string{"find"},
+		},
+		{
+			Name:        TabNewCommand,
+			Description: "new tab",
+			Keybindings: parseBindings("<leader>o"),
+			Trigger:     []string{"tab"},
+		},
+		{
+			Name:        TabCloseCommand,
+			Description: "close tab",
+			Keybindings: parseBindings("<leader>w"),
+		},
+		{
+			Name:        TabNextCommand,
+			Description: "next tab",
+			Keybindings: parseBindings("<leader>]"),
+		},
+		{
+			Name:        TabPreviousCommand,
+			Description: "previous tab",
+			Keybindings: parseBindings("<leader>["),
+		},
 		{
 			Name:        SessionTimelineCommand,
 			Description: "show session timeline",
@@ -272,6 +326,48 @@ func LoadFromConfig(config *opencode.Config, customCommands []opencode.Command)
 			Keybindings: parseBindings("<leader>b"),
 			Trigger:     []string{"thinking"},
 		},
+		{
+			Name:        VimModeCommand,
+			Description: "toggle vim mode",
+			Keybindings: parseBindings("none"),
+			Trigger:     []string{"vim"},
+		},
+		{
+			Name:        SnippetListCommand,
+			Description: "snippets",
+			Keybindings: parseBindings("none"),
+			Trigger:     []string{"snippets"},
+		},
+		{
+			Name:        DiagnosticsCommand,
+			Description: "toggle diagnostics",
+			Keybindings: parseBindings("none"),
+			Trigger:     []string{"diagnostics"},
+		},
+		{
+			Name:        TodosCommand,
+			Description: "toggle todos",
+			Keybindings: parseBindings("none"),
+			Trigger:     []string{"todos"},
+		},
+		{
+			Name:        ChangesCommand,
+			Description: "changed files",
+			Keybindings: parseBindings("none"),
+			Trigger:     []string{"changes"},
+		},
+		{
+			Name:        ReviewCommand,
+			Description: "review changes",
+			Keybindings: parseBindings("none"),
+			Trigger:     []string{"review"},
+		},
+		{
+			Name:        PermissionListCommand,
+			Description: "review permissions",
+			Keybindings: parseBindings("<leader>p"),
+			Trigger:     []string{"permissions"},
+		},
 		{
 			Name:        ModelListCommand,
 			Description: "list models",
@@ -385,6 +481,12 @@ func LoadFromConfig(config *opencode.Config, customCommands []opencode.Command)
 			Keybindings: parseBindings("<leader>r"),
 			Trigger:     []string{"redo"},
 		},
+		{
+			Name:        MessagesSearchCommand,
+			Description: "search messages",
+			Keybindings: parseBindings("<leader>/"),
+			Trigger:     []string{"search"},
+		},
 		{
 			Name:        AppExitCommand,
 			Description: "exit the app",
@@ -0,0 +1,63 @@
+package completions
+
+import (
+	"strings"
+
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+)
+
+type snippetsContextGroup struct {
+	app *app.App
+}
+
+func (cg *snippetsContextGroup) GetId() string {
+	return "snippets"
+}
+
+func (cg *snippetsContextGroup) GetEmptyMessage() string {
+	return "no matching snippets"
+}
+
+func (cg *snippetsContextGroup) GetChildEntries(
+	query string,
+) ([]CompletionSuggestion, error) {
+	items := make([]CompletionSuggestion, 0)
+
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	for _, snippet := range cg.app.State.Snippets {
+		if query != "" &&
+			!strings.Contains(strings.ToLower(snippet.Name), query) &&
+			!strings.Contains(strings.ToLower(snippet.Description), query) {
+			continue
+		}
+
+		description := snippet.Description
+		if description == "" {
+			description, _, _ = strings.Cut(strings.TrimSpace(snippet.Body), "\n")
+		}
+		displayFunc := func(s styles.Style) string {
+			t := theme.CurrentTheme()
+			muted := s.Foreground(t.TextMuted()).Render
+			return s.Render("#"+snippet.Name) + muted(" "+description)
+		}
+
+		item := CompletionSuggestion{
+			Display:    displayFunc,
+			Value:      snippet.Name,
+			ProviderID: cg.GetId(),
+			RawData:    snippet,
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func NewSnippetsContextGroup(app *app.App) CompletionProvider {
+	return &snippetsContextGroup{
+		app: app,
+	}
+}
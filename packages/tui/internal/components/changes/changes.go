@@ -0,0 +1,227 @@
+package changes
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+// refreshDelay is how long file events are gathered before the changes are
+// fetched again, the agent often editing several files in a row
+const refreshDelay = 300 * time.Millisecond
+
+// OpenFileDiffMsg is sent to show the diff of a changed file
+type OpenFileDiffMsg struct {
+	Changes []app.FileChange
+	Index   int
+}
+
+// refreshMsg fetches the changes again, unless file events came since it was
+// scheduled
+type refreshMsg struct {
+	seq int
+}
+
+// ChangesComponent is the panel of the sidebar listing the files changed in
+// the current session, with the number of added and removed lines
+type ChangesComponent interface {
+	tea.Model
+	tea.ViewModel
+	// SetSize sets the width of the panel and the height it may take
+	SetSize(width, height int)
+	// Visible reports whether files changed in the current session
+	Visible() bool
+	Focused() bool
+	Focus()
+	Blur()
+}
+
+type changesComponent struct {
+	app     *app.App
+	list    list.List[app.FileChange]
+	width   int
+	height  int
+	focused bool
+	// seq numbers the scheduled refreshes, only the last one fetches
+	seq int
+}
+
+func (c *changesComponent) Init() tea.Cmd {
+	return nil
+}
+
+func (c *changesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case opencode.EventListResponseEventFileEdited, opencode.EventListResponseEventFileWatcherUpdated:
+		return c, c.scheduleRefresh()
+	case app.SessionLoadedMsg, app.MessageRevertedMsg, app.SessionUnrevertedMsg:
+		return c, c.scheduleRefresh()
+	case refreshMsg:
+		if msg.seq != c.seq || c.app.Session.ID == "" {
+			return c, nil
+		}
+		return c, c.app.FetchFileChanges(c.app.Session.ID)
+	case app.FileChangesMsg:
+		c.app.UpdateFileChanges(msg)
+		c.refresh()
+	case tea.KeyPressMsg:
+		if !c.focused {
+			return c, nil
+		}
+		switch msg.String() {
+		case "esc", "tab":
+			c.focused = false
+			return c, nil
+		case "enter":
+			if _, idx := c.list.GetSelectedItem(); idx >= 0 {
+				return c, util.CmdHandler(OpenFileDiffMsg{Changes: c.list.GetItems(), Index: idx})
+			}
+			return c, nil
+		}
+		updated, cmd := c.list.Update(msg)
+		c.list = updated.(list.List[app.FileChange])
+		return c, cmd
+	}
+	return c, nil
+}
+
+// scheduleRefresh fetches the changes after a delay, restarted by every call
+func (c *changesComponent) scheduleRefresh() tea.Cmd {
+	c.seq++
+	seq := c.seq
+	return tea.Tick(refreshDelay, func(time.Time) tea.Msg {
+		return refreshMsg{seq: seq}
+	})
+}
+
+// refresh lists the changed files, keeping the selection on the same file
+func (c *changesComponent) refresh() {
+	selected := ""
+	if item, idx := c.list.GetSelectedItem(); idx >= 0 {
+		selected = item.Path
+	}
+	changes := c.app.FileChanges()
+	c.list.SetItems(changes)
+	for i, change := range changes {
+		if change.Path == selected {
+			c.list.SetSelectedIndex(i)
+			break
+		}
+	}
+	if len(changes) == 0 {
+		c.focused = false
+	}
+}
+
+func (c *changesComponent) renderItem(change app.FileChange, selected bool, width int, _ styles.Style) string {
+	t := theme.CurrentTheme()
+	base := styles.NewStyle().Background(t.BackgroundPanel())
+	pathStyle := base.Foreground(t.Text())
+	addedStyle := base.Foreground(t.Success())
+	removedStyle := base.Foreground(t.Error())
+	if selected && c.focused {
+		base = base.Background(t.Primary())
+		pathStyle = base.Foreground(t.BackgroundElement())
+		addedStyle = pathStyle
+		removedStyle = pathStyle
+	}
+
+	stats := addedStyle.Render(fmt.Sprintf(" +%d", change.Added)) +
+		removedStyle.Render(fmt.Sprintf(" -%d", change.Removed))
+	path := truncateLeft(change.Path, max(1, width-lipgloss.Width(stats)))
+	path = pathStyle.Width(width - lipgloss.Width(stats)).Render(path)
+	return base.Width(width).Render(path + stats)
+}
+
+// truncateLeft shortens the path from its start, to keep the file name
+func truncateLeft(path string, width int) string {
+	runes := []rune(path)
+	if len(runes) <= width {
+		return path
+	}
+	return "…" + string(runes[len(runes)-width+1:])
+}
+
+func (c *changesComponent) SetSize(width, height int) {
+	c.width = width
+	if height != c.height {
+		c.height = height
+		c.list = c.newList()
+		c.refresh()
+	}
+}
+
+func (c *changesComponent) View() string {
+	changes := c.app.FileChanges()
+	if len(changes) == 0 || c.height < 2 {
+		return ""
+	}
+	t := theme.CurrentTheme()
+	base := styles.NewStyle().Background(t.BackgroundPanel())
+	width := c.width - 2
+
+	added, removed := 0, 0
+	for _, change := range changes {
+		added += change.Added
+		removed += change.Removed
+	}
+	title := base.Foreground(t.Text()).Bold(true).Render("Changes ") +
+		base.Foreground(t.TextMuted()).Render(fmt.Sprintf("%d ", len(changes))) +
+		base.Foreground(t.Success()).Render(fmt.Sprintf("+%d ", added)) +
+		base.Foreground(t.Error()).Render(fmt.Sprintf("-%d", removed))
+
+	c.list.SetMaxWidth(width)
+	content := title + "\n" + c.list.View()
+	if c.focused {
+		content += "\n" + base.Foreground(t.Text()).Bold(true).Render("enter") +
+			base.Foreground(t.TextMuted()).Render(" diff  ") +
+			base.Foreground(t.Text()).Bold(true).Render("esc") +
+			base.Foreground(t.TextMuted()).Render(" back")
+	}
+	return base.
+		Width(c.width).
+		Padding(0, 1).
+		Render(content)
+}
+
+func (c *changesComponent) Visible() bool {
+	return len(c.app.FileChanges()) > 0
+}
+
+func (c *changesComponent) Focused() bool {
+	return c.focused
+}
+
+func (c *changesComponent) Focus() {
+	c.focused = len(c.app.FileChanges()) > 0
+}
+
+func (c *changesComponent) Blur() {
+	c.focused = false
+}
+
+// NewChangesComponent creates the changed files panel of the sidebar
+func NewChangesComponent(app *app.App) ChangesComponent {
+	c := &changesComponent{app: app, height: 2}
+	c.list = c.newList()
+	return c
+}
+
+// newList creates the list of changed files filling the panel below its
+// title, and the key hints when focused
+func (c *changesComponent) newList() list.List[app.FileChange] {
+	return list.NewListComponent(
+		list.WithMaxVisibleHeight[app.FileChange](max(1, c.height-2)),
+		list.WithAlphaNumericKeys[app.FileChange](true),
+		list.WithRenderFunc(c.renderItem),
+		list.WithSelectableFunc(func(app.FileChange) bool { return true }),
+	)
+}
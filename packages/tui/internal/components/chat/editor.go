@@ -48,8 +48,20 @@ type EditorComponent interface {
 	SetInterruptKeyInDebounce(inDebounce bool)
 	SetExitKeyInDebounce(inDebounce bool)
 	RestoreFromHistory(index int)
+	// Inserting reports whether typed text is inserted, false in the normal
+	// and visual modes of the Vim-style editing
+	Inserting() bool
+	// VimPending reports whether esc leaves the insert or visual mode, or
+	// cancels a pending command, rather than interrupting the session
+	VimPending() bool
+	// InTabStops reports whether tab moves to the next tab-stop of an inserted
+	// snippet
+	InTabStops() bool
+	NextTabStop()
 }
 
+type ToggleVimModeMsg struct{}
+
 type editorComponent struct {
 	app                    *app.App
 	width                  int
@@ -64,7 +76,7 @@ type editorComponent struct {
 }
 
 func (m *editorComponent) Init() tea.Cmd {
-	return tea.Batch(m.textarea.Focus(), m.spinner.Tick, tea.EnableReportFocus)
+	return tea.Batch(m.textarea.Focus(), m.spinner.Tick)
 }
 
 func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -78,6 +90,10 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case spinner.TickMsg:
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
+	case ToggleVimModeMsg:
+		m.app.State.VimMode = !m.app.State.VimMode
+		m.textarea.SetVim(m.app.State.VimMode)
+		return m, m.app.SaveState()
 	case tea.KeyPressMsg:
 		// Handle up/down arrows and ctrl+p/ctrl+n for history navigation
 		switch msg.String() {
@@ -221,6 +237,13 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.textarea = updateTextareaStyles(m.textarea)
 		m.spinner = createSpinner()
 		return m, tea.Batch(m.textarea.Focus(), m.spinner.Tick)
+	case dialog.SnippetSelectedMsg:
+		if msg.Raw {
+			m.SetValue(msg.Snippet.Body)
+			return m, nil
+		}
+		m.insertSnippet(msg.Snippet)
+		return m, nil
 	case dialog.CompletionSelectedMsg:
 		switch msg.Item.ProviderID {
 		case "commands":
@@ -322,6 +345,15 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.textarea.InsertString(" ")
 			return m, nil
 
+		case "snippets":
+			hashIndex := m.textarea.LastRuneIndex('#')
+			if hashIndex != -1 {
+				cursorCol := m.textarea.CursorColumn()
+				m.textarea.ReplaceRange(hashIndex, cursorCol, "")
+			}
+			m.insertSnippet(msg.Item.RawData.(app.Snippet))
+			return m, nil
+
 		default:
 			slog.Debug("Unknown provider", "provider", msg.Item.ProviderID)
 			return m, nil
@@ -414,16 +446,35 @@ func (m *editorComponent) Content() string {
 		model = muted(m.app.Provider.Name) + base(" "+m.app.Model.Name)
 	}
 
-	space := width - 2 - lipgloss.Width(model) - lipgloss.Width(hint)
+	vim := m.vimModeView()
+	space := width - 2 - lipgloss.Width(vim) - lipgloss.Width(model) - lipgloss.Width(hint)
 	spacer := styles.NewStyle().Background(t.Background()).Width(space).Render("")
 
-	info := hint + spacer + model
+	info := vim + hint + spacer + model
 	info = styles.NewStyle().Background(t.Background()).Padding(0, 1).Render(info)
 
 	content := strings.Join([]string{"", textarea, info}, "\n")
 	return content
 }
 
+// vimModeView renders the mode of the Vim-style editing, when enabled
+func (m *editorComponent) vimModeView() string {
+	if !m.textarea.Vim() {
+		return ""
+	}
+	t := theme.CurrentTheme()
+	mode := m.textarea.VimMode()
+	style := styles.NewStyle().Foreground(t.TextMuted()).Background(t.Background())
+	switch mode {
+	case textarea.VimNormal:
+		style = styles.NewStyle().Foreground(t.Background()).Background(t.Primary()).Bold(true)
+	case textarea.VimVisual, textarea.VimVisualLine:
+		style = styles.NewStyle().Foreground(t.Background()).Background(t.Accent()).Bold(true)
+	}
+	spacer := styles.NewStyle().Background(t.Background()).Render("  ")
+	return style.Render(" "+mode.String()+" ") + spacer
+}
+
 func (m *editorComponent) Cursor() *tea.Cursor {
 	return m.textarea.Cursor()
 }
@@ -447,6 +498,51 @@ func (m *editorComponent) View() string {
 	return m.Content()
 }
 
+func (m *editorComponent) Inserting() bool {
+	return m.textarea.VimMode() == textarea.VimInsert
+}
+
+func (m *editorComponent) VimPending() bool {
+	return m.textarea.VimPending()
+}
+
+func (m *editorComponent) InTabStops() bool {
+	return m.textarea.InTabStops()
+}
+
+func (m *editorComponent) NextTabStop() {
+	m.textarea.NextTabStop()
+}
+
+// insertSnippet inserts the snippet at the cursor and moves to its first
+// tab-stop. The selection is the text selected in visual mode, which the
+// snippet replaces, or else the text of the clipboard, and the file the last
+// one attached to the prompt.
+func (m *editorComponent) insertSnippet(snippet app.Snippet) {
+	values := map[string]string{}
+	if selection, ok := m.textarea.CutVimSelection(); ok {
+		values["selection"] = selection
+	} else if strings.Contains(snippet.Body, "selection") {
+		values["selection"] = strings.TrimSpace(string(clipboard.Read(clipboard.FmtText)))
+	}
+	if strings.Contains(snippet.Body, "branch") {
+		values["branch"] = app.CurrentBranch(util.CwdPath)
+	}
+	for _, att := range m.textarea.GetAttachments() {
+		if att.Type == "file" {
+			values["file"] = att.Filename
+		}
+	}
+
+	text, snippetStops := app.ExpandSnippet(snippet.Body, values)
+	stops := make([]textarea.TabStop, len(snippetStops))
+	for i, stop := range snippetStops {
+		stops[i] = textarea.TabStop(stop)
+	}
+	m.textarea.SetVimMode(textarea.VimInsert)
+	m.textarea.InsertSnippet(text, stops)
+}
+
 func (m *editorComponent) Focused() bool {
 	return m.textarea.Focused()
 }
@@ -550,6 +646,7 @@ func (m *editorComponent) SubmitBash() (tea.Model, tea.Cmd) {
 
 func (m *editorComponent) Clear() (tea.Model, tea.Cmd) {
 	m.textarea.Reset()
+	m.textarea.SetVimMode(textarea.VimInsert)
 	m.historyIndex = -1
 	m.currentText = ""
 	m.pasteCounter = 0
@@ -741,6 +838,10 @@ func updateTextareaStyles(ta textarea.Model) textarea.Model {
 		Foreground(t.Text()).
 		Background(t.Secondary()).
 		Lipgloss()
+	ta.Styles.Selection = styles.NewStyle().
+		Foreground(t.Background()).
+		Background(t.Primary()).
+		Lipgloss()
 	ta.Styles.Cursor.Color = t.Primary()
 	return ta
 }
@@ -767,6 +868,7 @@ func NewEditorComponent(app *app.App) EditorComponent {
 	ta.ShowLineNumbers = false
 	ta.CharLimit = -1
 	ta.VirtualCursor = false
+	ta.SetVim(app.State.VimMode)
 	ta = updateTextareaStyles(ta)
 
 	m := &editorComponent{
@@ -2,6 +2,7 @@ package chat
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"slices"
@@ -22,6 +23,7 @@ import (
 	"github.com/sst/opencode/internal/layout"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/usage"
 	"github.com/sst/opencode/internal/util"
 	"github.com/sst/opencode/internal/viewport"
 )
@@ -41,6 +43,8 @@ type MessagesComponent interface {
 	UndoLastMessage() (tea.Model, tea.Cmd)
 	RedoLastMessage() (tea.Model, tea.Cmd)
 	ScrollToMessage(messageID string) (tea.Model, tea.Cmd)
+	Search() (tea.Model, tea.Cmd)
+	Searching() bool
 }
 
 type messagesComponent struct {
@@ -61,6 +65,8 @@ type messagesComponent struct {
 	selection          *selection
 	messagePositions   map[string]int // map message ID to line position
 	animating          bool
+	search             *search
+	pendingScroll      string // message to scroll to once it's rendered
 }
 
 type selection struct {
@@ -110,6 +116,10 @@ func (m *messagesComponent) Init() tea.Cmd {
 func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		if m.search != nil {
+			return m.updateSearch(msg)
+		}
 	case shimmerTickMsg:
 		if !m.app.HasAnimatingWork() {
 			m.animating = false
@@ -220,6 +230,7 @@ func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if currentParent != targetParent {
 			m.cache.Clear()
 		}
+		m.pendingScroll = ""
 
 		m.viewport.GotoBottom()
 	case app.MessageRevertedMsg:
@@ -275,12 +286,23 @@ func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// if the user was at bottom, keep following; otherwise restore the previous offset.
 		wasAtBottom := m.viewport.AtBottom()
 		prevYOffset := m.viewport.YOffset
+		highlight := m.viewport.HighlightIndex()
 		m.viewport = msg.viewport
+		if m.search != nil {
+			m.viewport.SetHeight(m.viewport.Height() - 1)
+		}
 		if wasAtBottom {
 			m.viewport.GotoBottom()
 		} else {
 			m.viewport.YOffset = prevYOffset
 		}
+		if m.search != nil {
+			m.restoreSearch(highlight)
+		}
+		if position, ok := m.messagePositions[m.pendingScroll]; ok {
+			m.viewport.SetYOffset(position)
+			m.pendingScroll = ""
+		}
 
 		m.header = msg.header
 		if m.dirty {
@@ -652,16 +674,15 @@ func (m *messagesComponent) renderView() tea.Cmd {
 					}
 				}
 
-				switch err := casted.Error.AsUnion().(type) {
-				case nil:
-				case opencode.AssistantMessageErrorMessageOutputLengthError:
+				var err *opencode.SessionError
+				switch {
+				case !errors.As(casted.Error.AsError(), &err):
+				case errors.Is(err, opencode.ErrOutputLength):
 					error = "Message output length exceeded"
-				case opencode.ProviderAuthError:
-					error = err.Data.Message
-				case opencode.MessageAbortedError:
+				case errors.Is(err, opencode.ErrAborted):
 					error = "Request was aborted"
-				case opencode.UnknownError:
-					error = err.Data.Message
+				default:
+					error = err.Message
 				}
 
 				if !hasContent && error == "" && !reverted && casted.Time.Completed == 0 {
@@ -998,24 +1019,7 @@ func formatTokensAndCost(
 	cost float64,
 	isSubscriptionModel bool,
 ) string {
-	// Format tokens in human-readable format (e.g., 110K, 1.2M)
-	var formattedTokens string
-	switch {
-	case tokens >= 1_000_000:
-		formattedTokens = fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
-	case tokens >= 1_000:
-		formattedTokens = fmt.Sprintf("%.1fK", float64(tokens)/1_000)
-	default:
-		formattedTokens = fmt.Sprintf("%d", int(tokens))
-	}
-
-	// Remove .0 suffix if present
-	if strings.HasSuffix(formattedTokens, ".0K") {
-		formattedTokens = strings.Replace(formattedTokens, ".0K", "K", 1)
-	}
-	if strings.HasSuffix(formattedTokens, ".0M") {
-		formattedTokens = strings.Replace(formattedTokens, ".0M", "M", 1)
-	}
+	formattedTokens := usage.FormatTokens(tokens)
 
 	percentage := 0.0
 	if contextWindow > 0 {
@@ -1055,6 +1059,9 @@ func (m *messagesComponent) View() string {
 	}
 
 	viewport := m.viewport.View()
+	if m.search != nil {
+		viewport += "\n" + m.renderSearch()
+	}
 	return styles.NewStyle().
 		Background(bgColor).
 		Render(m.header + "\n" + viewport)
@@ -1283,6 +1290,9 @@ func (m *messagesComponent) ScrollToMessage(messageID string) (tea.Model, tea.Cm
 	if position, exists := m.messagePositions[messageID]; exists {
 		m.viewport.SetYOffset(position)
 		m.tail = false // Stop auto-scrolling to bottom when manually navigating
+	} else {
+		// The message may not be rendered yet, as when a session was just opened
+		m.pendingScroll = messageID
 	}
 	return m, nil
 }
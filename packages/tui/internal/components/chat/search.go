@@ -0,0 +1,189 @@
+package chat
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+)
+
+// search is the state of the search bar shown below the messages
+type search struct {
+	input textinput.Model
+	// origin is the offset the search started from, matches are looked up
+	// from there as the query is typed
+	origin int
+}
+
+// findMatches returns the byte ranges of the query in the content. The search
+// is case insensitive unless the query has an upper case letter.
+func findMatches(content, query string) [][]int {
+	if query == "" {
+		return nil
+	}
+	pattern := regexp.QuoteMeta(query)
+	if !strings.ContainsFunc(query, unicode.IsUpper) {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.MustCompile(pattern).FindAllStringIndex(content, -1)
+}
+
+func (m *messagesComponent) Searching() bool {
+	return m.search != nil
+}
+
+// Search opens the search bar, taking a line from the viewport
+func (m *messagesComponent) Search() (tea.Model, tea.Cmd) {
+	if m.search != nil {
+		return m, nil
+	}
+	t := theme.CurrentTheme()
+	bgColor := t.BackgroundPanel()
+
+	input := textinput.New()
+	input.Prompt = "/ "
+	input.Placeholder = "Search messages"
+	input.Styles.Focused.Prompt = styles.NewStyle().
+		Foreground(t.Primary()).
+		Background(bgColor).
+		Lipgloss()
+	input.Styles.Focused.Text = styles.NewStyle().
+		Foreground(t.Text()).
+		Background(bgColor).
+		Lipgloss()
+	input.Styles.Focused.Placeholder = styles.NewStyle().
+		Foreground(t.TextMuted()).
+		Background(bgColor).
+		Lipgloss()
+
+	m.search = &search{input: input, origin: m.viewport.YOffset}
+	m.viewport.SetHeight(m.viewport.Height() - 1)
+	m.viewport.HighlightStyle = styles.NewStyle().
+		Foreground(t.BackgroundPanel()).
+		Background(t.Warning()).
+		Lipgloss()
+	m.viewport.SelectedHighlightStyle = styles.NewStyle().
+		Foreground(t.BackgroundPanel()).
+		Background(t.Accent()).
+		Bold(true).
+		Lipgloss()
+	return m, m.search.input.Focus()
+}
+
+func (m *messagesComponent) closeSearch() {
+	m.search = nil
+	m.viewport.ClearHighlights()
+	m.viewport.SetHeight(m.viewport.Height() + 1)
+}
+
+func (m *messagesComponent) updateSearch(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.closeSearch()
+		return m, nil
+	case "enter", "down", "ctrl+n":
+		offset := m.viewport.YOffset
+		m.viewport.HighlightNext()
+		m.revealHighlight(offset)
+		return m, nil
+	case "shift+enter", "up", "ctrl+p":
+		offset := m.viewport.YOffset
+		m.viewport.HighlightPrevious()
+		m.revealHighlight(offset)
+		return m, nil
+	}
+
+	query := m.search.input.Value()
+	var cmd tea.Cmd
+	m.search.input, cmd = m.search.input.Update(msg)
+	if m.search.input.Value() != query {
+		m.applySearch()
+	}
+	return m, cmd
+}
+
+// applySearch highlights the matches of the query, selecting the first one
+// after where the search started.
+func (m *messagesComponent) applySearch() {
+	m.viewport.SetYOffset(m.search.origin)
+	matches := findMatches(ansi.Strip(m.viewport.GetContent()), m.search.input.Value())
+	if len(matches) == 0 {
+		m.viewport.ClearHighlights()
+		return
+	}
+	m.viewport.SetHighlights(matches)
+	if m.viewport.HighlightIndex() < 0 {
+		// every match is above, wrap around
+		m.viewport.HighlightNext()
+	}
+	m.revealHighlight(m.search.origin)
+}
+
+// restoreSearch highlights the matches again after the messages are rendered,
+// keeping the selected one.
+func (m *messagesComponent) restoreSearch(index int) {
+	matches := findMatches(ansi.Strip(m.viewport.GetContent()), m.search.input.Value())
+	m.viewport.RestoreHighlights(matches, index)
+}
+
+// revealHighlight scrolls to the start of the message holding the selected
+// match, when the viewport had to scroll to show it and both fit on screen,
+// so that the match is read in context.
+func (m *messagesComponent) revealHighlight(offset int) {
+	line := m.viewport.HighlightLine()
+	if line < 0 || m.viewport.YOffset == offset {
+		return
+	}
+	start := -1
+	for _, position := range m.messagePositions {
+		if position <= line && position > start {
+			start = position
+		}
+	}
+	if start >= 0 && line-start < m.viewport.Height()-1 {
+		m.viewport.SetYOffset(start)
+	}
+	m.tail = m.viewport.AtBottom()
+}
+
+func (m *messagesComponent) renderSearch() string {
+	t := theme.CurrentTheme()
+	bgColor := t.BackgroundPanel()
+
+	var counter string
+	switch count := m.viewport.HighlightCount(); {
+	case m.search.input.Value() == "":
+		counter = ""
+	case count == 0:
+		counter = styles.NewStyle().Foreground(t.Error()).Background(bgColor).Render("no matches")
+	case m.viewport.HighlightIndex() < 0:
+		counter = styles.NewStyle().
+			Foreground(t.TextMuted()).
+			Background(bgColor).
+			Render(fmt.Sprintf("%d matches", count))
+	default:
+		counter = styles.NewStyle().
+			Foreground(t.TextMuted()).
+			Background(bgColor).
+			Render(fmt.Sprintf("%d/%d", m.viewport.HighlightIndex()+1, count))
+	}
+
+	width := max(0, m.width-2)
+	m.search.input.SetWidth(max(1, width-lipgloss.Width(counter)-lipgloss.Width(m.search.input.Prompt)-2))
+	input := m.search.input.View()
+	spacer := styles.NewStyle().
+		Background(bgColor).
+		Width(max(0, width-lipgloss.Width(input)-lipgloss.Width(counter))).
+		Render("")
+	return styles.NewStyle().
+		Background(bgColor).
+		Padding(0, 1).
+		Render(input + spacer + counter)
+}
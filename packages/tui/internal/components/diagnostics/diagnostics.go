@@ -0,0 +1,325 @@
+package diagnostics
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/lipgloss/v2/compat"
+	"github.com/muesli/reflow/truncate"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+const (
+	minHeight = 5
+	maxHeight = 12
+)
+
+// InsertDiagnosticsMsg is sent when diagnostics should be added to the
+// prompt
+type InsertDiagnosticsMsg struct {
+	Text string
+}
+
+// DiagnosticsComponent is the panel below the messages listing the current
+// diagnostics of the language servers, grouped by severity
+type DiagnosticsComponent interface {
+	tea.Model
+	tea.ViewModel
+	Visible() bool
+	Focused() bool
+	// Toggle shows and focuses the panel, focuses it when it's shown but not
+	// focused, and hides it otherwise
+	Toggle()
+	Blur()
+	// Height is the number of lines the panel takes, zero when hidden
+	Height() int
+}
+
+var headers = map[app.DiagnosticSeverity]string{
+	app.SeverityError:       "Errors",
+	app.SeverityWarning:     "Warnings",
+	app.SeverityInformation: "Information",
+	app.SeverityHint:        "Hints",
+}
+
+// header is the non-selectable title of a severity
+type header struct {
+	severity app.DiagnosticSeverity
+	count    int
+}
+
+type diagnosticsComponent struct {
+	app     *app.App
+	list    list.List[any]
+	visible bool
+	focused bool
+	height  int
+	// marked are the keys of the diagnostics to send to the prompt
+	marked map[string]bool
+}
+
+func (d *diagnosticsComponent) Init() tea.Cmd {
+	return nil
+}
+
+func (d *diagnosticsComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		if height := min(maxHeight, max(minHeight, (msg.Height-2)/4)); height != d.height {
+			d.height = height
+			d.list = d.newList()
+			d.refresh()
+		}
+	case opencode.EventListResponseEventLspClientDiagnostics:
+		d.refresh()
+	case tea.KeyPressMsg:
+		if !d.focused {
+			return d, nil
+		}
+		switch msg.String() {
+		case "esc", "tab":
+			d.focused = false
+			return d, nil
+		case "space":
+			if item, idx := d.list.GetSelectedItem(); idx >= 0 {
+				key := item.(app.Diagnostic).Key()
+				if d.marked[key] {
+					delete(d.marked, key)
+				} else {
+					d.marked[key] = true
+				}
+				d.list.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+			}
+			return d, nil
+		case "enter":
+			return d, d.send()
+		}
+		updated, cmd := d.list.Update(msg)
+		d.list = updated.(list.List[any])
+		return d, cmd
+	}
+	return d, nil
+}
+
+// send adds the marked diagnostics to the prompt, or the selected one when
+// none is marked
+func (d *diagnosticsComponent) send() tea.Cmd {
+	var selected []app.Diagnostic
+	for _, diagnostic := range d.app.Diagnostics() {
+		if d.marked[diagnostic.Key()] {
+			selected = append(selected, diagnostic)
+		}
+	}
+	if len(selected) == 0 {
+		item, idx := d.list.GetSelectedItem()
+		if idx < 0 {
+			return nil
+		}
+		selected = append(selected, item.(app.Diagnostic))
+	}
+	d.marked = map[string]bool{}
+	d.focused = false
+	return util.CmdHandler(InsertDiagnosticsMsg{
+		Text: app.FormatDiagnostics(selected, d.app.Project.Worktree),
+	})
+}
+
+// refresh lists the current diagnostics under the header of their severity,
+// keeping the selection on the same diagnostic
+func (d *diagnosticsComponent) refresh() {
+	selectedKey := ""
+	if item, idx := d.list.GetSelectedItem(); idx >= 0 {
+		selectedKey = item.(app.Diagnostic).Key()
+	}
+
+	diagnostics := d.app.Diagnostics()
+	current := map[string]bool{}
+	var items []any
+	for i, diagnostic := range diagnostics {
+		if i == 0 || diagnostics[i-1].Severity != diagnostic.Severity {
+			items = append(items, header{severity: diagnostic.Severity, count: count(diagnostics, diagnostic.Severity)})
+		}
+		items = append(items, diagnostic)
+		current[diagnostic.Key()] = true
+	}
+	for key := range d.marked {
+		if !current[key] {
+			delete(d.marked, key)
+		}
+	}
+
+	d.list.SetItems(items)
+	for i, item := range items {
+		if diagnostic, ok := item.(app.Diagnostic); ok && diagnostic.Key() == selectedKey {
+			d.list.SetSelectedIndex(i)
+			break
+		}
+	}
+}
+
+func count(diagnostics []app.Diagnostic, severity app.DiagnosticSeverity) int {
+	n := 0
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// severityColor returns the color diagnostics of the severity are shown in
+func severityColor(severity app.DiagnosticSeverity) compat.AdaptiveColor {
+	t := theme.CurrentTheme()
+	switch severity {
+	case app.SeverityError:
+		return t.Error()
+	case app.SeverityWarning:
+		return t.Warning()
+	case app.SeverityInformation:
+		return t.Info()
+	}
+	return t.TextMuted()
+}
+
+func (d *diagnosticsComponent) renderItem(item any, selected bool, width int, _ styles.Style) string {
+	t := theme.CurrentTheme()
+	base := styles.NewStyle().Background(t.BackgroundPanel())
+
+	if h, ok := item.(header); ok {
+		label := fmt.Sprintf("%s (%d)", headers[h.severity], h.count)
+		return base.Foreground(severityColor(h.severity)).Bold(true).PaddingLeft(1).Render(label)
+	}
+
+	diagnostic := item.(app.Diagnostic)
+	selected = selected && d.focused
+	locationStyle := base.Foreground(severityColor(diagnostic.Severity))
+	textStyle := base.Foreground(t.Text())
+	markStyle := base.Foreground(t.Primary())
+	if selected {
+		base = base.Background(t.Primary())
+		locationStyle = base.Foreground(t.BackgroundElement())
+		textStyle = locationStyle
+		markStyle = locationStyle
+	}
+
+	mark := "  "
+	if d.marked[diagnostic.Key()] {
+		mark = "● "
+	}
+	path := util.Relative(diagnostic.Path)
+	if rel, err := filepath.Rel(d.app.Project.Worktree, diagnostic.Path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
+	}
+	location := fmt.Sprintf("%s:%d:%d ", path, diagnostic.Line, diagnostic.Column)
+	message := strings.Join(strings.Fields(diagnostic.Message), " ")
+	available := max(8, width-3-lipgloss.Width(mark)-lipgloss.Width(location))
+	message = truncate.StringWithTail(message, uint(available), "...")
+
+	line := markStyle.Render(mark) + locationStyle.Render(location) + textStyle.Render(message)
+	return base.Width(width).PaddingLeft(1).Render(line)
+}
+
+func (d *diagnosticsComponent) View() string {
+	if !d.visible {
+		return ""
+	}
+	t := theme.CurrentTheme()
+	width := layout.Current.Container.Width
+	base := styles.NewStyle().Background(t.BackgroundPanel())
+	keyStyle := base.Foreground(t.Text()).Bold(true).Render
+	mutedStyle := base.Foreground(t.TextMuted()).Render
+
+	diagnostics := d.app.Diagnostics()
+	title := keyStyle("Diagnostics")
+	for _, severity := range app.Severities {
+		if n := count(diagnostics, severity); n > 0 {
+			title += base.Foreground(severityColor(severity)).Render(fmt.Sprintf("  %d %s", n, severity))
+		}
+	}
+	if d.focused {
+		title += mutedStyle("   ") +
+			keyStyle("space") + mutedStyle(" mark  ") +
+			keyStyle("enter") + mutedStyle(" send to prompt  ") +
+			keyStyle("esc") + mutedStyle(" back")
+	}
+	title = truncate.StringWithTail(title, uint(width-2), "…")
+
+	d.list.SetMaxWidth(width - 2)
+	body := d.list.View()
+	if d.list.IsEmpty() {
+		body = mutedStyle(" No diagnostics")
+	}
+
+	content := base.PaddingLeft(1).Render(title) + "\n" + body
+	return base.
+		Width(width).
+		Height(d.height).
+		MaxHeight(d.height).
+		Render(content)
+}
+
+func (d *diagnosticsComponent) Visible() bool {
+	return d.visible
+}
+
+func (d *diagnosticsComponent) Focused() bool {
+	return d.visible && d.focused
+}
+
+func (d *diagnosticsComponent) Toggle() {
+	switch {
+	case !d.visible:
+		d.visible = true
+		d.focused = true
+		d.refresh()
+	case !d.focused:
+		d.focused = true
+	default:
+		d.visible = false
+		d.focused = false
+	}
+}
+
+func (d *diagnosticsComponent) Blur() {
+	d.focused = false
+}
+
+func (d *diagnosticsComponent) Height() int {
+	if !d.visible {
+		return 0
+	}
+	return d.height
+}
+
+// NewDiagnosticsComponent creates the hidden diagnostics panel
+func NewDiagnosticsComponent(app *app.App) DiagnosticsComponent {
+	d := &diagnosticsComponent{
+		app:    app,
+		height: minHeight,
+		marked: map[string]bool{},
+	}
+	d.list = d.newList()
+	return d
+}
+
+// newList creates the list of diagnostics filling the panel below its title
+func (d *diagnosticsComponent) newList() list.List[any] {
+	return list.NewListComponent(
+		list.WithMaxVisibleHeight[any](d.height-1),
+		list.WithAlphaNumericKeys[any](true),
+		list.WithRenderFunc(d.renderItem),
+		list.WithSelectableFunc(func(item any) bool {
+			_, ok := item.(app.Diagnostic)
+			return ok
+		}),
+	)
+}
@@ -172,8 +172,10 @@ func (c *completionDialogComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				triggerWidth := lipgloss.Width(c.trigger)
 
 				if msg.String() == "space" || msg.String() == " " {
+					// A space right after the trigger is text, such as a
+					// markdown heading
 					item, i := c.list.GetSelectedItem()
-					if i > -1 {
+					if i > -1 && value != c.trigger {
 						return c, c.complete(item)
 					}
 					// If no exact match, close the dialog
@@ -0,0 +1,186 @@
+package dialog
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/exporter"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+// ExportDialog interface for the session export dialog
+type ExportDialog interface {
+	layout.Modal
+}
+
+// ExportSessionMsg is sent when the current session should be exported
+type ExportSessionMsg struct {
+	Path   string
+	Format exporter.Format
+}
+
+type exportDialog struct {
+	modal  *modal.Modal
+	input  textinput.Model
+	format int // index in exporter.Formats
+}
+
+func (e *exportDialog) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (e *exportDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "tab", "shift+tab":
+			step := 1
+			if msg.String() == "shift+tab" {
+				step = len(exporter.Formats) - 1
+			}
+			previous := exporter.Formats[e.format]
+			e.format = (e.format + step) % len(exporter.Formats)
+			// Follow the format with the extension, unless it was changed by hand
+			path := e.input.Value()
+			if format, ok := exporter.FormatForPath(path); ok && format == previous {
+				path = strings.TrimSuffix(path, filepath.Ext(path)) + exporter.Formats[e.format].Extension()
+				e.input.SetValue(path)
+				e.input.CursorEnd()
+			}
+			return e, nil
+		case "enter":
+			path := strings.TrimSpace(e.input.Value())
+			if path == "" {
+				return e, nil
+			}
+			path = expandPath(path)
+			return e, tea.Sequence(
+				util.CmdHandler(modal.CloseModalMsg{}),
+				util.CmdHandler(ExportSessionMsg{Path: path, Format: exporter.Formats[e.format]}),
+			)
+		}
+	}
+
+	var cmd tea.Cmd
+	e.input, cmd = e.input.Update(msg)
+	return e, cmd
+}
+
+func (e *exportDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	keyStyle := styles.NewStyle().
+		Foreground(t.Text()).
+		Background(t.BackgroundPanel()).
+		Bold(true).
+		Render
+	mutedStyle := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundPanel()).Render
+	selectedStyle := styles.NewStyle().
+		Foreground(t.BackgroundElement()).
+		Background(t.Primary()).
+		Padding(0, 1).
+		Render
+	formatStyle := styles.NewStyle().
+		Foreground(t.Text()).
+		Background(t.BackgroundPanel()).
+		Padding(0, 1).
+		Render
+
+	var formats []string
+	for i, format := range exporter.Formats {
+		if i == e.format {
+			formats = append(formats, selectedStyle(string(format)))
+		} else {
+			formats = append(formats, formatStyle(string(format)))
+		}
+	}
+	formatRow := styles.NewStyle().PaddingLeft(1).PaddingTop(1).Render(strings.Join(formats, mutedStyle(" ")))
+
+	helpText := keyStyle("tab") + mutedStyle(" format   ") + keyStyle("enter") + mutedStyle(" export")
+	helpText = styles.NewStyle().PaddingLeft(1).PaddingTop(1).Render(helpText)
+
+	content := strings.Join([]string{e.input.View(), formatRow, helpText}, "\n")
+	return e.modal.Render(content, background)
+}
+
+func (e *exportDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewExportDialog creates a dialog asking where to export the current session
+func NewExportDialog(app *app.App) ExportDialog {
+	format := exporter.FormatMarkdown
+	if app.State.ExportFormat != "" {
+		if f, err := exporter.ParseFormat(app.State.ExportFormat); err == nil {
+			format = f
+		}
+	}
+
+	dir := app.Session.Directory
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	path := filepath.Join(dir, exporter.DefaultFilename(*app.Session, format))
+
+	return &exportDialog{
+		input:  newPathInput(path, "Path to export to"),
+		format: slices.Index(exporter.Formats, format),
+		modal: modal.New(
+			modal.WithTitle("Export Session"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+}
+
+// newPathInput creates a focused text input for a file path
+func newPathInput(value string, placeholder string) textinput.Model {
+	t := theme.CurrentTheme()
+	bgColor := t.BackgroundPanel()
+	textColor := t.Text()
+	textMutedColor := t.TextMuted()
+
+	input := textinput.New()
+	input.SetValue(value)
+	input.Placeholder = placeholder
+	input.Focus()
+	input.CursorEnd()
+	input.SetWidth(layout.Current.Container.Width - 20)
+	input.Styles.Blurred.Placeholder = styles.NewStyle().
+		Foreground(textMutedColor).
+		Background(bgColor).
+		Lipgloss()
+	input.Styles.Blurred.Text = styles.NewStyle().
+		Foreground(textColor).
+		Background(bgColor).
+		Lipgloss()
+	input.Styles.Focused.Placeholder = styles.NewStyle().
+		Foreground(textMutedColor).
+		Background(bgColor).
+		Lipgloss()
+	input.Styles.Focused.Text = styles.NewStyle().
+		Foreground(textColor).
+		Background(bgColor).
+		Lipgloss()
+	input.Styles.Focused.Prompt = styles.NewStyle().
+		Background(bgColor).
+		Lipgloss()
+	return input
+}
+
+// expandPath expands a leading ~ to the home directory
+func expandPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,153 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/diff"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/viewport"
+)
+
+// FileDiffDialog interface for the full-screen diff of the files changed in
+// a session
+type FileDiffDialog interface {
+	layout.Modal
+}
+
+type fileDiffDialog struct {
+	changes  []app.FileChange
+	index    int
+	unified  bool
+	viewport viewport.Model
+}
+
+func (f *fileDiffDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (f *fileDiffDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.resize()
+		return f, nil
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "n", "]", "tab":
+			f.show((f.index + 1) % len(f.changes))
+			return f, nil
+		case "p", "[", "shift+tab":
+			f.show((f.index - 1 + len(f.changes)) % len(f.changes))
+			return f, nil
+		case "s":
+			f.unified = !f.unified
+			f.render()
+			return f, nil
+		case "g", "home":
+			f.viewport.GotoTop()
+			return f, nil
+		case "G", "end":
+			f.viewport.GotoBottom()
+			return f, nil
+		}
+	}
+	var cmd tea.Cmd
+	f.viewport, cmd = f.viewport.Update(msg)
+	return f, cmd
+}
+
+// resize fits the viewport in the screen, below the title and above the key
+// hints
+func (f *fileDiffDialog) resize() {
+	f.viewport.SetWidth(layout.Current.Viewport.Width)
+	f.viewport.SetHeight(max(1, layout.Current.Viewport.Height-2))
+	f.render()
+}
+
+// show displays the diff of the change at the index
+func (f *fileDiffDialog) show(index int) {
+	f.index = index
+	f.render()
+	f.viewport.GotoTop()
+}
+
+// render formats the diff of the current change at the width of the screen
+func (f *fileDiffDialog) render() {
+	t := theme.CurrentTheme()
+	change := f.changes[f.index]
+	width := f.viewport.Width()
+
+	var formatted string
+	var err error
+	if f.unified {
+		formatted, err = diff.FormatUnifiedDiff(change.Path, change.Diff, diff.WithWidth(width))
+	} else {
+		formatted, err = diff.FormatDiff(change.Path, change.Diff, diff.WithWidth(width))
+	}
+	formatted = strings.TrimSuffix(formatted, "\n")
+	if err != nil || formatted == "" {
+		formatted = styles.NewStyle().
+			Foreground(t.TextMuted()).
+			Background(t.Background()).
+			Width(width).
+			Padding(1, 2).
+			Render("No textual changes to show")
+	}
+	f.viewport.SetContent(formatted)
+}
+
+func (f *fileDiffDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Viewport.Width
+	base := styles.NewStyle().Background(t.BackgroundPanel())
+	keyStyle := base.Foreground(t.Text()).Bold(true).Render
+	mutedStyle := base.Foreground(t.TextMuted()).Render
+
+	change := f.changes[f.index]
+	title := keyStyle(change.Path) +
+		base.Foreground(t.Success()).Render(fmt.Sprintf("  +%d", change.Added)) +
+		base.Foreground(t.Error()).Render(fmt.Sprintf(" -%d", change.Removed)) +
+		mutedStyle(fmt.Sprintf("  %d/%d", f.index+1, len(f.changes)))
+
+	mode := "unified"
+	if f.unified {
+		mode = "side by side"
+	}
+	help := keyStyle("n/p") + mutedStyle(" next/previous file   ") +
+		keyStyle("s") + mutedStyle(" "+mode+"   ") +
+		keyStyle("esc") + mutedStyle(" close") +
+		mutedStyle(fmt.Sprintf("   %3.f%%", f.viewport.ScrollPercent()*100))
+
+	body := lipgloss.PlaceVertical(
+		f.viewport.Height(),
+		lipgloss.Top,
+		f.viewport.View(),
+		styles.WhitespaceStyle(t.Background()),
+	)
+	return base.Width(width).PaddingLeft(1).Render(title) + "\n" +
+		body + "\n" +
+		base.Width(width).PaddingLeft(1).Render(help)
+}
+
+func (f *fileDiffDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewFileDiffDialog creates a full-screen view of the diff of each changed
+// file, starting at the one at the index. The diffs are side by side when the
+// screen is wide enough, as in the messages.
+func NewFileDiffDialog(changes []app.FileChange, index int) FileDiffDialog {
+	dialog := &fileDiffDialog{
+		changes:  changes,
+		index:    index,
+		unified:  layout.Current.Viewport.Width < 120,
+		viewport: viewport.New(),
+	}
+	dialog.resize()
+	return dialog
+}
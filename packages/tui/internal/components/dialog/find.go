@@ -0,0 +1,263 @@
+package dialog
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/search"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+const (
+	numVisibleHits = 12
+	maxHits        = 100
+)
+
+// FindDialog interface for the dialog searching the messages of all sessions
+type FindDialog interface {
+	layout.Modal
+}
+
+type findIndexLoadedMsg struct {
+	index *search.Index
+}
+
+type findIndexUpdatedMsg struct {
+	err error
+}
+
+// findItem is a message matching the query
+type findItem struct {
+	hit search.Hit
+}
+
+func (f findItem) Render(selected bool, width int, baseStyle styles.Style) string {
+	t := theme.CurrentTheme()
+
+	textStyle := baseStyle.Background(t.BackgroundPanel()).Foreground(t.Text())
+	if selected {
+		textStyle = textStyle.Foreground(t.Primary())
+	}
+	matchStyle := textStyle.Foreground(t.Accent()).Bold(true)
+	roleStyle := baseStyle.Background(t.BackgroundPanel()).Foreground(t.TextMuted())
+
+	role := "› "
+	if f.hit.Role == "assistant" {
+		role = "‹ "
+	}
+
+	// Cut the snippet to the width, then style the matches left in it
+	available := max(1, width-2-ansi.StringWidth(role))
+	snippet := f.hit.Snippet
+	tail := ""
+	if ansi.StringWidth(snippet) > available {
+		snippet = ansi.Truncate(snippet, available-1, "")
+		tail = "…"
+	}
+	var b strings.Builder
+	b.WriteString(roleStyle.Render(role))
+	offset := 0
+	for _, match := range f.hit.Matches {
+		if match[0] >= len(snippet) {
+			break
+		}
+		end := min(match[1], len(snippet))
+		b.WriteString(textStyle.Render(snippet[offset:match[0]]))
+		b.WriteString(matchStyle.Render(snippet[match[0]:end]))
+		offset = end
+	}
+	b.WriteString(textStyle.Render(snippet[offset:] + tail))
+
+	return baseStyle.
+		Background(t.BackgroundPanel()).
+		PaddingLeft(1).
+		Render(b.String())
+}
+
+func (f findItem) Selectable() bool {
+	return true
+}
+
+// findStatusItem is a line telling why there are no hits
+type findStatusItem string
+
+func (f findStatusItem) Render(selected bool, width int, baseStyle styles.Style) string {
+	t := theme.CurrentTheme()
+	return baseStyle.
+		Background(t.BackgroundPanel()).
+		Foreground(t.TextMuted()).
+		PaddingLeft(1).
+		Render(string(f))
+}
+
+func (f findStatusItem) Selectable() bool {
+	return false
+}
+
+type findDialog struct {
+	app          *app.App
+	modal        *modal.Modal
+	searchDialog *SearchDialog
+	index        *search.Index
+	updating     bool
+}
+
+func (f *findDialog) Init() tea.Cmd {
+	return tea.Batch(f.searchDialog.Init(), f.loadIndex())
+}
+
+// loadIndex opens the cached index, so it can be searched while it's updated
+func (f *findDialog) loadIndex() tea.Cmd {
+	path := search.CachePath(filepath.Dir(f.app.StatePath), f.app.Project.ID)
+	return func() tea.Msg {
+		index, err := search.Open(path)
+		if err != nil {
+			slog.Error("Failed to open search index", "error", err)
+			index = search.New(path)
+		}
+		return findIndexLoadedMsg{index: index}
+	}
+}
+
+func (f *findDialog) updateIndex() tea.Cmd {
+	index := f.index
+	return func() tea.Msg {
+		ctx := context.Background()
+		sessions, err := f.app.ListSessions(ctx)
+		if err != nil {
+			return findIndexUpdatedMsg{err: err}
+		}
+		changed, err := index.Update(ctx, f.app.Client, sessions)
+		if changed {
+			if err := index.Save(); err != nil {
+				slog.Error("Failed to save search index", "error", err)
+			}
+		}
+		return findIndexUpdatedMsg{err: err}
+	}
+}
+
+func (f *findDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case findIndexLoadedMsg:
+		f.index = msg.index
+		f.updating = true
+		f.refresh(f.searchDialog.GetQuery())
+		return f, f.updateIndex()
+
+	case findIndexUpdatedMsg:
+		f.updating = false
+		f.refresh(f.searchDialog.GetQuery())
+		if msg.err != nil {
+			slog.Error("Failed to update search index", "error", msg.err)
+			return f, toast.NewErrorToast("Failed to index sessions")
+		}
+		return f, nil
+
+	case SearchSelectionMsg:
+		if item, ok := msg.Item.(findItem); ok {
+			return f, f.open(item.hit)
+		}
+		return f, nil
+
+	case SearchCancelledMsg:
+		return f, util.CmdHandler(modal.CloseModalMsg{})
+
+	case SearchQueryChangedMsg:
+		f.refresh(msg.Query)
+		return f, nil
+
+	case tea.WindowSizeMsg:
+		f.searchDialog.SetWidth(layout.Current.Container.Width - 14)
+	}
+
+	updated, cmd := f.searchDialog.Update(msg)
+	f.searchDialog = updated.(*SearchDialog)
+	return f, cmd
+}
+
+// refresh lists the hits of the query, grouped by session
+func (f *findDialog) refresh(query string) {
+	var items []list.Item
+	switch {
+	case f.index == nil:
+		items = append(items, findStatusItem("Loading…"))
+	case strings.TrimSpace(query) == "":
+		items = append(items, findStatusItem("Type to search the messages of every session"))
+	default:
+		hits := f.index.Search(query, maxHits)
+		session := ""
+		for _, hit := range hits {
+			if hit.SessionID != session {
+				session = hit.SessionID
+				items = append(items, list.HeaderItem(hit.Title))
+			}
+			items = append(items, findItem{hit: hit})
+		}
+		if len(hits) == 0 {
+			items = append(items, findStatusItem("No messages found"))
+		}
+	}
+	if f.updating {
+		items = append(items, findStatusItem("Indexing sessions…"))
+	}
+	f.searchDialog.SetItems(items)
+}
+
+// open switches to the session of the hit, if needed, and scrolls to the
+// message once it's rendered
+func (f *findDialog) open(hit search.Hit) tea.Cmd {
+	scroll := util.CmdHandler(ScrollToMessageMsg{MessageID: hit.MessageID})
+	if f.app.Session != nil && f.app.Session.ID == hit.SessionID {
+		return tea.Sequence(util.CmdHandler(modal.CloseModalMsg{}), scroll)
+	}
+	return tea.Sequence(
+		util.CmdHandler(modal.CloseModalMsg{}),
+		func() tea.Msg {
+			session, err := f.app.Client.Session.Get(context.Background(), hit.SessionID, opencode.SessionGetParams{})
+			if err != nil {
+				slog.Error("Failed to get session", "error", err)
+				return toast.NewErrorToast("Failed to open session")()
+			}
+			return app.SessionSelectedMsg(session)
+		},
+		scroll,
+	)
+}
+
+func (f *findDialog) Render(background string) string {
+	return f.modal.Render(f.searchDialog.View(), background)
+}
+
+func (f *findDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewFindDialog creates a dialog searching the messages of every session
+func NewFindDialog(app *app.App) FindDialog {
+	searchDialog := NewSearchDialog("Search all sessions...", numVisibleHits)
+	searchDialog.SetWidth(layout.Current.Container.Width - 14)
+
+	dialog := &findDialog{
+		app:          app,
+		searchDialog: searchDialog,
+		modal: modal.New(
+			modal.WithTitle("Search Sessions"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+	dialog.refresh("")
+	return dialog
+}
@@ -0,0 +1,80 @@
+package dialog
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+// ImportDialog interface for the session import dialog
+type ImportDialog interface {
+	layout.Modal
+}
+
+// ImportSessionMsg is sent when a session exported as JSON should be imported
+type ImportSessionMsg struct {
+	Path string
+}
+
+type importDialog struct {
+	modal *modal.Modal
+	input textinput.Model
+}
+
+func (i *importDialog) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (i *importDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyPressMsg); ok && msg.String() == "enter" {
+		path := strings.TrimSpace(i.input.Value())
+		if path == "" {
+			return i, nil
+		}
+		return i, tea.Sequence(
+			util.CmdHandler(modal.CloseModalMsg{}),
+			util.CmdHandler(ImportSessionMsg{Path: expandPath(path)}),
+		)
+	}
+
+	var cmd tea.Cmd
+	i.input, cmd = i.input.Update(msg)
+	return i, cmd
+}
+
+func (i *importDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	keyStyle := styles.NewStyle().
+		Foreground(t.Text()).
+		Background(t.BackgroundPanel()).
+		Bold(true).
+		Render
+	mutedStyle := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundPanel()).Render
+
+	helpText := keyStyle("enter") + mutedStyle(" import as a new session")
+	helpText = styles.NewStyle().PaddingLeft(1).PaddingTop(1).Render(helpText)
+
+	content := strings.Join([]string{i.input.View(), helpText}, "\n")
+	return i.modal.Render(content, background)
+}
+
+func (i *importDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewImportDialog creates a dialog asking for a session exported as JSON
+func NewImportDialog() ImportDialog {
+	return &importDialog{
+		input: newPathInput("", "Path to an exported session (.json)"),
+		modal: modal.New(
+			modal.WithTitle("Import Session"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+}
@@ -0,0 +1,439 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/muesli/reflow/truncate"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/diff"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+const previewLines = 14
+
+// PermissionsDialog interface for the dialog reviewing permission requests,
+// the saved rules and the audit log
+type PermissionsDialog interface {
+	layout.Modal
+}
+
+type permissionsView int
+
+const (
+	permissionsQueueView permissionsView = iota
+	permissionsRulesView
+	permissionsLogView
+)
+
+// permissionItem is a pending permission request
+type permissionItem struct {
+	permission opencode.Permission
+}
+
+func (p permissionItem) Render(selected bool, width int, baseStyle styles.Style) string {
+	return renderPermissionsRow(selected, width, baseStyle, p.permission.Type, p.permission.Title, "")
+}
+
+func (p permissionItem) Selectable() bool {
+	return true
+}
+
+// ruleItem is a saved permission rule
+type ruleItem struct {
+	rule app.PermissionRule
+}
+
+func (r ruleItem) Render(selected bool, width int, baseStyle styles.Style) string {
+	return renderPermissionsRow(selected, width, baseStyle, string(r.rule.Action), r.rule.Type, r.rule.Pattern)
+}
+
+func (r ruleItem) Selectable() bool {
+	return true
+}
+
+// decisionItem is an entry of the audit log
+type decisionItem struct {
+	decision app.PermissionDecision
+}
+
+func (d decisionItem) Render(selected bool, width int, baseStyle styles.Style) string {
+	source := "by hand"
+	if d.decision.Rule != "" {
+		source = "rule " + d.decision.Rule
+	}
+	return renderPermissionsRow(
+		selected,
+		width,
+		baseStyle,
+		d.decision.Time.Format("Jan 02 15:04")+" "+d.decision.Response,
+		d.decision.Title,
+		source,
+	)
+}
+
+func (d decisionItem) Selectable() bool {
+	return true
+}
+
+// renderPermissionsRow renders a label, the text and a muted suffix on a line
+func renderPermissionsRow(selected bool, width int, baseStyle styles.Style, label, text, suffix string) string {
+	t := theme.CurrentTheme()
+	labelStyle := baseStyle.Background(t.BackgroundPanel()).Foreground(t.Info())
+	textStyle := baseStyle.Background(t.BackgroundPanel()).Foreground(t.Text())
+	mutedStyle := baseStyle.Background(t.BackgroundPanel()).Foreground(t.TextMuted())
+	if selected {
+		labelStyle = baseStyle.Background(t.Primary()).Foreground(t.BackgroundElement())
+		textStyle = labelStyle
+		mutedStyle = labelStyle
+	}
+
+	label += " "
+	if suffix != "" {
+		suffix = " " + suffix
+	}
+	available := max(8, width-2-lipgloss.Width(label)-lipgloss.Width(suffix))
+	text = truncate.StringWithTail(strings.Split(text, "\n")[0], uint(available), "...")
+
+	line := labelStyle.Render(label) + textStyle.Render(text) + mutedStyle.Render(suffix)
+	if selected {
+		return baseStyle.Background(t.Primary()).Width(width).PaddingLeft(1).Render(line)
+	}
+	return baseStyle.PaddingLeft(1).Render(line)
+}
+
+type permissionsDialog struct {
+	app     *app.App
+	modal   *modal.Modal
+	list    list.List[list.Item]
+	view    permissionsView
+	pattern textinput.Model
+	// selected is the permission the pattern was suggested for
+	selected string
+}
+
+func (p *permissionsDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (p *permissionsDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case opencode.EventListResponseEventPermissionUpdated,
+		opencode.EventListResponseEventPermissionReplied:
+		if p.view == permissionsQueueView {
+			_, idx := p.list.GetSelectedItem()
+			p.refresh()
+			p.list.SetSelectedIndex(min(max(idx, 0), len(p.list.GetItems())-1))
+			p.suggestPattern()
+		}
+		return p, nil
+
+	case tea.WindowSizeMsg:
+		p.list.SetMaxWidth(layout.Current.Container.Width - 12)
+		p.pattern.SetWidth(layout.Current.Container.Width - 26)
+
+	case tea.KeyPressMsg:
+		if p.pattern.Focused() {
+			if msg.String() == "enter" {
+				p.pattern.Blur()
+				return p, nil
+			}
+			var cmd tea.Cmd
+			p.pattern, cmd = p.pattern.Update(msg)
+			return p, cmd
+		}
+
+		switch msg.String() {
+		case "tab", "shift+tab":
+			step := 1
+			if msg.String() == "shift+tab" {
+				step = 2
+			}
+			p.view = (p.view + permissionsView(step)) % 3
+			p.refresh()
+			return p, nil
+		}
+
+		switch p.view {
+		case permissionsQueueView:
+			if cmd, ok := p.updateQueue(msg); ok {
+				return p, cmd
+			}
+		case permissionsRulesView:
+			switch msg.String() {
+			case "d", "delete", "backspace":
+				if item, idx := p.list.GetSelectedItem(); idx >= 0 {
+					p.app.State.RemovePermissionRule(item.(ruleItem).rule)
+					p.refresh()
+					p.list.SetSelectedIndex(min(idx, len(p.list.GetItems())-1))
+					return p, p.app.SaveState()
+				}
+			}
+		}
+	}
+
+	listModel, cmd := p.list.Update(msg)
+	p.list = listModel.(list.List[list.Item])
+	p.suggestPattern()
+	return p, cmd
+}
+
+// updateQueue answers the selected permission, saving a rule for it with + and
+// -. It reports whether the key was handled.
+func (p *permissionsDialog) updateQueue(msg tea.KeyPressMsg) (tea.Cmd, bool) {
+	item, idx := p.list.GetSelectedItem()
+	if idx < 0 {
+		return nil, false
+	}
+	permission := item.(permissionItem).permission
+
+	response := opencode.SessionPermissionRespondParamsResponse("")
+	rule := ""
+	switch msg.String() {
+	case "enter":
+		response = opencode.SessionPermissionRespondParamsResponseOnce
+	case "a":
+		response = opencode.SessionPermissionRespondParamsResponseAlways
+	case "r":
+		response = opencode.SessionPermissionRespondParamsResponseReject
+	case "e":
+		return p.pattern.Focus(), true
+	case "+", "=", "-":
+		rule = strings.TrimSpace(p.pattern.Value())
+		if rule == "" || app.PermissionSubject(permission) == "" {
+			return nil, true
+		}
+		action := app.PermissionAllow
+		response = opencode.SessionPermissionRespondParamsResponseOnce
+		if msg.String() == "-" {
+			action = app.PermissionDeny
+			response = opencode.SessionPermissionRespondParamsResponseReject
+		}
+		p.app.State.AddPermissionRule(app.PermissionRule{
+			Type:    permission.Type,
+			Pattern: rule,
+			Action:  action,
+		})
+	default:
+		return nil, false
+	}
+
+	cmd := p.app.RespondPermission(permission, response, rule)
+	p.refresh()
+	p.list.SetSelectedIndex(min(idx, len(p.list.GetItems())-1))
+	p.suggestPattern()
+	return cmd, true
+}
+
+// refresh lists the items of the current view
+func (p *permissionsDialog) refresh() {
+	var items []list.Item
+	switch p.view {
+	case permissionsQueueView:
+		for _, permission := range p.app.Permissions {
+			items = append(items, permissionItem{permission: permission})
+		}
+		p.list.SetEmptyMessage(" No pending permissions")
+	case permissionsRulesView:
+		for _, rule := range p.app.State.PermissionRules {
+			items = append(items, ruleItem{rule: rule})
+		}
+		p.list.SetEmptyMessage(" No rules, add one with + or - on a pending permission")
+	case permissionsLogView:
+		// Latest decisions first
+		for i := len(p.app.State.PermissionLog) - 1; i >= 0; i-- {
+			items = append(items, decisionItem{decision: p.app.State.PermissionLog[i]})
+		}
+		p.list.SetEmptyMessage(" No decisions yet")
+	}
+	p.list.SetItems(items)
+	p.suggestPattern()
+}
+
+// suggestPattern fills the pattern in for the selected permission, when the
+// selection changed
+func (p *permissionsDialog) suggestPattern() {
+	if p.view != permissionsQueueView {
+		return
+	}
+	item, idx := p.list.GetSelectedItem()
+	if idx < 0 {
+		p.selected = ""
+		p.pattern.SetValue("")
+		return
+	}
+	permission := item.(permissionItem).permission
+	if permission.ID == p.selected {
+		return
+	}
+	p.selected = permission.ID
+	p.pattern.SetValue(app.SuggestPermissionPattern(permission, p.app.Project.Worktree))
+	p.pattern.CursorEnd()
+}
+
+// renderPreview renders what the permission would do: the diff of an edit,
+// the content of a written file or the command
+func (p *permissionsDialog) renderPreview(permission opencode.Permission, width int) string {
+	t := theme.CurrentTheme()
+	filePath, _ := permission.Metadata["filePath"].(string)
+
+	preview := ""
+	switch permission.Type {
+	case "edit":
+		if patch, ok := permission.Metadata["diff"].(string); ok {
+			preview, _ = diff.FormatUnifiedDiff(filePath, patch, diff.WithWidth(width))
+		}
+	case "write":
+		if content, ok := permission.Metadata["content"].(string); ok {
+			preview = util.RenderFile(filePath, content, width, util.WithTruncate(previewLines))
+		}
+	case "bash":
+		preview = "$ " + app.PermissionSubject(permission)
+	default:
+		preview = permission.Title
+	}
+	if preview == "" {
+		preview = permission.Title
+	}
+
+	lines := strings.Split(preview, "\n")
+	if len(lines) > previewLines {
+		more := fmt.Sprintf("… %d more lines", len(lines)-previewLines)
+		lines = append(lines[:previewLines], styles.NewStyle().
+			Foreground(t.TextMuted()).
+			Background(t.BackgroundPanel()).
+			Render(more))
+	}
+	return styles.NewStyle().
+		Foreground(t.Text()).
+		Background(t.BackgroundPanel()).
+		Width(width).
+		Render(strings.Join(lines, "\n"))
+}
+
+func (p *permissionsDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Container.Width - 14
+	keyStyle := styles.NewStyle().
+		Foreground(t.Text()).
+		Background(t.BackgroundPanel()).
+		Bold(true).
+		Render
+	mutedStyle := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundPanel()).Render
+	selectedStyle := styles.NewStyle().
+		Foreground(t.BackgroundElement()).
+		Background(t.Primary()).
+		Padding(0, 1).
+		Render
+	tabStyle := styles.NewStyle().
+		Foreground(t.Text()).
+		Background(t.BackgroundPanel()).
+		Padding(0, 1).
+		Render
+
+	var tabs []string
+	for i, label := range []string{
+		fmt.Sprintf("Queue %d", len(p.app.Permissions)),
+		fmt.Sprintf("Rules %d", len(p.app.State.PermissionRules)),
+		"Log",
+	} {
+		if permissionsView(i) == p.view {
+			tabs = append(tabs, selectedStyle(label))
+		} else {
+			tabs = append(tabs, tabStyle(label))
+		}
+	}
+	sections := []string{
+		styles.NewStyle().PaddingLeft(1).PaddingBottom(1).Render(strings.Join(tabs, mutedStyle(" "))),
+		p.list.View(),
+	}
+
+	var help string
+	switch p.view {
+	case permissionsQueueView:
+		if item, idx := p.list.GetSelectedItem(); idx >= 0 {
+			permission := item.(permissionItem).permission
+			if app.PermissionSubject(permission) != "" {
+				label := mutedStyle("pattern ")
+				if p.pattern.Focused() {
+					label = keyStyle("pattern ")
+				}
+				sections = append(sections, "", styles.NewStyle().
+					PaddingLeft(1).
+					Render(label+p.pattern.View()))
+			}
+			sections = append(sections, "", styles.NewStyle().
+				PaddingLeft(1).
+				Render(p.renderPreview(permission, width-2)))
+		}
+		if p.pattern.Focused() {
+			help = keyStyle("enter") + mutedStyle(" done")
+		} else {
+			help = keyStyle("enter") + mutedStyle(" once   ") +
+				keyStyle("a") + mutedStyle(" always   ") +
+				keyStyle("r") + mutedStyle(" reject   ") +
+				keyStyle("+/-") + mutedStyle(" allow/deny rule   ") +
+				keyStyle("e") + mutedStyle(" edit pattern")
+		}
+	case permissionsRulesView:
+		help = keyStyle("d") + mutedStyle(" delete rule")
+	}
+	if help != "" {
+		help = mutedStyle("   ") + help
+	}
+	help = keyStyle("tab") + mutedStyle(" view") + help
+
+	sections = append(sections, styles.NewStyle().
+		Background(t.BackgroundPanel()).
+		Width(width).
+		PaddingLeft(1).
+		PaddingTop(1).
+		Render(help))
+
+	return p.modal.Render(strings.Join(sections, "\n"), background)
+}
+
+func (p *permissionsDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewPermissionsDialog creates a dialog reviewing the pending permission
+// requests, the saved rules and the audit log
+func NewPermissionsDialog(app *app.App) PermissionsDialog {
+	pattern := newPathInput("", "Command prefix or path glob")
+	pattern.Blur()
+	pattern.SetWidth(layout.Current.Container.Width - 26)
+
+	listComponent := list.NewListComponent(
+		list.WithMaxVisibleHeight[list.Item](8),
+		list.WithAlphaNumericKeys[list.Item](true),
+		list.WithRenderFunc(func(item list.Item, selected bool, width int, baseStyle styles.Style) string {
+			return item.Render(selected, width, baseStyle)
+		}),
+		list.WithSelectableFunc(func(item list.Item) bool {
+			return item.Selectable()
+		}),
+	)
+	listComponent.SetMaxWidth(layout.Current.Container.Width - 12)
+
+	dialog := &permissionsDialog{
+		app:     app,
+		list:    listComponent,
+		pattern: pattern,
+		modal: modal.New(
+			modal.WithTitle("Permissions"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+	dialog.refresh()
+	return dialog
+}
@@ -0,0 +1,215 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/muesli/reflow/truncate"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/diff"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+	"github.com/sst/opencode/internal/viewport"
+)
+
+// ReviewDialog interface for the full-screen review of the hunks of the
+// changes of a session
+type ReviewDialog interface {
+	layout.Modal
+}
+
+// ReviewAppliedMsg is sent once the rejected hunks of a review were reverted.
+// Reverted holds the rejected hunks that were actually reverted, and Err the
+// errors of the files that couldn't be.
+type ReviewAppliedMsg struct {
+	Hunks    []app.ReviewHunk
+	Reverted []app.ReviewHunk
+	Err      error
+}
+
+type reviewDialog struct {
+	app      *app.App
+	hunks    []app.ReviewHunk
+	index    int
+	unified  bool
+	viewport viewport.Model
+}
+
+func (r *reviewDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (r *reviewDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.resize()
+		return r, nil
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "a", "y":
+			r.decide(false)
+			return r, nil
+		case "r", "x":
+			r.decide(true)
+			return r, nil
+		case "A":
+			r.decideFile(false)
+			return r, nil
+		case "R":
+			r.decideFile(true)
+			return r, nil
+		case "n", "]", "tab", "right":
+			r.show(min(r.index+1, len(r.hunks)-1))
+			return r, nil
+		case "p", "[", "shift+tab", "left":
+			r.show(max(r.index-1, 0))
+			return r, nil
+		case "s":
+			r.unified = !r.unified
+			r.render()
+			return r, nil
+		case "enter":
+			return r, tea.Sequence(
+				util.CmdHandler(modal.CloseModalMsg{}),
+				r.apply(),
+			)
+		}
+	}
+	var cmd tea.Cmd
+	r.viewport, cmd = r.viewport.Update(msg)
+	return r, cmd
+}
+
+// decide accepts or rejects the current hunk and moves to the next one
+func (r *reviewDialog) decide(reject bool) {
+	r.hunks[r.index].Rejected = reject
+	r.show(min(r.index+1, len(r.hunks)-1))
+}
+
+// decideFile accepts or rejects every hunk of the file of the current hunk
+// and moves to the next file
+func (r *reviewDialog) decideFile(reject bool) {
+	path := r.hunks[r.index].Path
+	next := r.index
+	for i := range r.hunks {
+		if r.hunks[i].Path == path {
+			r.hunks[i].Rejected = reject
+			next = i
+		}
+	}
+	r.show(min(next+1, len(r.hunks)-1))
+}
+
+// apply reverts the rejected hunks in the working tree
+func (r *reviewDialog) apply() tea.Cmd {
+	hunks := r.hunks
+	root := r.app.Project.Worktree
+	return func() tea.Msg {
+		reverted, err := app.RevertRejected(root, hunks)
+		return ReviewAppliedMsg{Hunks: hunks, Reverted: reverted, Err: err}
+	}
+}
+
+// resize fits the viewport in the screen, below the title and above the key
+// hints
+func (r *reviewDialog) resize() {
+	r.viewport.SetWidth(layout.Current.Viewport.Width)
+	r.viewport.SetHeight(max(1, layout.Current.Viewport.Height-3))
+	r.render()
+}
+
+// show displays the hunk at the index
+func (r *reviewDialog) show(index int) {
+	r.index = index
+	r.render()
+	r.viewport.GotoTop()
+}
+
+// render formats the current hunk at the width of the screen
+func (r *reviewDialog) render() {
+	hunk := r.hunks[r.index]
+	width := r.viewport.Width()
+	var rendered string
+	if r.unified {
+		rendered = diff.RenderUnifiedHunk(hunk.Path, hunk.Hunk, diff.WithWidth(width))
+	} else {
+		rendered = diff.RenderSideBySideHunk(hunk.Path, hunk.Hunk, diff.WithWidth(width))
+	}
+	r.viewport.SetContent(strings.TrimSuffix(rendered, "\n"))
+}
+
+func (r *reviewDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Viewport.Width
+	base := styles.NewStyle().Background(t.BackgroundPanel())
+	keyStyle := base.Foreground(t.Text()).Bold(true).Render
+	mutedStyle := base.Foreground(t.TextMuted()).Render
+
+	hunk := r.hunks[r.index]
+	decision := base.Foreground(t.Success()).Render("accepted")
+	if hunk.Rejected {
+		decision = base.Foreground(t.Error()).Render("rejected")
+	}
+	rejected := 0
+	for _, h := range r.hunks {
+		if h.Rejected {
+			rejected++
+		}
+	}
+	title := keyStyle(hunk.Path) +
+		mutedStyle(" "+hunk.Hunk.Header+"  ") + decision +
+		mutedStyle(fmt.Sprintf("  hunk %d/%d, %d rejected", r.index+1, len(r.hunks), rejected))
+	title = truncate.StringWithTail(title, uint(width-1), "…")
+	// Only the files the session changed are reviewed, but their diff against
+	// the snapshot can't tell the agent's edits apart from the user's.
+	warning := base.Foreground(t.Warning()).Render(truncate.StringWithTail(
+		"Hunks include any edits made by hand to these files; rejecting one reverts them too.",
+		uint(width-1), "…",
+	))
+
+	mode := "unified"
+	if r.unified {
+		mode = "side by side"
+	}
+	help := keyStyle("a") + mutedStyle(" accept   ") +
+		keyStyle("r") + mutedStyle(" reject   ") +
+		keyStyle("A/R") + mutedStyle(" whole file   ") +
+		keyStyle("n/p") + mutedStyle(" next/previous   ") +
+		keyStyle("s") + mutedStyle(" "+mode+"   ") +
+		keyStyle("enter") + mutedStyle(" apply   ") +
+		keyStyle("esc") + mutedStyle(" cancel")
+
+	body := lipgloss.PlaceVertical(
+		r.viewport.Height(),
+		lipgloss.Top,
+		r.viewport.View(),
+		styles.WhitespaceStyle(t.Background()),
+	)
+	return base.Width(width).PaddingLeft(1).Render(title) + "\n" +
+		base.Width(width).PaddingLeft(1).Render(warning) + "\n" +
+		body + "\n" +
+		base.Width(width).PaddingLeft(1).Render(help)
+}
+
+func (r *reviewDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewReviewDialog creates a full-screen review of the hunks, to accept or
+// reject each; applying it reverts the rejected ones. Hunks are accepted
+// until rejected.
+func NewReviewDialog(app *app.App, hunks []app.ReviewHunk) ReviewDialog {
+	dialog := &reviewDialog{
+		app:      app,
+		hunks:    hunks,
+		unified:  layout.Current.Viewport.Width < 120,
+		viewport: viewport.New(),
+	}
+	dialog.resize()
+	return dialog
+}
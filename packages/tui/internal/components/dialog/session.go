@@ -179,6 +179,14 @@ func (s *sessionDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					util.CmdHandler(modal.CloseModalMsg{}),
 					util.CmdHandler(app.SessionClearedMsg{}),
 				)
+			case "t":
+				if _, idx := s.list.GetSelectedItem(); idx >= 0 && idx < len(s.sessions) {
+					selectedSession := s.sessions[idx]
+					return s, tea.Sequence(
+						util.CmdHandler(modal.CloseModalMsg{}),
+						util.CmdHandler(app.OpenTabMsg{Session: &selectedSession}),
+					)
+				}
 			case "r":
 				if _, idx := s.list.GetSelectedItem(); idx >= 0 && idx < len(s.sessions) {
 					s.renameMode = true
@@ -255,7 +263,7 @@ func (s *sessionDialog) Render(background string) string {
 		Render
 	mutedStyle := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundPanel()).Render
 
-	leftHelp := keyStyle("n") + mutedStyle(" new   ") + keyStyle("r") + mutedStyle(" rename")
+	leftHelp := keyStyle("n") + mutedStyle(" new   ") + keyStyle("t") + mutedStyle(" new tab   ") + keyStyle("r") + mutedStyle(" rename")
 	rightHelp := keyStyle("x/del") + mutedStyle(" delete")
 
 	bgColor := t.BackgroundPanel()
@@ -0,0 +1,193 @@
+package dialog
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+// SnippetsDialog interface for the dialog managing the prompt snippets
+type SnippetsDialog interface {
+	layout.Modal
+}
+
+// SnippetSelectedMsg is sent when a snippet should be inserted in the prompt,
+// or replace it with its body as is when Raw, to edit it
+type SnippetSelectedMsg struct {
+	Snippet app.Snippet
+	Raw     bool
+}
+
+// snippetItem is a saved snippet
+type snippetItem struct {
+	snippet app.Snippet
+}
+
+func (s snippetItem) Render(selected bool, width int, baseStyle styles.Style) string {
+	description := s.snippet.Description
+	if description == "" {
+		description = strings.TrimSpace(s.snippet.Body)
+	}
+	return renderPermissionsRow(selected, width, baseStyle, "#"+s.snippet.Name, description, "")
+}
+
+func (s snippetItem) Selectable() bool {
+	return true
+}
+
+type snippetsDialog struct {
+	app   *app.App
+	modal *modal.Modal
+	list  list.List[list.Item]
+	name  textinput.Model
+	// prompt is the text of the prompt, saved as a snippet with n
+	prompt string
+}
+
+func (s *snippetsDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (s *snippetsDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.list.SetMaxWidth(layout.Current.Container.Width - 12)
+		s.name.SetWidth(layout.Current.Container.Width - 26)
+
+	case tea.KeyPressMsg:
+		if s.name.Focused() {
+			if msg.String() == "enter" {
+				name := strings.Join(strings.Fields(s.name.Value()), "-")
+				if name == "" {
+					return s, nil
+				}
+				s.app.State.SaveSnippet(app.Snippet{Name: name, Body: s.prompt})
+				s.name.Blur()
+				s.name.SetValue("")
+				s.refresh()
+				return s, s.app.SaveState()
+			}
+			var cmd tea.Cmd
+			s.name, cmd = s.name.Update(msg)
+			return s, cmd
+		}
+
+		switch msg.String() {
+		case "n":
+			if strings.TrimSpace(s.prompt) == "" {
+				return s, nil
+			}
+			return s, s.name.Focus()
+		}
+
+		item, idx := s.list.GetSelectedItem()
+		if idx >= 0 {
+			snippet := item.(snippetItem).snippet
+			switch msg.String() {
+			case "enter", "e":
+				return s, tea.Sequence(
+					util.CmdHandler(modal.CloseModalMsg{}),
+					util.CmdHandler(SnippetSelectedMsg{Snippet: snippet, Raw: msg.String() == "e"}),
+				)
+			case "d", "delete", "backspace":
+				s.app.State.RemoveSnippet(snippet.Name)
+				s.refresh()
+				s.list.SetSelectedIndex(min(idx, len(s.list.GetItems())-1))
+				return s, s.app.SaveState()
+			}
+		}
+	}
+
+	listModel, cmd := s.list.Update(msg)
+	s.list = listModel.(list.List[list.Item])
+	return s, cmd
+}
+
+// refresh lists the saved snippets
+func (s *snippetsDialog) refresh() {
+	var items []list.Item
+	for _, snippet := range s.app.State.Snippets {
+		items = append(items, snippetItem{snippet: snippet})
+	}
+	s.list.SetItems(items)
+}
+
+func (s *snippetsDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	keyStyle := styles.NewStyle().
+		Foreground(t.Text()).
+		Background(t.BackgroundPanel()).
+		Bold(true).
+		Render
+	mutedStyle := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundPanel()).Render
+
+	sections := []string{s.list.View()}
+	var help string
+	if s.name.Focused() {
+		sections = append(sections, "", styles.NewStyle().
+			PaddingLeft(1).
+			Render(keyStyle("name ")+s.name.View()))
+		help = keyStyle("enter") + mutedStyle(" save   ") + keyStyle("esc") + mutedStyle(" cancel")
+	} else {
+		if _, idx := s.list.GetSelectedItem(); idx >= 0 {
+			help = keyStyle("enter") + mutedStyle(" insert   ") +
+				keyStyle("e") + mutedStyle(" edit   ") +
+				keyStyle("d") + mutedStyle(" delete   ")
+		}
+		help += keyStyle("n") + mutedStyle(" save prompt")
+	}
+
+	sections = append(sections, styles.NewStyle().
+		Background(t.BackgroundPanel()).
+		Width(layout.Current.Container.Width-14).
+		PaddingLeft(1).
+		PaddingTop(1).
+		Render(help))
+
+	return s.modal.Render(strings.Join(sections, "\n"), background)
+}
+
+func (s *snippetsDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewSnippetsDialog creates a dialog listing the prompt snippets, to insert,
+// edit and delete them or save the prompt as one
+func NewSnippetsDialog(app *app.App, prompt string) SnippetsDialog {
+	name := newPathInput("", "Snippet name")
+	name.Blur()
+	name.SetWidth(layout.Current.Container.Width - 26)
+
+	listComponent := list.NewListComponent(
+		list.WithMaxVisibleHeight[list.Item](10),
+		list.WithRenderFunc(func(item list.Item, selected bool, width int, baseStyle styles.Style) string {
+			return item.Render(selected, width, baseStyle)
+		}),
+		list.WithSelectableFunc(func(item list.Item) bool {
+			return item.Selectable()
+		}),
+	)
+	listComponent.SetMaxWidth(layout.Current.Container.Width - 12)
+	listComponent.SetEmptyMessage(" No snippets, save the prompt as one with n")
+
+	dialog := &snippetsDialog{
+		app:    app,
+		list:   listComponent,
+		name:   name,
+		prompt: prompt,
+		modal: modal.New(
+			modal.WithTitle("Snippets"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+	dialog.refresh()
+	return dialog
+}
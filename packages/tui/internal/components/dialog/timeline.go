@@ -34,6 +34,13 @@ type RestoreToMessageMsg struct {
 	Index     int
 }
 
+// ForkFromMessageMsg is sent when a new session should be created with the
+// conversation up to and including a specific message
+type ForkFromMessageMsg struct {
+	MessageID string
+	Index     int
+}
+
 // timelineItem represents a user message in the timeline list
 type timelineItem struct {
 	messageID string
@@ -194,6 +201,14 @@ func (n *timelineDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					util.CmdHandler(modal.CloseModalMsg{}),
 				)
 			}
+		case "f":
+			// Fork the conversation at the selected message into a new session
+			if item, idx := n.list.GetSelectedItem(); idx >= 0 {
+				return n, tea.Sequence(
+					util.CmdHandler(modal.CloseModalMsg{}),
+					util.CmdHandler(ForkFromMessageMsg{MessageID: item.messageID, Index: item.index}),
+				)
+			}
 		case "enter":
 			// Keep Enter functionality for closing the modal
 			if _, idx := n.list.GetSelectedItem(); idx >= 0 {
@@ -226,7 +241,11 @@ func (n *timelineDialog) Render(background string) string {
 	) + keyStyle(
 		"r",
 	) + mutedStyle(
-		" restore",
+		" restore   ",
+	) + keyStyle(
+		"f",
+	) + mutedStyle(
+		" fork",
 	)
 
 	bgColor := t.BackgroundPanel()
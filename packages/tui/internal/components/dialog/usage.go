@@ -0,0 +1,399 @@
+package dialog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/muesli/reflow/truncate"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/usage"
+)
+
+// usageRanges are the periods the dashboard can cover, in days, 0 being all
+// time
+var usageRanges = []int{7, 30, 90, 0}
+
+// usageColumns are the headers and widths of the numeric columns
+var usageColumns = []struct {
+	title string
+	width int
+}{
+	{"Replies", 8}, {"Input", 7}, {"Output", 7}, {"Reason", 7}, {"Cache R", 8}, {"Cache W", 8}, {"Cost", 9},
+}
+
+var usageGroupingTitles = map[usage.Grouping]string{
+	usage.ByDay:      "Day",
+	usage.ByModel:    "Model",
+	usage.ByProvider: "Provider",
+	usage.ByAgent:    "Agent",
+}
+
+// UsageDialog interface for the usage and cost dashboard
+type UsageDialog interface {
+	layout.Modal
+}
+
+type usageLoadedMsg struct {
+	days   int
+	report *usage.Report
+	err    error
+}
+
+// usageRowItem is a row of the table
+type usageRowItem struct {
+	row usage.Row
+}
+
+func (u usageRowItem) Render(selected bool, width int, baseStyle styles.Style) string {
+	t := theme.CurrentTheme()
+	style := baseStyle.Background(t.BackgroundPanel()).Foreground(t.Text())
+	if selected {
+		style = baseStyle.Background(t.Primary()).Foreground(t.BackgroundElement())
+	}
+	values := []string{
+		fmt.Sprintf("%d", u.row.Messages),
+		usage.FormatTokens(u.row.Input),
+		usage.FormatTokens(u.row.Output),
+		usage.FormatTokens(u.row.Reasoning),
+		usage.FormatTokens(u.row.CacheRead),
+		usage.FormatTokens(u.row.CacheWrite),
+		fmt.Sprintf("$%.2f", u.row.Cost),
+	}
+	return style.Width(width).PaddingLeft(1).Render(renderUsageColumns(u.row.Key, values, width-1))
+}
+
+func (u usageRowItem) Selectable() bool {
+	return true
+}
+
+// renderUsageColumns lays the key out on the left and the values in right
+// aligned columns
+func renderUsageColumns(key string, values []string, width int) string {
+	numeric := 0
+	for _, column := range usageColumns {
+		numeric += column.width
+	}
+	keyWidth := max(8, width-numeric)
+	key = truncate.StringWithTail(key, uint(keyWidth-1), "…")
+
+	var b strings.Builder
+	b.WriteString(key + strings.Repeat(" ", max(0, keyWidth-lipgloss.Width(key))))
+	for i, value := range values {
+		b.WriteString(fmt.Sprintf("%*s", usageColumns[i].width, value))
+	}
+	return b.String()
+}
+
+type usageDialog struct {
+	app      *app.App
+	modal    *modal.Modal
+	list     list.List[list.Item]
+	report   *usage.Report
+	err      error
+	loading  bool
+	rangeIdx int
+	grouping int
+	// export is the path input shown while exporting to CSV
+	export *textinput.Model
+}
+
+func (u *usageDialog) Init() tea.Cmd {
+	return u.load()
+}
+
+// load collects the usage of the selected period
+func (u *usageDialog) load() tea.Cmd {
+	u.loading = true
+	days := usageRanges[u.rangeIdx]
+	since := time.Time{}
+	if days > 0 {
+		now := time.Now()
+		since = time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, now.Location())
+	}
+	return func() tea.Msg {
+		report, err := usage.Collect(context.Background(), u.app.Client, since)
+		return usageLoadedMsg{days: days, report: report, err: err}
+	}
+}
+
+func (u *usageDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case usageLoadedMsg:
+		if msg.days != usageRanges[u.rangeIdx] {
+			// A response for a period no longer shown
+			return u, nil
+		}
+		u.loading = false
+		u.report, u.err = msg.report, msg.err
+		if msg.err != nil {
+			slog.Error("Failed to collect usage", "error", msg.err)
+		}
+		u.refresh()
+		return u, nil
+
+	case tea.WindowSizeMsg:
+		u.list.SetMaxWidth(layout.Current.Container.Width - 12)
+
+	case tea.KeyPressMsg:
+		if u.export != nil {
+			switch msg.String() {
+			case "enter":
+				path := expandPath(strings.TrimSpace(u.export.Value()))
+				u.export = nil
+				if path == "" || u.report == nil {
+					return u, nil
+				}
+				return u, u.writeCSV(path)
+			}
+			var cmd tea.Cmd
+			*u.export, cmd = u.export.Update(msg)
+			return u, cmd
+		}
+
+		switch msg.String() {
+		case "tab", "shift+tab":
+			step := 1
+			if msg.String() == "shift+tab" {
+				step = len(usage.Groupings) - 1
+			}
+			u.grouping = (u.grouping + step) % len(usage.Groupings)
+			u.refresh()
+			return u, nil
+		case "r":
+			u.rangeIdx = (u.rangeIdx + 1) % len(usageRanges)
+			return u, u.load()
+		case "x":
+			if u.report == nil {
+				return u, nil
+			}
+			dir, _ := os.Getwd()
+			name := fmt.Sprintf("opencode-usage-%s.csv", time.Now().Format("2006-01-02"))
+			input := newPathInput(filepath.Join(dir, name), "Path to export the CSV to")
+			u.export = &input
+			return u, textinput.Blink
+		}
+	}
+
+	listModel, cmd := u.list.Update(msg)
+	u.list = listModel.(list.List[list.Item])
+	return u, cmd
+}
+
+// writeCSV exports the aggregated rows of the report
+func (u *usageDialog) writeCSV(path string) tea.Cmd {
+	report := u.report
+	return func() tea.Msg {
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		var file *os.File
+		if err == nil {
+			file, err = os.Create(path)
+		}
+		if err == nil {
+			err = usage.WriteCSV(file, report)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			slog.Error("Failed to export usage", "error", err)
+			return toast.NewErrorToast("Failed to export usage")()
+		}
+		return toast.NewSuccessToast("Usage exported to " + path)()
+	}
+}
+
+// refresh lists the rows of the selected grouping
+func (u *usageDialog) refresh() {
+	var items []list.Item
+	if u.report != nil {
+		for _, row := range u.report.Groups[usage.Groupings[u.grouping]] {
+			items = append(items, usageRowItem{row: row})
+		}
+	}
+	u.list.SetItems(items)
+}
+
+// days returns how many days the sparklines cover
+func (u *usageDialog) days() int {
+	if days := usageRanges[u.rangeIdx]; days > 0 {
+		return days
+	}
+	days := 1
+	for _, row := range u.report.Groups[usage.ByDay] {
+		if day, err := time.ParseInLocation("2006-01-02", row.Key, time.Local); err == nil {
+			days = max(days, int(time.Since(day).Hours()/24)+1)
+		}
+	}
+	return min(days, 365)
+}
+
+func (u *usageDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Container.Width - 14
+	bgColor := t.BackgroundPanel()
+	base := styles.NewStyle().Background(bgColor)
+	keyStyle := base.Foreground(t.Text()).Bold(true).Render
+	textStyle := base.Foreground(t.Text()).Render
+	mutedStyle := base.Foreground(t.TextMuted()).Render
+	sparkStyle := base.Foreground(t.Accent()).Render
+	selectedStyle := styles.NewStyle().
+		Foreground(t.BackgroundElement()).
+		Background(t.Primary()).
+		Padding(0, 1).
+		Render
+	tabStyle := base.Foreground(t.Text()).Padding(0, 1).Render
+	line := func(s string) string {
+		return base.Width(width).PaddingLeft(1).Render(s)
+	}
+
+	period := "All time"
+	if days := usageRanges[u.rangeIdx]; days > 0 {
+		period = fmt.Sprintf("Last %d days", days)
+	}
+
+	var sections []string
+	switch {
+	case u.report == nil && u.err != nil:
+		sections = append(sections, line(mutedStyle(period+" · ")+base.Foreground(t.Error()).Render("failed to collect usage")))
+	case u.report == nil:
+		sections = append(sections, line(mutedStyle(period+" · loading…")))
+	default:
+		report := u.report
+		status := ""
+		if u.loading {
+			status = " · loading…"
+		}
+		sections = append(sections,
+			line(keyStyle(period)+mutedStyle(fmt.Sprintf(
+				" · %d sessions · %d replies%s",
+				report.Sessions,
+				report.Total.Messages,
+				status,
+			))),
+			line(mutedStyle("Tokens ")+textStyle(fmt.Sprintf(
+				"%s in · %s out · %s reasoning · %s/%s cache read/write",
+				usage.FormatTokens(report.Total.Input),
+				usage.FormatTokens(report.Total.Output),
+				usage.FormatTokens(report.Total.Reasoning),
+				usage.FormatTokens(report.Total.CacheRead),
+				usage.FormatTokens(report.Total.CacheWrite),
+			))),
+			"",
+		)
+
+		// Daily trends of the whole period, and of the selected row
+		days := u.days()
+		now := time.Now()
+		sparkWidth := width - 24
+		trend := func(label string, daily []usage.Totals, tokens bool) string {
+			values := make([]float64, len(daily))
+			total := 0.0
+			for i, day := range daily {
+				values[i] = day.Cost
+				if tokens {
+					values[i] = day.Tokens()
+				}
+				total += values[i]
+			}
+			summary := fmt.Sprintf("$%.2f", total)
+			if tokens {
+				summary = usage.FormatTokens(total)
+			}
+			label = truncate.StringWithTail(label, 10, "…")
+			return line(
+				mutedStyle(fmt.Sprintf("%-11s", label)) +
+					sparkStyle(fmt.Sprintf("%-*s", sparkWidth, usage.Sparkline(values, sparkWidth))) +
+					textStyle(fmt.Sprintf("%10s", summary)),
+			)
+		}
+		daily := report.Daily(usage.ByDay, "", days, now)
+		sections = append(sections, trend("Cost", daily, false), trend("Tokens", daily, true))
+		grouping := usage.Groupings[u.grouping]
+		if item, idx := u.list.GetSelectedItem(); idx >= 0 && grouping != usage.ByDay {
+			key := item.(usageRowItem).row.Key
+			sections = append(sections, trend(key, report.Daily(grouping, key, days, now), false))
+		}
+	}
+
+	var tabs []string
+	for i, grouping := range usage.Groupings {
+		label := "By " + string(grouping)
+		if i == u.grouping {
+			tabs = append(tabs, selectedStyle(label))
+		} else {
+			tabs = append(tabs, tabStyle(label))
+		}
+	}
+	headers := make([]string, len(usageColumns))
+	for i, column := range usageColumns {
+		headers[i] = column.title
+	}
+	sections = append(sections,
+		"",
+		base.PaddingLeft(1).Render(strings.Join(tabs, mutedStyle(" "))),
+		"",
+		line(mutedStyle(renderUsageColumns(usageGroupingTitles[usage.Groupings[u.grouping]], headers, width-1))),
+	)
+	if u.report != nil && len(u.report.Groups[usage.ByDay]) == 0 {
+		sections = append(sections, line(mutedStyle("No replies in this period")))
+	} else {
+		sections = append(sections, u.list.View())
+	}
+
+	if u.export != nil {
+		sections = append(sections, "", base.PaddingLeft(1).Render(u.export.View()))
+	}
+
+	help := keyStyle("tab") + mutedStyle(" group   ") +
+		keyStyle("r") + mutedStyle(" period   ") +
+		keyStyle("x") + mutedStyle(" export csv")
+	if u.export != nil {
+		help = keyStyle("enter") + mutedStyle(" export")
+	}
+	sections = append(sections, base.Width(width).PaddingLeft(1).PaddingTop(1).Render(help))
+
+	return u.modal.Render(strings.Join(sections, "\n"), background)
+}
+
+func (u *usageDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewUsageDialog creates a dashboard of the tokens and cost of every session
+func NewUsageDialog(app *app.App) UsageDialog {
+	listComponent := list.NewListComponent(
+		list.WithMaxVisibleHeight[list.Item](10),
+		list.WithAlphaNumericKeys[list.Item](true),
+		list.WithRenderFunc(func(item list.Item, selected bool, width int, baseStyle styles.Style) string {
+			return item.Render(selected, width, baseStyle)
+		}),
+		list.WithSelectableFunc(func(item list.Item) bool {
+			return item.Selectable()
+		}),
+	)
+	listComponent.SetMaxWidth(layout.Current.Container.Width - 12)
+
+	return &usageDialog{
+		app:      app,
+		list:     listComponent,
+		rangeIdx: 1,
+		modal: modal.New(
+			modal.WithTitle("Usage"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+}
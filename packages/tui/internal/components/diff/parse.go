@@ -3,6 +3,8 @@ package diff
 import (
 	"bufio"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -56,3 +58,103 @@ func ParseStats(diff string) (map[string]DiffStats, error) {
 
 	return stats, nil
 }
+
+// hunkNewStart returns the 0-based index of the first line of the hunk in
+// the new file, read from its "@@ -a,b +c,d @@" header
+func hunkNewStart(header string) (int, error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0, fmt.Errorf("invalid hunk header %q", header)
+	}
+	start, count, _ := strings.Cut(fields[2][1:], ",")
+	line, err := strconv.Atoi(start)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hunk header %q", header)
+	}
+	// An empty range starts after the line it names
+	if count == "0" {
+		return line, nil
+	}
+	return line - 1, nil
+}
+
+// RevertHunks reverse-applies the hunks of a diff to the content of the new
+// file, restoring their lines of the old file. The hunks must be of the same
+// file; each is looked for at its position first and then anywhere in the
+// content, in case the lines moved since.
+func RevertHunks(content string, hunks []Hunk) (string, error) {
+	lines := strings.Split(content, "\n")
+	trailingNewline := len(lines) > 0 && lines[len(lines)-1] == ""
+	if trailingNewline {
+		lines = lines[:len(lines)-1]
+	}
+
+	type replacement struct {
+		start    int
+		newLines []string
+		oldLines []string
+	}
+	var replacements []replacement
+	for _, h := range hunks {
+		start, err := hunkNewStart(h.Header)
+		if err != nil {
+			return "", err
+		}
+		var r replacement
+		for _, line := range h.Lines {
+			text := line.Content
+			// Context lines keep their leading space when parsed
+			if line.Kind == LineContext {
+				text = strings.TrimPrefix(text, " ")
+			}
+			if line.Kind != LineAdded {
+				r.oldLines = append(r.oldLines, text)
+			}
+			if line.Kind != LineRemoved {
+				r.newLines = append(r.newLines, text)
+			}
+		}
+		r.start = findLines(lines, r.newLines, start)
+		if r.start < 0 {
+			return "", fmt.Errorf("hunk %s no longer matches the file", h.Header)
+		}
+		replacements = append(replacements, r)
+	}
+
+	// Replace from the end so that the positions of the others hold
+	slices.SortFunc(replacements, func(a, b replacement) int { return b.start - a.start })
+	for _, r := range replacements {
+		lines = slices.Replace(lines, r.start, r.start+len(r.newLines), r.oldLines...)
+	}
+
+	result := strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		result += "\n"
+	}
+	return result, nil
+}
+
+// findLines returns the index of the needle lines in the lines, preferring
+// the expected index, or -1 when they are not found
+func findLines(lines, needle []string, expected int) int {
+	matches := func(i int) bool {
+		return i >= 0 && i+len(needle) <= len(lines) && slices.Equal(lines[i:i+len(needle)], needle)
+	}
+	if matches(expected) {
+		return expected
+	}
+	best := -1
+	for i := 0; i+len(needle) <= len(lines); i++ {
+		if matches(i) && (best < 0 || abs(i-expected) < abs(best-expected)) {
+			best = i
+		}
+	}
+	return best
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,65 @@
+package diff
+
+import "testing"
+
+func TestRevertHunks(t *testing.T) {
+	patch := `--- a/main.go
++++ b/main.go
+@@ -1,4 +1,4 @@
+ package main
+-func old() {}
++func new() {}
+ 
+ // middle
+@@ -8,3 +8,4 @@
+ // end
+ func a() {}
++func b() {}
+ func c() {}
+`
+	content := "package main\nfunc new() {}\n\n// middle\n\n\n\n// end\nfunc a() {}\nfunc b() {}\nfunc c() {}\n"
+	result, err := ParseUnifiedDiff(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(result.Hunks))
+	}
+
+	reverted, err := RevertHunks(content, result.Hunks[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "package main\nfunc new() {}\n\n// middle\n\n\n\n// end\nfunc a() {}\nfunc c() {}\n"
+	if reverted != expected {
+		t.Errorf("expected the second hunk reverted, got %q", reverted)
+	}
+
+	// The first hunk is found even when lines were added above it since
+	reverted, err = RevertHunks("// header\n"+content, result.Hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "// header\npackage main\nfunc old() {}\n\n// middle\n\n\n\n// end\nfunc a() {}\nfunc c() {}\n"
+	if reverted != expected {
+		t.Errorf("expected both hunks reverted, got %q", reverted)
+	}
+
+	if _, err := RevertHunks("package main\n", result.Hunks[:1]); err == nil {
+		t.Error("expected an error for a hunk not matching the content")
+	}
+}
+
+func TestRevertHunksOfNewFile(t *testing.T) {
+	result, err := ParseUnifiedDiff("--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,2 @@\n+one\n+two\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reverted, err := RevertHunks("one\ntwo\n", result.Hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverted != "" {
+		t.Errorf("expected an empty file, got %q", reverted)
+	}
+}
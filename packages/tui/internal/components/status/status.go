@@ -1,6 +1,7 @@
 package status
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -10,6 +11,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/lipgloss/v2"
 	"github.com/charmbracelet/lipgloss/v2/compat"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/fsnotify/fsnotify"
 	"github.com/sst/opencode/internal/app"
 	"github.com/sst/opencode/internal/commands"
@@ -195,9 +197,60 @@ func (m *statusComponent) View() string {
 	)
 
 	blank := styles.NewStyle().Background(t.Background()).Width(m.width).Render("")
+	if len(m.app.Tabs) > 1 {
+		blank = m.tabs()
+	}
 	return blank + "\n" + status
 }
 
+// tabs renders the open session tabs, with a marker on the busy ones and the
+// count of unread replies on the others
+func (m *statusComponent) tabs() string {
+	t := theme.CurrentTheme()
+	const maxTitleWidth = 24
+
+	var tabs []string
+	for i, tab := range m.app.Tabs {
+		active := i == m.app.ActiveTab()
+		session, busy := tab.Session, tab.Busy()
+		if active {
+			session, busy = m.app.Session, m.app.IsBusy()
+		}
+
+		title := "New session"
+		if session != nil && session.Title != "" {
+			title = session.Title
+		}
+		title = ansi.Truncate(title, maxTitleWidth, "…")
+
+		style := styles.NewStyle().Background(t.Background()).Foreground(t.TextMuted())
+		if active {
+			style = styles.NewStyle().Background(t.BackgroundElement()).Foreground(t.Text()).Bold(true)
+		}
+		label := style.Render(fmt.Sprintf(" %d %s", i+1, title))
+		if busy {
+			label += style.Foreground(t.Accent()).Render(" ●")
+		}
+		if tab.Unread > 0 && !active {
+			label += style.Render(" ") + styles.NewStyle().
+				Background(t.Primary()).
+				Foreground(t.BackgroundPanel()).
+				Bold(true).
+				Render(fmt.Sprintf(" %d ", tab.Unread))
+		}
+		tabs = append(tabs, label+style.Render(" "))
+	}
+
+	strip := strings.Join(tabs, styles.NewStyle().Background(t.Background()).Render(" "))
+	strip = ansi.Truncate(strip, m.width, "")
+	return lipgloss.PlaceHorizontal(
+		m.width,
+		lipgloss.Left,
+		strip,
+		styles.WhitespaceStyle(t.Background()),
+	)
+}
+
 func (m *statusComponent) startGitWatcher() tea.Cmd {
 	cmd := util.CmdHandler(
 		GitBranchUpdatedMsg{Branch: getCurrentGitBranch(util.CwdPath)},
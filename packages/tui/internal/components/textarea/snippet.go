@@ -0,0 +1,152 @@
+package textarea
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// TabStop is a place to fill in after inserting a snippet, at a rune offset
+// of its text and spanning its default text.
+type TabStop struct {
+	Offset int
+	Length int
+}
+
+type tabStops struct {
+	// stops are the stops left to visit, as offsets in the value with a
+	// newline counting for one
+	stops []TabStop
+	// current is the stop the cursor moved to, its default text selected
+	// until a key is pressed
+	current  TabStop
+	selected bool
+	// length is the length of the value when the cursor moved to the current
+	// stop, to shift the next ones by what was typed there
+	length int
+}
+
+// InsertSnippet inserts the text at the cursor and moves the cursor to its
+// first tab-stop, the next ones being visited with [Model.NextTabStop].
+func (m *Model) InsertSnippet(text string, stops []TabStop) {
+	runes := []rune(text)
+	var bounds []int
+	for _, stop := range stops {
+		bounds = append(bounds, stop.Offset, stop.Offset+stop.Length)
+	}
+	slices.Sort(bounds)
+	bounds = slices.Compact(bounds)
+
+	// Insert the text piece by piece, since sanitizing it can change its
+	// length, and record where each bound ends up
+	offsets := make(map[int]int, len(bounds))
+	from := 0
+	for _, bound := range bounds {
+		to := clamp(bound, from, len(runes))
+		m.InsertRunesFromUserInput(runes[from:to])
+		offsets[bound] = m.offset(position{m.row, m.col})
+		from = to
+	}
+	m.InsertRunesFromUserInput(runes[from:])
+
+	m.tabStops = tabStops{length: m.valueLength()}
+	for _, stop := range stops {
+		start, end := offsets[stop.Offset], offsets[stop.Offset+stop.Length]
+		m.tabStops.stops = append(m.tabStops.stops, TabStop{Offset: start, Length: end - start})
+	}
+	m.NextTabStop()
+}
+
+// NextTabStop moves the cursor to the next tab-stop of the inserted snippet
+// and selects its default text, so that typing replaces it. It reports false
+// when there are no stops left.
+func (m *Model) NextTabStop() bool {
+	if len(m.tabStops.stops) == 0 {
+		return false
+	}
+	previous := m.tabStops.current
+	delta := m.valueLength() - m.tabStops.length
+	for i, stop := range m.tabStops.stops {
+		if stop.Offset >= previous.Offset+previous.Length {
+			m.tabStops.stops[i].Offset = max(0, stop.Offset+delta)
+		}
+	}
+
+	stop := m.tabStops.stops[0]
+	m.tabStops.stops = m.tabStops.stops[1:]
+	m.tabStops.current = stop
+	m.tabStops.selected = stop.Length > 0
+	m.tabStops.length = m.valueLength()
+	m.SetVimMode(VimInsert)
+	m.setCursor(m.positionAt(stop.Offset + stop.Length))
+	return true
+}
+
+// InTabStops reports whether tab-stops of the inserted snippet are left to
+// visit.
+func (m Model) InTabStops() bool {
+	return len(m.tabStops.stops) > 0
+}
+
+// replaceTabStop clears the selection of the default text of the current
+// tab-stop, removing the text when typing over it. It reports whether the key
+// was handled.
+func (m *Model) replaceTabStop(msg tea.KeyPressMsg) bool {
+	if !m.tabStops.selected {
+		return false
+	}
+	m.tabStops.selected = false
+	if m.VimMode() != VimInsert {
+		return false
+	}
+	deleting := key.Matches(msg, m.KeyMap.DeleteCharacterBackward, m.KeyMap.DeleteCharacterForward)
+	if msg.Text == "" && !deleting {
+		return false
+	}
+	stop := m.tabStops.current
+	start, end := m.positionAt(stop.Offset), m.positionAt(stop.Offset+stop.Length)
+	m.deleteRange(start, end)
+	m.setCursor(start)
+	return deleting
+}
+
+// tabStopSelected reports whether the item is part of the selected default
+// text of the current tab-stop
+func (m Model) tabStopSelected(row, col int) bool {
+	if !m.tabStops.selected || !m.focus {
+		return false
+	}
+	offset := m.offset(position{row, col})
+	stop := m.tabStops.current
+	return offset >= stop.Offset && offset < stop.Offset+stop.Length
+}
+
+// offset returns the offset of the position in the value, a newline counting
+// for one
+func (m *Model) offset(p position) int {
+	offset := p.col
+	for row := 0; row < p.row && row < len(m.value); row++ {
+		offset += len(m.value[row]) + 1
+	}
+	return offset
+}
+
+// positionAt returns the position at the offset in the value, clamped to its
+// end
+func (m *Model) positionAt(offset int) position {
+	for row := range m.value {
+		if offset <= len(m.value[row]) {
+			return position{row, max(0, offset)}
+		}
+		offset -= len(m.value[row]) + 1
+	}
+	last := len(m.value) - 1
+	return position{last, len(m.value[last])}
+}
+
+// valueLength returns the length of the value, a newline counting for one
+func (m *Model) valueLength() int {
+	last := len(m.value) - 1
+	return m.offset(position{last, len(m.value[last])})
+}
@@ -0,0 +1,66 @@
+package textarea
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+func TestInsertSnippet(t *testing.T) {
+	m := New()
+	m.Focus()
+	m.SetWidth(80)
+	m.InsertString("> ")
+	// "review {{file}} on\n\tbranch $1" with {{file}} left as a stop
+	m.InsertSnippet("review file on\n\tbranch ", []TabStop{
+		{Offset: 23},
+		{Offset: 7, Length: 4},
+		{Offset: 23},
+	})
+
+	if m.tabStops.selected {
+		t.Fatal("expected no default text to be selected at the first stop")
+	}
+	if got := m.Value(); got != "> review file on\n    branch " {
+		t.Fatalf("value = %q", got)
+	}
+	if m.row != 1 || m.col != 11 {
+		t.Fatalf("cursor = %d:%d, want 1:11", m.row, m.col)
+	}
+
+	m, _ = press(m, "main")
+	if !m.NextTabStop() {
+		t.Fatal("expected a second stop")
+	}
+	if !m.tabStops.selected || m.row != 0 || m.col != 13 {
+		t.Fatalf("cursor = %d:%d selected %v, want 0:13 selected", m.row, m.col, m.tabStops.selected)
+	}
+	m, _ = press(m, "main.go")
+	if got := m.Value(); got != "> review main.go on\n    branch main" {
+		t.Fatalf("value = %q", got)
+	}
+
+	if !m.InTabStops() || !m.NextTabStop() {
+		t.Fatal("expected a final stop")
+	}
+	if m.InTabStops() {
+		t.Fatal("expected no stops left")
+	}
+	if m.row != 1 || m.col != 15 {
+		t.Fatalf("cursor = %d:%d, want 1:15", m.row, m.col)
+	}
+}
+
+func TestTabStopBackspace(t *testing.T) {
+	m := New()
+	m.Focus()
+	m.InsertSnippet("fix name", []TabStop{{Offset: 4, Length: 4}, {Offset: 8}})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyBackspace})
+	if got := m.Value(); got != "fix " {
+		t.Fatalf("value = %q", got)
+	}
+	m, _ = press(m, "x")
+	if got := m.Value(); got != "fix x" {
+		t.Fatalf("value = %q", got)
+	}
+}
@@ -112,21 +112,30 @@ func (m Model) isAttachmentAtCursor() (*attachment.Attachment, int, int) {
 	return nil, -1, -1
 }
 
-// renderLineWithAttachments renders a line with proper attachment highlighting
+// renderLineWithAttachments renders a line with proper attachment highlighting.
+// The items start at the given column of the row, to highlight the visual
+// selection.
 func (m Model) renderLineWithAttachments(
 	items []any,
+	row, col int,
 	style lipgloss.Style,
 ) string {
 	var s strings.Builder
 	currentAttachment, _, _ := m.isAttachmentAtCursor()
 
-	for _, item := range items {
+	for i, item := range items {
 		switch val := item.(type) {
 		case rune:
+			if m.selected(row, col+i) {
+				s.WriteString(m.Styles.Selection.Render(string(val)))
+				continue
+			}
 			s.WriteString(style.Render(string(val)))
 		case *attachment.Attachment:
 			// Check if this is the attachment the cursor is currently on
-			if currentAttachment != nil && currentAttachment.ID == val.ID {
+			if m.selected(row, col+i) {
+				s.WriteString(m.Styles.Selection.Render(val.Display))
+			} else if currentAttachment != nil && currentAttachment.ID == val.ID {
 				// Cursor is on this attachment, highlight it
 				s.WriteString(m.Styles.SelectedAttachment.Render(val.Display))
 			} else {
@@ -137,6 +146,12 @@ func (m Model) renderLineWithAttachments(
 	return s.String()
 }
 
+// selected reports whether the item is part of the visual selection, or of
+// the default text of the current tab-stop
+func (m Model) selected(row, col int) bool {
+	return m.vimSelected(row, col) || m.tabStopSelected(row, col)
+}
+
 // getRuneAt safely gets a rune at a specific position, returns 0 if not a rune
 func getRuneAt(items []any, index int) rune {
 	if index < 0 || index >= len(items) {
@@ -362,6 +377,8 @@ type Styles struct {
 	Cursor             CursorStyle
 	Attachment         lipgloss.Style
 	SelectedAttachment lipgloss.Style
+	// Selection styles the visual selection of the Vim-style editing.
+	Selection lipgloss.Style
 }
 
 // StyleState that will be applied to the text area.
@@ -522,6 +539,13 @@ type Model struct {
 
 	// rune sanitizer for input.
 	rsan Sanitizer
+
+	// vim is the state of the Vim-style modal editing, see [Model.SetVim].
+	vim vimState
+
+	// tabStops are the stops of the snippet being filled in, see
+	// [Model.InsertSnippet].
+	tabStops tabStops
 }
 
 // New creates a new model with default settings.
@@ -1183,6 +1207,9 @@ func (m *Model) Reset() {
 	m.col = 0
 	m.row = 0
 	m.SetCursorColumn(0)
+	m.vim.undo = nil
+	m.vim.redo = nil
+	m.tabStops = tabStops{}
 }
 
 // san initializes or retrieves the rune sanitizer.
@@ -1583,6 +1610,13 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
+		if m.replaceTabStop(msg) {
+			break
+		}
+		if m.vim.enabled && (m.vim.mode != VimInsert || msg.String() == "esc") {
+			cmds = append(cmds, m.vimUpdate(msg))
+			break
+		}
 		switch {
 		case key.Matches(msg, m.KeyMap.DeleteAfterCursor):
 			m.col = clamp(m.col, 0, len(m.value[m.row]))
@@ -1722,6 +1756,7 @@ func (m Model) View() string {
 			style = styles.computedText()
 		}
 
+		start := 0
 		for wl, wrappedLine := range wrappedLines {
 			prompt := m.promptView(displayLine)
 			prompt = styles.computedPrompt().Render(prompt)
@@ -1765,6 +1800,7 @@ func (m Model) View() string {
 				s.WriteString(
 					m.renderLineWithAttachments(
 						wrappedLine[:lineInfo.ColumnOffset],
+						l, start,
 						style,
 					),
 				)
@@ -1786,15 +1822,20 @@ func (m Model) View() string {
 					}
 
 					// Render the part of the line after the cursor
-					s.WriteString(m.renderLineWithAttachments(wrappedLine[lineInfo.ColumnOffset+1:], style))
+					s.WriteString(m.renderLineWithAttachments(
+						wrappedLine[lineInfo.ColumnOffset+1:],
+						l, start+lineInfo.ColumnOffset+1,
+						style,
+					))
 				} else {
 					// Cursor is at the end of the line
 					m.virtualCursor.SetChar(" ")
 					s.WriteString(style.Render(m.virtualCursor.View()))
 				}
 			} else {
-				s.WriteString(m.renderLineWithAttachments(wrappedLine, style))
+				s.WriteString(m.renderLineWithAttachments(wrappedLine, l, start, style))
 			}
+			start += len(wrappedLine)
 
 			s.WriteString(style.Render(strings.Repeat(" ", max(0, padding))))
 			s.WriteRune('\n')
@@ -1984,6 +2025,13 @@ func (m Model) Cursor() *tea.Cursor {
 	c.Blink = m.Styles.Cursor.Blink
 	c.Color = m.Styles.Cursor.Color
 	c.Shape = m.Styles.Cursor.Shape
+	if m.vim.enabled {
+		// A bar while inserting, a block on the character otherwise
+		c.Shape = tea.CursorBlock
+		if m.vim.mode == VimInsert {
+			c.Shape = tea.CursorBar
+		}
+	}
 	return c
 }
 
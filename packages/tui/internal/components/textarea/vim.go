@@ -0,0 +1,1333 @@
+package textarea
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/attachment"
+	"github.com/sst/opencode/internal/clipboard"
+)
+
+// maxVimUndo is how many changes can be undone
+const maxVimUndo = 100
+
+// VimMode is the mode of the Vim-style modal editing.
+type VimMode int
+
+const (
+	VimInsert VimMode = iota
+	VimNormal
+	VimVisual
+	VimVisualLine
+)
+
+func (m VimMode) String() string {
+	switch m {
+	case VimNormal:
+		return "NORMAL"
+	case VimVisual:
+		return "VISUAL"
+	case VimVisualLine:
+		return "V-LINE"
+	default:
+		return "INSERT"
+	}
+}
+
+// readClipboard returns the text of the system clipboard, which backs the
+// unnamed and the + and * registers.
+var readClipboard = func() string {
+	return string(clipboard.Read(clipboard.FmtText))
+}
+
+// writeClipboard copies the text to the system clipboard, and through OSC52
+// for terminals that support it.
+func writeClipboard(text string) tea.Cmd {
+	return tea.Sequence(
+		func() tea.Msg {
+			clipboard.Write(clipboard.FmtText, []byte(text))
+			return nil
+		},
+		tea.SetClipboard(text),
+	)
+}
+
+// position is a place in the value. A column equal to the length of its row
+// is the end of the line.
+type position struct {
+	row, col int
+}
+
+func (p position) before(q position) bool {
+	return p.row < q.row || p.row == q.row && p.col < q.col
+}
+
+// register holds yanked or deleted text. Attachments are kept as they are, so
+// they are put back as attachments.
+type register struct {
+	lines    [][]any
+	linewise bool
+}
+
+func (r register) text() string {
+	lines := make([]string, len(r.lines))
+	for i, line := range r.lines {
+		lines[i] = interfacesToString(line)
+	}
+	text := strings.Join(lines, "\n")
+	if r.linewise {
+		text += "\n"
+	}
+	return text
+}
+
+type vimSnapshot struct {
+	value    [][]any
+	row, col int
+}
+
+type vimFind struct {
+	command rune
+	char    rune
+}
+
+// vimMotion is where a motion moves the cursor, and the text an operator
+// applied with it covers
+type vimMotion struct {
+	to        position
+	linewise  bool
+	inclusive bool
+}
+
+type vimState struct {
+	enabled bool
+	mode    VimMode
+	// count is the count being typed, opCount the one typed before the
+	// operator
+	count   string
+	opCount int
+	// operator is the pending d, c or y
+	operator rune
+	// awaiting is the command waiting for the next key, such as f, r, g or
+	// the i and a of a text object
+	awaiting rune
+	// register is the register selected with "
+	register  rune
+	registers map[rune]register
+	// anchor is where the visual selection started
+	anchor   position
+	lastFind vimFind
+	undo     []vimSnapshot
+	redo     []vimSnapshot
+}
+
+// SetVim enables or disables Vim-style modal editing. It starts in insert
+// mode.
+func (m *Model) SetVim(enabled bool) {
+	m.vim.enabled = enabled
+	m.vim.mode = VimInsert
+	m.vimReset()
+	m.vim.register = 0
+	if m.vim.registers == nil {
+		m.vim.registers = map[rune]register{}
+	}
+}
+
+// Vim reports whether Vim-style modal editing is enabled.
+func (m Model) Vim() bool {
+	return m.vim.enabled
+}
+
+// VimMode returns the current mode, insert when Vim-style editing is
+// disabled.
+func (m Model) VimMode() VimMode {
+	if !m.vim.enabled {
+		return VimInsert
+	}
+	return m.vim.mode
+}
+
+// SetVimMode switches to the given mode, when Vim-style editing is enabled.
+func (m *Model) SetVimMode(mode VimMode) {
+	if !m.vim.enabled {
+		return
+	}
+	m.vim.mode = mode
+	m.vim.anchor = position{m.row, m.col}
+	m.vimReset()
+	m.vim.register = 0
+	m.vimClamp()
+}
+
+// VimPending reports whether esc is handled by the textarea: to leave the
+// insert or visual mode, or to cancel a pending command.
+func (m Model) VimPending() bool {
+	if !m.vim.enabled {
+		return false
+	}
+	return m.vim.mode != VimNormal || m.vim.count != "" || m.vim.operator != 0 ||
+		m.vim.awaiting != 0 || m.vim.register != 0
+}
+
+// vimSelected reports whether the item is part of the visual selection
+func (m Model) vimSelected(row, col int) bool {
+	if !m.vim.enabled || !m.focus {
+		return false
+	}
+	start, end := m.vim.anchor, position{m.row, m.col}
+	if end.before(start) {
+		start, end = end, start
+	}
+	switch m.vim.mode {
+	case VimVisualLine:
+		return row >= start.row && row <= end.row
+	case VimVisual:
+		p := position{row, col}
+		return !p.before(start) && !end.before(p)
+	}
+	return false
+}
+
+// vimReset cancels the pending count and operator
+func (m *Model) vimReset() {
+	m.vim.count = ""
+	m.vim.opCount = 0
+	m.vim.operator = 0
+	m.vim.awaiting = 0
+}
+
+// takeCount returns the count typed, multiplied by the one typed before the
+// operator, and whether one was typed at all
+func (m *Model) takeCount() (int, bool) {
+	given := m.vim.count != "" || m.vim.opCount > 0
+	count, err := strconv.Atoi(m.vim.count)
+	if err != nil || count <= 0 {
+		count = 1
+	}
+	if m.vim.opCount > 0 {
+		count *= m.vim.opCount
+	}
+	m.vim.count = ""
+	m.vim.opCount = 0
+	return count, given
+}
+
+// vimClamp keeps the cursor on a character outside of insert mode
+func (m *Model) vimClamp() {
+	m.row = clamp(m.row, 0, len(m.value)-1)
+	limit := len(m.value[m.row])
+	if m.vim.mode != VimInsert && limit > 0 {
+		limit--
+	}
+	m.col = clamp(m.col, 0, limit)
+}
+
+func (m *Model) setCursor(p position) {
+	m.row = clamp(p.row, 0, len(m.value)-1)
+	m.SetCursorColumn(p.col)
+}
+
+// vimCheckpoint saves the value to be restored by undo, before a change
+func (m *Model) vimCheckpoint() {
+	m.vim.undo = append(m.vim.undo, m.vimSnapshot())
+	if len(m.vim.undo) > maxVimUndo {
+		m.vim.undo = m.vim.undo[len(m.vim.undo)-maxVimUndo:]
+	}
+	m.vim.redo = nil
+}
+
+func (m *Model) vimSnapshot() vimSnapshot {
+	value := make([][]any, len(m.value))
+	for i, row := range m.value {
+		value[i] = copyInterfaceSlice(row)
+	}
+	return vimSnapshot{value: value, row: m.row, col: m.col}
+}
+
+func (m *Model) vimRestore(snapshot vimSnapshot) {
+	m.value = snapshot.value
+	m.row = snapshot.row
+	m.SetCursorColumn(snapshot.col)
+}
+
+// vimUpdate handles a key press outside of insert mode, and the esc leaving
+// insert mode
+func (m *Model) vimUpdate(msg tea.KeyPressMsg) tea.Cmd {
+	key := msg.String()
+	if msg.Text != "" {
+		key = msg.Text
+	}
+
+	if key == "esc" {
+		if m.vim.mode == VimInsert && m.col > 0 {
+			m.SetCursorColumn(m.col - 1)
+		}
+		if m.vim.operator == 0 && m.vim.awaiting == 0 && m.vim.count == "" {
+			m.vim.mode = VimNormal
+		}
+		m.vimReset()
+		m.vim.register = 0
+		m.vimClamp()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	selected := false
+	if m.vim.awaiting != 0 {
+		awaiting := m.vim.awaiting
+		m.vim.awaiting = 0
+		cmd = m.vimAwaited(awaiting, key)
+		selected = awaiting == '"'
+	} else {
+		cmd = m.vimKey(key)
+	}
+	// The register only applies to the command it was selected for
+	if !selected && m.vim.count == "" && m.vim.operator == 0 && m.vim.awaiting == 0 {
+		m.vim.register = 0
+	}
+	if m.vim.mode != VimInsert {
+		m.vimClamp()
+	}
+	return cmd
+}
+
+// vimAwaited completes a command with the key it was waiting for
+func (m *Model) vimAwaited(awaiting rune, key string) tea.Cmd {
+	char := []rune(key)
+	if len(char) != 1 {
+		if key == "space" {
+			char = []rune{' '}
+		} else {
+			m.vimReset()
+			return nil
+		}
+	}
+
+	switch awaiting {
+	case '"':
+		r := char[0]
+		if unicode.IsLetter(r) && r < unicode.MaxASCII || strings.ContainsRune(`"+*_`, r) {
+			m.vim.register = r
+		}
+		return nil
+	case 'r':
+		count, _ := m.takeCount()
+		m.vimReplace(char[0], count)
+		return nil
+	case 'g':
+		if char[0] != 'g' {
+			m.vimReset()
+			return nil
+		}
+		count, given := m.takeCount()
+		row := 0
+		if given {
+			row = count - 1
+		}
+		return m.vimMove(m.lineMotion(row), true)
+	case 'f', 't', 'F', 'T':
+		m.vim.lastFind = vimFind{command: awaiting, char: char[0]}
+		count, _ := m.takeCount()
+		motion, ok := m.findMotion(m.vim.lastFind, count, false)
+		return m.vimMove(motion, ok)
+	case 'i', 'a':
+		start, end, ok := m.textObject(awaiting, char[0])
+		if !ok {
+			m.vimReset()
+			return nil
+		}
+		if m.vim.mode == VimVisual || m.vim.mode == VimVisualLine {
+			m.vim.mode = VimVisual
+			m.vim.anchor = start
+			m.setCursor(position{end.row, end.col - 1})
+			return nil
+		}
+		operator := m.vim.operator
+		m.vimReset()
+		return m.vimOperate(operator, start, end, false)
+	}
+	return nil
+}
+
+// vimKey handles a key that doesn't complete a pending command
+func (m *Model) vimKey(key string) tea.Cmd {
+	if len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (key != "0" || m.vim.count != "") {
+		m.vim.count += key
+		return nil
+	}
+
+	visual := m.vim.mode == VimVisual || m.vim.mode == VimVisualLine
+	switch key {
+	case "\"", "r":
+		if m.vim.operator == 0 && (key == "\"" || !visual) {
+			m.vim.awaiting = []rune(key)[0]
+			return nil
+		}
+	case "g", "f", "t", "F", "T":
+		m.vim.awaiting = []rune(key)[0]
+		return nil
+	}
+
+	if (key == "i" || key == "a") && (m.vim.operator != 0 || visual) {
+		m.vim.awaiting = []rune(key)[0]
+		return nil
+	}
+
+	if motion, ok, isMotion := m.motion(key); isMotion {
+		return m.vimMove(motion, ok)
+	}
+
+	if m.vim.operator != 0 {
+		operator := m.vim.operator
+		if key != string(operator) {
+			m.vimReset()
+			return nil
+		}
+		// dd, cc and yy apply to whole lines
+		count, _ := m.takeCount()
+		m.vimReset()
+		last := min(m.row+count-1, len(m.value)-1)
+		return m.vimOperate(operator, position{m.row, 0}, position{last, 0}, true)
+	}
+
+	if visual {
+		return m.visualKey(key)
+	}
+	return m.normalKey(key)
+}
+
+// vimMove moves the cursor with a motion, or applies the pending operator to
+// the text it covers
+func (m *Model) vimMove(motion vimMotion, ok bool) tea.Cmd {
+	operator := m.vim.operator
+	m.vimReset()
+	if !ok {
+		return nil
+	}
+	if operator == 0 {
+		m.setCursor(motion.to)
+		return nil
+	}
+
+	from := position{m.row, m.col}
+	if motion.linewise {
+		return m.vimOperate(operator, from, motion.to, true)
+	}
+	start, end := from, motion.to
+	if end.before(start) {
+		start, end = end, start
+	}
+	if motion.inclusive {
+		end.col = min(end.col+1, len(m.value[end.row]))
+	}
+	return m.vimOperate(operator, start, end, false)
+}
+
+// motion returns where a motion key moves the cursor. isMotion is false for
+// keys that aren't motions, ok is false when the motion fails.
+func (m *Model) motion(key string) (motion vimMotion, ok bool, isMotion bool) {
+	cursor := position{m.row, m.col}
+	row := m.value[m.row]
+	switch key {
+	case "h", "left", "backspace",
+		"l", "right", " ",
+		"j", "down", "k", "up",
+		"0", "home", "^", "$", "end",
+		"w", "W", "b", "B", "e", "E", "G", ";", ",":
+	default:
+		return vimMotion{}, false, false
+	}
+	count, given := m.takeCount()
+
+	switch key {
+	case "h", "left", "backspace":
+		return vimMotion{to: position{m.row, max(0, m.col-count)}}, m.col > 0, true
+	case "l", "right", " ":
+		return vimMotion{to: position{m.row, min(len(row), m.col+count)}}, m.col < len(row), true
+	case "j", "down":
+		to := min(len(m.value)-1, m.row+count)
+		return vimMotion{to: position{to, m.col}, linewise: true}, to != m.row, true
+	case "k", "up":
+		to := max(0, m.row-count)
+		return vimMotion{to: position{to, m.col}, linewise: true}, to != m.row, true
+	case "0", "home":
+		return vimMotion{to: position{m.row, 0}}, true, true
+	case "^":
+		return vimMotion{to: position{m.row, m.firstNonBlank(m.row)}}, true, true
+	case "$", "end":
+		to := min(len(m.value)-1, m.row+count-1)
+		return vimMotion{to: position{to, max(0, len(m.value[to])-1)}, inclusive: len(m.value[to]) > 0}, true, true
+	case "G":
+		to := len(m.value) - 1
+		if given {
+			to = count - 1
+		}
+		return m.lineMotion(to), true, true
+	case ";", ",":
+		if m.vim.lastFind.command == 0 {
+			return vimMotion{}, false, true
+		}
+		find := m.vim.lastFind
+		if key == "," {
+			find.command = map[rune]rune{'f': 'F', 'F': 'f', 't': 'T', 'T': 't'}[find.command]
+		}
+		motion, ok := m.findMotion(find, count, true)
+		return motion, ok, true
+	}
+
+	big := key == "W" || key == "B" || key == "E"
+	// cw changes up to the end of the word, like ce
+	changeWord := m.vim.operator == 'c' && m.classAt(cursor, big) != 0
+	to := cursor
+	for i := range count {
+		switch key {
+		case "w", "W":
+			if changeWord {
+				to = m.wordEnd(to, big, i == 0)
+			} else {
+				to = m.nextWordStart(to, big)
+			}
+		case "b", "B":
+			to = m.prevWordStart(to, big)
+		case "e", "E":
+			to = m.wordEnd(to, big, false)
+		}
+	}
+	switch {
+	case changeWord:
+		return vimMotion{to: to, inclusive: true}, true, true
+	case key == "w" || key == "W":
+		// An operator stops at the end of the line rather than at the start of
+		// the next one
+		if m.vim.operator != 0 && to.row > cursor.row {
+			to = position{to.row - 1, len(m.value[to.row-1])}
+		}
+		return vimMotion{to: to}, to != cursor || m.vim.operator != 0, true
+	case key == "e" || key == "E":
+		return vimMotion{to: to, inclusive: true}, to != cursor, true
+	}
+	return vimMotion{to: to}, to != cursor, true
+}
+
+// lineMotion moves to the first non-blank character of the row
+func (m *Model) lineMotion(row int) vimMotion {
+	row = clamp(row, 0, len(m.value)-1)
+	return vimMotion{to: position{row, m.firstNonBlank(row)}, linewise: true}
+}
+
+func (m *Model) firstNonBlank(row int) int {
+	for col := range m.value[row] {
+		if !isSpaceAt(m.value[row], col) {
+			return col
+		}
+	}
+	return max(0, len(m.value[row])-1)
+}
+
+// findMotion finds the count-th character on the current line with f, t, F
+// or T. Repeating a t or T doesn't stop before the character it stopped
+// before.
+func (m *Model) findMotion(find vimFind, count int, repeat bool) (vimMotion, bool) {
+	row := m.value[m.row]
+	col := m.col
+	forward := find.command == 'f' || find.command == 't'
+	for range count {
+		start := col
+		if repeat && find.command == 't' && getRuneAt(row, col+1) == find.char {
+			start++
+		}
+		if repeat && find.command == 'T' && getRuneAt(row, col-1) == find.char {
+			start--
+		}
+		found := -1
+		if forward {
+			for i := start + 1; i < len(row); i++ {
+				if getRuneAt(row, i) == find.char {
+					found = i
+					break
+				}
+			}
+		} else {
+			for i := start - 1; i >= 0; i-- {
+				if getRuneAt(row, i) == find.char {
+					found = i
+					break
+				}
+			}
+		}
+		if found < 0 {
+			return vimMotion{}, false
+		}
+		col = found
+	}
+	switch find.command {
+	case 't':
+		col--
+	case 'T':
+		col++
+	}
+	return vimMotion{to: position{m.row, col}, inclusive: forward}, true
+}
+
+// classAt returns the class of the item at the position: 0 for blanks and
+// line ends, 1 for word characters, 2 for punctuation and 3 for attachments.
+// Big words only tell blanks from the rest.
+func (m *Model) classAt(p position, big bool) int {
+	if p.row >= len(m.value) || p.col >= len(m.value[p.row]) {
+		return 0
+	}
+	switch item := m.value[p.row][p.col].(type) {
+	case *attachment.Attachment:
+		return 3
+	case rune:
+		switch {
+		case unicode.IsSpace(item):
+			return 0
+		case big || unicode.IsLetter(item) || unicode.IsDigit(item) || item == '_':
+			return 1
+		}
+	}
+	return 2
+}
+
+// sameWord reports whether the items belong to the same word. Every
+// attachment is a word of its own.
+func (m *Model) sameWord(p, q position, big bool) bool {
+	if p.row != q.row {
+		return false
+	}
+	class := m.classAt(p, big)
+	return class != 0 && class != 3 && class == m.classAt(q, big)
+}
+
+// advance returns the position after p, line ends included
+func (m *Model) advance(p position) (position, bool) {
+	if p.col < len(m.value[p.row]) {
+		return position{p.row, p.col + 1}, true
+	}
+	if p.row < len(m.value)-1 {
+		return position{p.row + 1, 0}, true
+	}
+	return p, false
+}
+
+// retreat returns the position before p, line ends included
+func (m *Model) retreat(p position) (position, bool) {
+	if p.col > 0 {
+		return position{p.row, p.col - 1}, true
+	}
+	if p.row > 0 {
+		return position{p.row - 1, len(m.value[p.row-1])}, true
+	}
+	return p, false
+}
+
+func (m *Model) nextWordStart(p position, big bool) position {
+	q := p
+	for m.classAt(q, big) != 0 {
+		next, ok := m.advance(q)
+		if !ok {
+			return next
+		}
+		same := m.sameWord(q, next, big)
+		q = next
+		if !same {
+			break
+		}
+	}
+	// Empty lines count as words
+	for m.classAt(q, big) == 0 && (len(m.value[q.row]) > 0 || q.row == p.row) {
+		next, ok := m.advance(q)
+		if !ok {
+			return q
+		}
+		q = next
+	}
+	return q
+}
+
+func (m *Model) prevWordStart(p position, big bool) position {
+	q := p
+	for {
+		prev, ok := m.retreat(q)
+		if !ok {
+			return q
+		}
+		q = prev
+		if m.classAt(q, big) != 0 || len(m.value[q.row]) == 0 {
+			break
+		}
+	}
+	for q.col > 0 && m.sameWord(position{q.row, q.col - 1}, q, big) {
+		q.col--
+	}
+	return q
+}
+
+// wordEnd returns the end of the word after p, or the end of the word at p
+// when current is set
+func (m *Model) wordEnd(p position, big bool, current bool) position {
+	q := p
+	if !current {
+		next, ok := m.advance(q)
+		if !ok {
+			return p
+		}
+		q = next
+		for m.classAt(q, big) == 0 {
+			next, ok := m.advance(q)
+			if !ok {
+				return p
+			}
+			q = next
+		}
+	}
+	for next := (position{q.row, q.col + 1}); m.sameWord(q, next, big); next.col++ {
+		q = next
+	}
+	return q
+}
+
+// textObject returns the start and the end, exclusive, of the text object
+// under the cursor
+func (m *Model) textObject(kind rune, object rune) (position, position, bool) {
+	row := m.value[m.row]
+	switch object {
+	case 'w', 'W':
+		if len(row) == 0 {
+			return position{}, position{}, false
+		}
+		big := object == 'W'
+		cursor := position{m.row, min(m.col, len(row)-1)}
+		start, end := cursor, cursor
+		class := m.classAt(cursor, big)
+		same := func(p, q position) bool {
+			if class == 0 {
+				return m.classAt(q, big) == 0 && q.col < len(row)
+			}
+			return m.sameWord(p, q, big)
+		}
+		for start.col > 0 && same(start, position{m.row, start.col - 1}) {
+			start.col--
+		}
+		for end.col < len(row)-1 && same(end, position{m.row, end.col + 1}) {
+			end.col++
+		}
+		end.col++
+		if kind == 'a' {
+			// aw takes the blanks after the word, or those before it
+			trailing := end
+			for trailing.col < len(row) && isSpaceAt(row, trailing.col) {
+				trailing.col++
+			}
+			if trailing != end || class == 0 {
+				end = trailing
+			} else {
+				for start.col > 0 && isSpaceAt(row, start.col-1) {
+					start.col--
+				}
+			}
+		}
+		return start, end, true
+	case '"', '\'', '`':
+		return m.quoteObject(kind, object)
+	case '(', ')', 'b':
+		return m.bracketObject(kind, '(', ')')
+	case '[', ']':
+		return m.bracketObject(kind, '[', ']')
+	case '{', '}', 'B':
+		return m.bracketObject(kind, '{', '}')
+	case '<', '>':
+		return m.bracketObject(kind, '<', '>')
+	}
+	return position{}, position{}, false
+}
+
+// quoteObject pairs the quotes of the current line from its start, and returns
+// the quoted text around the cursor, or the next one after it
+func (m *Model) quoteObject(kind rune, quote rune) (position, position, bool) {
+	row := m.value[m.row]
+	var quotes []int
+	for i := range row {
+		if getRuneAt(row, i) == quote && getRuneAt(row, i-1) != '\\' {
+			quotes = append(quotes, i)
+		}
+	}
+	for i := 0; i+1 < len(quotes); i += 2 {
+		open, close := quotes[i], quotes[i+1]
+		if m.col > close {
+			continue
+		}
+		if kind == 'i' {
+			return position{m.row, open + 1}, position{m.row, close}, true
+		}
+		return position{m.row, open}, position{m.row, close + 1}, true
+	}
+	return position{}, position{}, false
+}
+
+// bracketObject returns the text between the brackets around the cursor
+func (m *Model) bracketObject(kind rune, open rune, close rune) (position, position, bool) {
+	runeAt := func(p position) rune {
+		return getRuneAt(m.value[p.row], p.col)
+	}
+
+	cursor := position{m.row, m.col}
+	start := cursor
+	if runeAt(cursor) != open {
+		depth := 0
+		for {
+			prev, ok := m.retreat(start)
+			if !ok {
+				return position{}, position{}, false
+			}
+			start = prev
+			if r := runeAt(start); r == close {
+				depth++
+			} else if r == open {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+		}
+	}
+
+	end := start
+	depth := 0
+	for {
+		next, ok := m.advance(end)
+		if !ok {
+			return position{}, position{}, false
+		}
+		end = next
+		if r := runeAt(end); r == open {
+			depth++
+		} else if r == close {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+	}
+
+	if kind == 'a' {
+		end.col++
+		return start, end, true
+	}
+	start, _ = m.advance(start)
+	return start, end, true
+}
+
+// normalKey handles the commands of normal mode
+func (m *Model) normalKey(key string) tea.Cmd {
+	switch key {
+	case "d", "c", "y":
+		if count, given := m.takeCount(); given {
+			m.vim.opCount = count
+		}
+		m.vim.operator = []rune(key)[0]
+		return nil
+	}
+
+	count, _ := m.takeCount()
+	row := m.value[m.row]
+	switch key {
+	case "i", "insert":
+		m.vimInsert(m.col)
+	case "a":
+		m.vimInsert(min(m.col+1, len(row)))
+	case "I":
+		m.vimInsert(m.firstNonBlank(m.row))
+		if len(row) > 0 && isSpaceAt(row, m.col) {
+			m.CursorEnd()
+		}
+	case "A":
+		m.vimInsert(len(row))
+	case "o", "O":
+		m.vimCheckpoint()
+		at := m.row + 1
+		if key == "O" {
+			at = m.row
+		}
+		m.replaceLines(at, at-1, [][]any{{}})
+		m.setCursor(position{at, 0})
+		m.vim.mode = VimInsert
+	case "v":
+		m.vim.mode = VimVisual
+		m.vim.anchor = position{m.row, m.col}
+	case "V":
+		m.vim.mode = VimVisualLine
+		m.vim.anchor = position{m.row, m.col}
+	case "x", "delete", "X", "s":
+		if len(row) == 0 || key == "X" && m.col == 0 {
+			if key == "s" {
+				m.vimInsert(m.col)
+			}
+			return nil
+		}
+		start, end := position{m.row, m.col}, position{m.row, min(len(row), m.col+count)}
+		if key == "X" {
+			start, end = position{m.row, max(0, m.col-count)}, position{m.row, m.col}
+		}
+		operator := 'd'
+		if key == "s" {
+			operator = 'c'
+		}
+		return m.vimOperate(operator, start, end, false)
+	case "D", "C":
+		last := min(len(m.value)-1, m.row+count-1)
+		operator := 'd'
+		if key == "C" {
+			operator = 'c'
+		}
+		return m.vimOperate(operator, position{m.row, m.col}, position{last, len(m.value[last])}, false)
+	case "S":
+		last := min(len(m.value)-1, m.row+count-1)
+		return m.vimOperate('c', position{m.row, 0}, position{last, 0}, true)
+	case "Y":
+		last := min(len(m.value)-1, m.row+count-1)
+		return m.vimOperate('y', position{m.row, 0}, position{last, 0}, true)
+	case "p", "P":
+		reg, ok := m.vimLoad()
+		if !ok {
+			return nil
+		}
+		m.vimCheckpoint()
+		m.vimPut(reg, key == "p", count)
+	case "J":
+		if m.row >= len(m.value)-1 {
+			return nil
+		}
+		m.vimCheckpoint()
+		m.vimJoin(max(1, count-1))
+	case "~":
+		if len(row) == 0 {
+			return nil
+		}
+		m.vimCheckpoint()
+		end := min(len(row), m.col+count)
+		m.mapRunes(position{m.row, m.col}, position{m.row, end}, toggleCase)
+		m.SetCursorColumn(end)
+	case "u":
+		for range count {
+			if len(m.vim.undo) == 0 {
+				break
+			}
+			m.vim.redo = append(m.vim.redo, m.vimSnapshot())
+			m.vimRestore(m.vim.undo[len(m.vim.undo)-1])
+			m.vim.undo = m.vim.undo[:len(m.vim.undo)-1]
+		}
+	case "ctrl+r":
+		for range count {
+			if len(m.vim.redo) == 0 {
+				break
+			}
+			m.vim.undo = append(m.vim.undo, m.vimSnapshot())
+			m.vimRestore(m.vim.redo[len(m.vim.redo)-1])
+			m.vim.redo = m.vim.redo[:len(m.vim.redo)-1]
+		}
+	}
+	return nil
+}
+
+// visualKey handles the commands of visual mode
+func (m *Model) visualKey(key string) tea.Cmd {
+	m.takeCount()
+	start, end, linewise := m.visualRange()
+
+	switch key {
+	case "v", "V":
+		mode := map[string]VimMode{"v": VimVisual, "V": VimVisualLine}[key]
+		if m.vim.mode == mode {
+			m.vim.mode = VimNormal
+		} else {
+			m.vim.mode = mode
+		}
+	case "o":
+		m.vim.anchor, m.row, m.col = position{m.row, m.col}, m.vim.anchor.row, m.vim.anchor.col
+	case "d", "x", "delete", "X", "D":
+		m.vim.mode = VimNormal
+		return m.vimOperate('d', start, end, linewise || key == "X" || key == "D")
+	case "c", "s", "S", "C":
+		m.vim.mode = VimNormal
+		return m.vimOperate('c', start, end, linewise || key == "S" || key == "C")
+	case "y", "Y":
+		m.vim.mode = VimNormal
+		return m.vimOperate('y', start, end, linewise || key == "Y")
+	case "p", "P":
+		reg, ok := m.vimLoad()
+		if !ok {
+			return nil
+		}
+		m.vimCheckpoint()
+		m.vim.mode = VimNormal
+		switch {
+		case linewise && reg.linewise:
+			m.replaceLines(start.row, end.row, reg.lines)
+			m.setCursor(position{start.row, m.firstNonBlank(start.row)})
+			return nil
+		case linewise:
+			m.replaceLines(start.row, end.row, [][]any{{}})
+			m.setCursor(position{start.row, 0})
+		default:
+			m.deleteRange(start, end)
+			m.setCursor(start)
+		}
+		m.vimPut(reg, false, 1)
+	case "J":
+		m.vim.mode = VimNormal
+		if start.row == end.row && start.row >= len(m.value)-1 {
+			return nil
+		}
+		m.vimCheckpoint()
+		m.setCursor(start)
+		m.vimJoin(max(1, end.row-start.row))
+	case "~", "u", "U":
+		m.vim.mode = VimNormal
+		m.vimCheckpoint()
+		if linewise {
+			start.col = 0
+			end = position{end.row, len(m.value[end.row])}
+		}
+		fn := map[string]func(rune) rune{"~": toggleCase, "u": unicode.ToLower, "U": unicode.ToUpper}[key]
+		m.mapRunes(start, end, fn)
+		m.setCursor(start)
+	}
+	return nil
+}
+
+// visualRange returns the visual selection from start to end, exclusive, or
+// its rows from start to end when linewise
+func (m *Model) visualRange() (start position, end position, linewise bool) {
+	start, end = m.vim.anchor, position{m.row, m.col}
+	if end.before(start) {
+		start, end = end, start
+	}
+	linewise = m.vim.mode == VimVisualLine
+	if !linewise {
+		end.col = min(end.col+1, len(m.value[end.row]))
+	}
+	return start, end, linewise
+}
+
+// CutVimSelection removes the visual selection and returns its text, leaving
+// the cursor in insert mode where it was, so that a snippet can take its
+// place. It reports false when nothing is selected.
+func (m *Model) CutVimSelection() (string, bool) {
+	if !m.vim.enabled || (m.vim.mode != VimVisual && m.vim.mode != VimVisualLine) {
+		return "", false
+	}
+	start, end, linewise := m.visualRange()
+	m.vimCheckpoint()
+	var reg register
+	if linewise {
+		reg.lines = m.lines(start.row, end.row)
+		m.replaceLines(start.row, end.row, [][]any{{}})
+		start.col = 0
+	} else {
+		reg.lines = m.items(start, end)
+		m.deleteRange(start, end)
+	}
+	m.vim.mode = VimInsert
+	m.setCursor(start)
+	return reg.text(), true
+}
+
+// vimInsert enters insert mode at the column of the current row
+func (m *Model) vimInsert(col int) {
+	m.vimCheckpoint()
+	m.vim.mode = VimInsert
+	m.SetCursorColumn(col)
+}
+
+// vimOperate applies an operator to the text from start to end, exclusive,
+// or to the rows from start to end when linewise
+func (m *Model) vimOperate(operator rune, start position, end position, linewise bool) tea.Cmd {
+	if linewise && end.row < start.row {
+		start, end = end, start
+	}
+
+	if linewise {
+		reg := register{lines: m.lines(start.row, end.row), linewise: true}
+		switch operator {
+		case 'y':
+			m.setCursor(position{start.row, m.col})
+		case 'd':
+			m.vimCheckpoint()
+			m.replaceLines(start.row, end.row, nil)
+			row := min(start.row, len(m.value)-1)
+			m.setCursor(position{row, m.firstNonBlank(row)})
+		case 'c':
+			m.vimCheckpoint()
+			m.replaceLines(start.row, end.row, [][]any{{}})
+			m.setCursor(position{start.row, 0})
+			m.vim.mode = VimInsert
+		}
+		return m.vimStore(reg)
+	}
+
+	if !start.before(end) && operator != 'c' {
+		return nil
+	}
+	reg := register{lines: m.items(start, end)}
+	switch operator {
+	case 'y':
+		m.setCursor(start)
+	case 'd':
+		m.vimCheckpoint()
+		m.deleteRange(start, end)
+		m.setCursor(start)
+	case 'c':
+		m.vimCheckpoint()
+		m.deleteRange(start, end)
+		m.setCursor(start)
+		m.vim.mode = VimInsert
+	}
+	return m.vimStore(reg)
+}
+
+// vimStore saves yanked or deleted text in the selected register. The unnamed
+// register is the system clipboard.
+func (m *Model) vimStore(reg register) tea.Cmd {
+	name := m.vim.register
+	m.vim.register = 0
+	if m.vim.registers == nil {
+		m.vim.registers = map[rune]register{}
+	}
+
+	switch {
+	case name == '_':
+		return nil
+	case name >= 'a' && name <= 'z':
+		m.vim.registers[name] = reg
+	case name >= 'A' && name <= 'Z':
+		// Uppercase registers append to the lowercase ones
+		name = unicode.ToLower(name)
+		previous, ok := m.vim.registers[name]
+		if ok {
+			if previous.linewise || reg.linewise {
+				previous.lines = append(previous.lines, reg.lines...)
+				previous.linewise = true
+			} else {
+				last := len(previous.lines) - 1
+				previous.lines[last] = append(copyInterfaceSlice(previous.lines[last]), reg.lines[0]...)
+				previous.lines = append(previous.lines, reg.lines[1:]...)
+			}
+			reg = previous
+		}
+		m.vim.registers[name] = reg
+	}
+	m.vim.registers['"'] = reg
+	if name == 0 || name == '"' || name == '+' || name == '*' {
+		return writeClipboard(reg.text())
+	}
+	return nil
+}
+
+// vimLoad returns the content of the selected register. The unnamed register
+// takes the system clipboard when it holds other text.
+func (m *Model) vimLoad() (register, bool) {
+	name := unicode.ToLower(m.vim.register)
+	m.vim.register = 0
+	if name >= 'a' && name <= 'z' {
+		reg, ok := m.vim.registers[name]
+		return reg, ok
+	}
+	if name == '_' {
+		return register{}, false
+	}
+
+	reg, ok := m.vim.registers['"']
+	if text := readClipboard(); text != "" && (!ok || text != reg.text()) {
+		reg = register{linewise: strings.HasSuffix(text, "\n")}
+		text = strings.TrimSuffix(text, "\n")
+		for _, line := range strings.Split(string(m.san().Sanitize([]rune(text))), "\n") {
+			reg.lines = append(reg.lines, runesToInterfaces([]rune(line)))
+		}
+		ok = true
+	}
+	return reg, ok
+}
+
+// vimPut puts the register after or before the cursor, count times
+func (m *Model) vimPut(reg register, after bool, count int) {
+	if len(reg.lines) == 0 {
+		return
+	}
+	if reg.linewise {
+		lines := make([][]any, 0, len(reg.lines)*count)
+		for range count {
+			lines = append(lines, reg.lines...)
+		}
+		at := m.row
+		if after {
+			at++
+		}
+		m.replaceLines(at, at-1, lines)
+		m.setCursor(position{at, m.firstNonBlank(at)})
+		return
+	}
+
+	lines := reg.lines
+	for range count - 1 {
+		joined := make([][]any, 0, len(lines)+len(reg.lines)-1)
+		joined = append(joined, lines[:len(lines)-1]...)
+		joined = append(joined, append(copyInterfaceSlice(lines[len(lines)-1]), reg.lines[0]...))
+		joined = append(joined, reg.lines[1:]...)
+		lines = joined
+	}
+	at := position{m.row, m.col}
+	if after && len(m.value[m.row]) > 0 {
+		at.col++
+	}
+	end := m.insertItems(at, lines)
+	m.setCursor(position{end.row, max(0, end.col-1)})
+}
+
+// vimReplace replaces count characters with r. Attachments are not replaced.
+func (m *Model) vimReplace(r rune, count int) {
+	row := m.value[m.row]
+	if m.col+count > len(row) {
+		return
+	}
+	for i := m.col; i < m.col+count; i++ {
+		if _, ok := row[i].(rune); !ok {
+			return
+		}
+	}
+	m.vimCheckpoint()
+	for i := m.col; i < m.col+count; i++ {
+		setRuneAt(row, i, r)
+	}
+	m.SetCursorColumn(m.col + count - 1)
+}
+
+// vimJoin joins the current row with the count rows below, separated by a
+// space
+func (m *Model) vimJoin(count int) {
+	for range count {
+		if m.row >= len(m.value)-1 {
+			return
+		}
+		current, next := m.value[m.row], m.value[m.row+1]
+		skip := 0
+		for skip < len(next) && isSpaceAt(next, skip) {
+			skip++
+		}
+		joined := copyInterfaceSlice(current)
+		col := len(joined)
+		if len(joined) > 0 && skip < len(next) && !isSpaceAt(joined, len(joined)-1) {
+			joined = append(joined, ' ')
+		}
+		joined = append(joined, next[skip:]...)
+		m.value[m.row] = joined
+		m.replaceLines(m.row+1, m.row+1, nil)
+		m.SetCursorColumn(col)
+	}
+}
+
+// items copies the items from start to end, exclusive, a slice per row
+func (m *Model) items(start position, end position) [][]any {
+	var lines [][]any
+	for row := start.row; row <= end.row; row++ {
+		from, to := 0, len(m.value[row])
+		if row == start.row {
+			from = start.col
+		}
+		if row == end.row {
+			to = end.col
+		}
+		lines = append(lines, copyInterfaceSlice(m.value[row][from:max(from, to)]))
+	}
+	return lines
+}
+
+// lines copies the rows from first to last
+func (m *Model) lines(first int, last int) [][]any {
+	lines := make([][]any, 0, last-first+1)
+	for row := first; row <= last; row++ {
+		lines = append(lines, copyInterfaceSlice(m.value[row]))
+	}
+	return lines
+}
+
+// deleteRange removes the items from start to end, exclusive, joining their
+// rows
+func (m *Model) deleteRange(start position, end position) {
+	joined := copyInterfaceSlice(m.value[start.row][:start.col])
+	joined = append(joined, m.value[end.row][end.col:]...)
+	value := make([][]any, 0, len(m.value)-(end.row-start.row))
+	value = append(value, m.value[:start.row]...)
+	value = append(value, joined)
+	value = append(value, m.value[end.row+1:]...)
+	m.value = value
+}
+
+// replaceLines replaces the rows from first to last with copies of the lines,
+// leaving an empty row when none remains. Lines are inserted before first when
+// last is first-1.
+func (m *Model) replaceLines(first int, last int, lines [][]any) {
+	value := make([][]any, 0, len(m.value)-(last-first+1)+len(lines)+1)
+	value = append(value, m.value[:first]...)
+	for _, line := range lines {
+		value = append(value, copyInterfaceSlice(line))
+	}
+	value = append(value, m.value[last+1:]...)
+	if len(value) == 0 {
+		value = append(value, []any{})
+	}
+	m.value = value
+}
+
+// insertItems inserts the lines at the position, and returns the position
+// after them
+func (m *Model) insertItems(at position, lines [][]any) position {
+	row := m.value[at.row]
+	head := copyInterfaceSlice(row[:at.col])
+	tail := copyInterfaceSlice(row[at.col:])
+
+	rows := make([][]any, len(lines))
+	for i, line := range lines {
+		rows[i] = copyInterfaceSlice(line)
+	}
+	last := len(rows) - 1
+	end := position{at.row + last, len(rows[last])}
+	if last == 0 {
+		end.col += len(head)
+	}
+	rows[0] = append(head, rows[0]...)
+	rows[last] = append(rows[last], tail...)
+
+	value := make([][]any, 0, len(m.value)+last)
+	value = append(value, m.value[:at.row]...)
+	value = append(value, rows...)
+	value = append(value, m.value[at.row+1:]...)
+	m.value = value
+	return end
+}
+
+// mapRunes maps the runes from start to end, exclusive
+func (m *Model) mapRunes(start position, end position, fn func(rune) rune) {
+	for row := start.row; row <= end.row; row++ {
+		from, to := 0, len(m.value[row])
+		if row == start.row {
+			from = start.col
+		}
+		if row == end.row {
+			to = min(to, end.col)
+		}
+		for col := from; col < to; col++ {
+			setRuneAt(m.value[row], col, fn(getRuneAt(m.value[row], col)))
+		}
+	}
+}
+
+func toggleCase(r rune) rune {
+	if unicode.IsUpper(r) {
+		return unicode.ToLower(r)
+	}
+	return unicode.ToUpper(r)
+}
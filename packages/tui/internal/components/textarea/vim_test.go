@@ -0,0 +1,302 @@
+package textarea
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/attachment"
+)
+
+// newVim returns a focused textarea in normal mode holding the value, with
+// the cursor on its last character
+func newVim(t *testing.T, value string) Model {
+	t.Helper()
+	clipboardText := ""
+	read := readClipboard
+	readClipboard = func() string { return clipboardText }
+	t.Cleanup(func() { readClipboard = read })
+
+	m := New()
+	m.Focus()
+	m.SetVim(true)
+	m.InsertString(value)
+	m, _ = press(m, "esc")
+	return m
+}
+
+// press sends the keys to the textarea, a key per character but for esc and
+// ctrl+r. It returns the commands of the last key.
+func press(m Model, keys ...string) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, keys := range keys {
+		var msgs []tea.KeyPressMsg
+		switch keys {
+		case "esc":
+			msgs = append(msgs, tea.KeyPressMsg{Code: tea.KeyEscape})
+		case "ctrl+r":
+			msgs = append(msgs, tea.KeyPressMsg{Code: 'r', Mod: tea.ModCtrl})
+		default:
+			for _, r := range keys {
+				msgs = append(msgs, tea.KeyPressMsg{Code: r, Text: string(r)})
+			}
+		}
+		for _, msg := range msgs {
+			m, cmd = m.Update(msg)
+		}
+	}
+	return m, cmd
+}
+
+func TestVimModes(t *testing.T) {
+	m := newVim(t, "hello")
+	if m.VimMode() != VimNormal || m.CursorColumn() != 4 {
+		t.Fatalf("expected normal mode on the last character, got %s at %d", m.VimMode(), m.CursorColumn())
+	}
+	if m.VimPending() {
+		t.Fatalf("expected esc not to be handled in normal mode")
+	}
+
+	m, _ = press(m, "d")
+	if !m.VimPending() {
+		t.Fatalf("expected esc to cancel the pending operator")
+	}
+	m, _ = press(m, "esc")
+	if m.VimPending() || m.VimMode() != VimNormal {
+		t.Fatalf("expected esc to cancel the operator in normal mode")
+	}
+
+	m, _ = press(m, "0i", ">", "esc", "A!")
+	if got := m.Value(); got != ">hello!" {
+		t.Fatalf("expected %q, got %q", ">hello!", got)
+	}
+	if m.VimMode() != VimInsert {
+		t.Fatalf("expected insert mode, got %s", m.VimMode())
+	}
+
+	m, _ = press(m, "esc", "v")
+	if m.VimMode() != VimVisual || !m.VimPending() {
+		t.Fatalf("expected visual mode, got %s", m.VimMode())
+	}
+	m, _ = press(m, "v")
+	if m.VimMode() != VimNormal {
+		t.Fatalf("expected v to leave visual mode, got %s", m.VimMode())
+	}
+
+	m.SetVim(false)
+	m, _ = press(m, "x")
+	if got := m.Value(); got != ">hellox!" {
+		t.Fatalf("expected typing once disabled, got %q", got)
+	}
+}
+
+func TestVimMotions(t *testing.T) {
+	tests := []struct {
+		keys string
+		row  int
+		col  int
+	}{
+		{"0", 0, 0},
+		{"0w", 0, 4},
+		{"0ww", 0, 7},
+		{"0W", 0, 4},
+		{"0WW", 0, 12},
+		{"03w", 0, 8},
+		{"0e", 0, 2},
+		{"0ee", 0, 6},
+		{"$b", 0, 12},
+		{"$B", 0, 12},
+		{"$BB", 0, 4},
+		{"0fa", 0, 5},
+		{"0fa;", 0, 9},
+		{"0fa;,", 0, 5},
+		{"0ta", 0, 4},
+		{"0ta;", 0, 8},
+		{"$Fo", 0, 2},
+		{"0$", 0, 14},
+		{"0wl^", 0, 0},
+		{"0fzh", 0, 9},
+		{"0j", 1, 0},
+		{"0jw", 1, 3},
+		{"0jj", 2, 0},
+		{"G", 2, 0},
+		{"Ggg", 0, 0},
+		{"2G", 1, 0},
+		{"0wwwww", 1, 0},
+		{"Gk$", 1, 6},
+	}
+	for _, test := range tests {
+		m := newVim(t, "foo bar.baz qux\nab cd e\nlast")
+		m, _ = press(m, "gg", test.keys)
+		if m.Line() != test.row || m.CursorColumn() != test.col {
+			t.Errorf("%q: expected %d:%d, got %d:%d", test.keys, test.row, test.col, m.Line(), m.CursorColumn())
+		}
+	}
+}
+
+func TestVimOperators(t *testing.T) {
+	tests := []struct {
+		value string
+		keys  []string
+		want  string
+		mode  VimMode
+	}{
+		{"foo bar baz", []string{"0dw"}, "bar baz", VimNormal},
+		{"foo bar baz", []string{"0d2w"}, "baz", VimNormal},
+		{"foo bar baz", []string{"02dw"}, "baz", VimNormal},
+		{"foo bar baz", []string{"0wdw"}, "foo baz", VimNormal},
+		{"foo bar\nbaz", []string{"ggwdw"}, "foo \nbaz", VimNormal},
+		{"foo bar baz", []string{"0cwx", "esc"}, "x bar baz", VimNormal},
+		{"foo bar baz", []string{"0c2wx"}, "x baz", VimInsert},
+		{"foo bar baz", []string{"0wdiw"}, "foo  baz", VimNormal},
+		{"foo bar baz", []string{"0wdaw"}, "foo baz", VimNormal},
+		{"foo bar baz", []string{"$daw"}, "foo bar", VimNormal},
+		{"foo bar baz", []string{"0wde"}, "foo  baz", VimNormal},
+		{"foo bar baz", []string{"0wd$"}, "foo ", VimNormal},
+		{"foo bar baz", []string{"0wD"}, "foo ", VimNormal},
+		{"foo bar baz", []string{"0wC!"}, "foo !", VimInsert},
+		{"foo bar baz", []string{"0dfa"}, "r baz", VimNormal},
+		{"foo bar baz", []string{"0dta"}, "ar baz", VimNormal},
+		{"foo bar baz", []string{"$dFa"}, "foo bar bz", VimNormal},
+		{"foo bar baz", []string{"$d0"}, "z", VimNormal},
+		{"say \"hello there\" now", []string{"0fhci\"x"}, "say \"x\" now", VimInsert},
+		{"say \"hello there\" now", []string{"0da\""}, "say  now", VimNormal},
+		{"f(a, (b)) c", []string{"0fbdi("}, "f(a, ()) c", VimNormal},
+		{"f(a, (b)) c", []string{"0fada("}, "f c", VimNormal},
+		{"f(a, (b)) c", []string{"0f)di)"}, "f(a, ()) c", VimNormal},
+		{"{\n  a\n}", []string{"jdiB"}, "{}", VimNormal},
+		{"abcdef", []string{"03x"}, "def", VimNormal},
+		{"abcdef", []string{"$2X"}, "abcf", VimNormal},
+		{"abcdef", []string{"0sx"}, "xbcdef", VimInsert},
+		{"abc", []string{"0~~"}, "ABc", VimNormal},
+		{"abc", []string{"0rx"}, "xbc", VimNormal},
+		{"abc", []string{"02r-"}, "--c", VimNormal},
+		{"one\ntwo", []string{"ggJ"}, "one two", VimNormal},
+		{"one\n  two\nthree", []string{"gg3J"}, "one two three", VimNormal},
+		{"one\ntwo\nthree", []string{"ggjdd"}, "one\nthree", VimNormal},
+		{"one\ntwo\nthree", []string{"gg2dd"}, "three", VimNormal},
+		{"one\ntwo\nthree", []string{"ggdj"}, "three", VimNormal},
+		{"one\ntwo\nthree", []string{"Gdk"}, "one", VimNormal},
+		{"one\ntwo\nthree", []string{"ggdG"}, "", VimNormal},
+		{"one\ntwo\nthree", []string{"Gdgg"}, "", VimNormal},
+		{"one\ntwo\nthree", []string{"ggjccnew"}, "one\nnew\nthree", VimInsert},
+		{"one\ntwo", []string{"ggonew"}, "one\nnew\ntwo", VimInsert},
+		{"one\ntwo", []string{"ggOnew"}, "new\none\ntwo", VimInsert},
+		{"one\ntwo", []string{"ggyyp"}, "one\none\ntwo", VimNormal},
+		{"one\ntwo", []string{"Gyy2P"}, "one\ntwo\ntwo\ntwo", VimNormal},
+		{"foo bar", []string{"0wyiw0P"}, "barfoo bar", VimNormal},
+		{"foo bar", []string{"0dwp"}, "bfoo ar", VimNormal},
+		{"foo bar", []string{"0yw$p"}, "foo barfoo ", VimNormal},
+		{"foo bar baz", []string{"0wvlld"}, "foo  baz", VimNormal},
+		{"foo bar baz", []string{"0wvey$p"}, "foo bar bazbar", VimNormal},
+		{"foo bar baz", []string{"0wviwd"}, "foo  baz", VimNormal},
+		{"foo bar baz", []string{"0wvecx"}, "foo x baz", VimInsert},
+		{"foo bar baz", []string{"0yiwwwviwp"}, "foo bar foo", VimNormal},
+		{"foo bar baz", []string{"0wveU"}, "foo BAR baz", VimNormal},
+		{"one\ntwo\nthree", []string{"ggVjd"}, "three", VimNormal},
+		{"one\ntwo\nthree", []string{"ggVjy", "Gp"}, "one\ntwo\nthree\none\ntwo", VimNormal},
+		{"one\ntwo\nthree", []string{"ggyyjVp"}, "one\none\nthree", VimNormal},
+		{"foo bar", []string{"0dwu"}, "foo bar", VimNormal},
+		{"foo bar", []string{"0dwu", "ctrl+r"}, "bar", VimNormal},
+		{"foo", []string{"A bar", "esc", "u"}, "foo", VimNormal},
+	}
+	for _, test := range tests {
+		m := newVim(t, test.value)
+		m, _ = press(m, test.keys...)
+		if got := m.Value(); got != test.want {
+			t.Errorf("%q on %q: expected %q, got %q", test.keys, test.value, test.want, got)
+		}
+		if m.VimMode() != test.mode {
+			t.Errorf("%q on %q: expected %s mode, got %s", test.keys, test.value, test.mode, m.VimMode())
+		}
+	}
+}
+
+func TestVimRegisters(t *testing.T) {
+	m := newVim(t, "foo bar baz")
+	m, cmd := press(m, "0\"ayiw")
+	if cmd != nil {
+		t.Fatalf("expected a named register not to be copied to the clipboard")
+	}
+	m, _ = press(m, "w\"Ayiw", "w\"byiw")
+	m, cmd = press(m, "yiw")
+	if cmd == nil {
+		t.Fatalf("expected the unnamed register to be copied to the clipboard")
+	}
+	m, _ = press(m, "$\"ap", "\"bp")
+	if got, want := m.Value(), "foo bar bazfoobarbaz"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	m = newVim(t, "foo bar")
+	m, _ = press(m, "0yiw", "w\"_dw")
+	m, _ = press(m, "P")
+	// The black hole register keeps the unnamed register
+	if got, want := m.Value(), "foofoo "; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	m = newVim(t, "foo")
+	readClipboard = func() string { return "clip\n" }
+	m, _ = press(m, "yiw", "p")
+	if got, want := m.Value(), "foo\nclip"; got != want {
+		t.Fatalf("expected the clipboard to be put as a line, got %q", got)
+	}
+}
+
+func TestVimAttachments(t *testing.T) {
+	m := newVim(t, "a ")
+	m, _ = press(m, "A")
+	m.InsertAttachment(&attachment.Attachment{ID: "1", Display: "@file.go"})
+	m.InsertString(" b")
+	m, _ = press(m, "esc")
+
+	m, _ = press(m, "0w")
+	if m.CursorColumn() != 2 {
+		t.Fatalf("expected w to stop on the attachment, got %d", m.CursorColumn())
+	}
+	m, _ = press(m, "w")
+	if m.CursorColumn() != 4 {
+		t.Fatalf("expected w to move over the attachment as one word, got %d", m.CursorColumn())
+	}
+
+	m, _ = press(m, "0wrx")
+	if got := m.Value(); got != "a @file.go b" {
+		t.Fatalf("expected r not to replace an attachment, got %q", got)
+	}
+
+	m, _ = press(m, "x")
+	if got := m.Value(); got != "a  b" || len(m.GetAttachments()) != 0 {
+		t.Fatalf("expected x to delete the whole attachment, got %q", got)
+	}
+	m, _ = press(m, "P")
+	if got := m.Value(); got != "a @file.go b" || len(m.GetAttachments()) != 1 {
+		t.Fatalf("expected P to put the attachment back, got %q", got)
+	}
+
+	m, _ = press(m, "yiw$p")
+	if attachments := m.GetAttachments(); len(attachments) != 2 || m.Value() != "a @file.go b@file.go" {
+		t.Fatalf("expected the yanked attachment to be put as an attachment, got %q", m.Value())
+	}
+}
+
+func TestCutVimSelection(t *testing.T) {
+	m := newVim(t, "say hello world")
+	if _, ok := m.CutVimSelection(); ok {
+		t.Fatal("expected no selection in normal mode")
+	}
+
+	m, _ = press(m, "0", "w", "v", "e")
+	text, ok := m.CutVimSelection()
+	if !ok || text != "hello" {
+		t.Fatalf("expected the selection to be cut, got %q %v", text, ok)
+	}
+	if m.Value() != "say  world" || m.VimMode() != VimInsert || m.CursorColumn() != 4 {
+		t.Errorf("unexpected %q in %s at %d", m.Value(), m.VimMode(), m.CursorColumn())
+	}
+
+	m = newVim(t, "one\ntwo\nthree")
+	m, _ = press(m, "k", "V", "k")
+	if text, _ := m.CutVimSelection(); text != "one\ntwo" || m.Value() != "\nthree" || m.Line() != 0 {
+		t.Errorf("expected the lines to be cut, got %q leaving %q", text, m.Value())
+	}
+}
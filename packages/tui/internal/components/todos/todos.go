@@ -0,0 +1,132 @@
+package todos
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/muesli/reflow/truncate"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+)
+
+// ToggleTodosMsg collapses or expands the todo panel
+type ToggleTodosMsg struct{}
+
+// TodosComponent is the panel of the sidebar showing the todo list the agent
+// keeps for the current session, with the progress and the count of each
+// status
+type TodosComponent interface {
+	tea.Model
+	tea.ViewModel
+	SetWidth(width int)
+	// Visible reports whether the current session has todos
+	Visible() bool
+}
+
+type todosComponent struct {
+	app   *app.App
+	width int
+}
+
+func (m *todosComponent) Init() tea.Cmd {
+	return nil
+}
+
+func (m *todosComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg.(type) {
+	case ToggleTodosMsg:
+		m.app.State.TodosCollapsed = !m.app.State.TodosCollapsed
+		return m, m.app.SaveState()
+	}
+	return m, nil
+}
+
+func (m *todosComponent) SetWidth(width int) {
+	m.width = width
+}
+
+func (m *todosComponent) Visible() bool {
+	return len(m.app.Todos()) > 0
+}
+
+func (m *todosComponent) View() string {
+	todos := m.app.Todos()
+	if len(todos) == 0 {
+		return ""
+	}
+	t := theme.CurrentTheme()
+	base := styles.NewStyle().Background(t.BackgroundPanel())
+	muted := base.Foreground(t.TextMuted())
+	width := m.width - 2
+
+	counts := app.CountTodos(todos)
+	total := len(todos) - counts["cancelled"]
+	arrow := "▾"
+	if m.app.State.TodosCollapsed {
+		arrow = "▸"
+	}
+	progress := fmt.Sprintf("%d/%d", counts["completed"], total)
+	title := base.Foreground(t.Text()).Bold(true).Render("Todos ") + muted.Render(progress)
+	title += base.Render(strings.Repeat(" ", max(1, width-lipgloss.Width(title)-1))) + muted.Render(arrow)
+	lines := []string{title, m.renderProgress(counts["completed"], total, width)}
+
+	if !m.app.State.TodosCollapsed {
+		lines = append(lines, "")
+		for _, todo := range todos {
+			lines = append(lines, m.renderTodo(todo, width))
+		}
+		lines = append(lines, "")
+
+		var summary []string
+		for _, status := range app.TodoStatuses {
+			if counts[status] > 0 {
+				summary = append(summary, fmt.Sprintf("%d %s", counts[status], strings.ReplaceAll(status, "_", " ")))
+			}
+		}
+		lines = append(lines, muted.Render(truncate.StringWithTail(strings.Join(summary, " · "), uint(width), "…")))
+	}
+
+	return base.
+		Width(m.width).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+}
+
+// renderProgress renders a bar of the completed todos
+func (m *todosComponent) renderProgress(completed, total, width int) string {
+	t := theme.CurrentTheme()
+	filled := 0
+	if total > 0 {
+		filled = width * completed / total
+	}
+	return styles.NewStyle().Background(t.BackgroundPanel()).Foreground(t.Success()).Render(strings.Repeat("━", filled)) +
+		styles.NewStyle().Background(t.BackgroundPanel()).Foreground(t.BackgroundElement()).Render(strings.Repeat("━", width-filled))
+}
+
+// renderTodo renders a todo on a line, with the icon of its status
+func (m *todosComponent) renderTodo(todo app.Todo, width int) string {
+	t := theme.CurrentTheme()
+	style := styles.NewStyle().Background(t.BackgroundPanel()).Foreground(t.Text())
+	icon := "○"
+	switch todo.Status {
+	case "in_progress":
+		icon = "●"
+		style = style.Foreground(t.Accent()).Bold(true)
+	case "completed":
+		icon = "✓"
+		style = style.Foreground(t.TextMuted())
+	case "cancelled":
+		icon = "✗"
+		style = style.Foreground(t.TextMuted()).Strikethrough(true)
+	}
+	content := truncate.StringWithTail(strings.Join(strings.Fields(todo.Content), " "), uint(max(1, width-2)), "…")
+	return style.Strikethrough(false).Render(icon+" ") + style.Render(content)
+}
+
+// NewTodosComponent creates the todo panel of the sidebar
+func NewTodosComponent(app *app.App) TodosComponent {
+	return &todosComponent{app: app}
+}
@@ -0,0 +1,252 @@
+// Package exporter writes a session and its messages to a file: as JSON, which
+// keeps every field the server sent and can be read back, as Markdown, or as
+// a self-contained HTML page.
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/theme"
+)
+
+// Version is the version of the JSON export format.
+const Version = 1
+
+type Format string
+
+const (
+	// FormatJSON is the session and its messages as the server returned them.
+	FormatJSON Format = "json"
+	// FormatMarkdown is a readable transcript, with tool calls and reasoning
+	// in collapsible blocks.
+	FormatMarkdown Format = "markdown"
+	// FormatHTML is the Markdown transcript rendered to a single HTML page,
+	// styled with the current theme.
+	FormatHTML Format = "html"
+)
+
+// Formats lists the supported formats in the order they are offered.
+var Formats = []Format{FormatMarkdown, FormatHTML, FormatJSON}
+
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(value) {
+	case "json":
+		return FormatJSON, nil
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "html", "htm":
+		return FormatHTML, nil
+	}
+	return "", fmt.Errorf("invalid export format %q, expected json, markdown or html", value)
+}
+
+// FormatForPath returns the format matching the extension of path.
+func FormatForPath(path string) (Format, bool) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return "", false
+	}
+	format, err := ParseFormat(ext)
+	return format, err == nil
+}
+
+// Extension returns the file extension for the format, including the dot.
+func (f Format) Extension() string {
+	switch f {
+	case FormatMarkdown:
+		return ".md"
+	case FormatHTML:
+		return ".html"
+	}
+	return ".json"
+}
+
+// Document is an exported session.
+type Document struct {
+	Version    int
+	ExportedAt time.Time
+	Session    opencode.Session
+	Messages   []app.Message
+}
+
+// document is the JSON representation of a Document. The session, messages
+// and parts are kept as the raw JSON the server sent, so that fields the SDK
+// doesn't know about survive an export.
+type document struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exportedAt"`
+	Session    json.RawMessage   `json:"session"`
+	Messages   []json.RawMessage `json:"messages"`
+}
+
+type message struct {
+	Info  json.RawMessage   `json:"info"`
+	Parts []json.RawMessage `json:"parts"`
+}
+
+// WriteFile exports the session to path. t is used for the colors of the HTML
+// format and may be nil.
+func WriteFile(path string, format Format, session opencode.Session, messages []app.Message, t theme.Theme) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(file, format, session, messages, t); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Write exports the session to w. t is used for the colors of the HTML format
+// and may be nil.
+func Write(w io.Writer, format Format, session opencode.Session, messages []app.Message, t theme.Theme) error {
+	doc := Document{
+		Version:    Version,
+		ExportedAt: time.Now(),
+		Session:    session,
+		Messages:   messages,
+	}
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, doc)
+	case FormatMarkdown:
+		_, err := io.WriteString(w, Markdown(doc))
+		return err
+	case FormatHTML:
+		return writeHTML(w, doc, t)
+	}
+	return fmt.Errorf("unsupported export format %q", format)
+}
+
+func writeJSON(w io.Writer, doc Document) error {
+	out := document{
+		Version:    doc.Version,
+		ExportedAt: doc.ExportedAt,
+		Messages:   []json.RawMessage{},
+	}
+	var err error
+	if out.Session, err = rawJSON(doc.Session); err != nil {
+		return err
+	}
+	for _, msg := range doc.Messages {
+		var m message
+		if m.Info, err = rawJSON(msg.Info); err != nil {
+			return err
+		}
+		m.Parts = []json.RawMessage{}
+		for _, part := range msg.Parts {
+			raw, err := rawJSON(part)
+			if err != nil {
+				return err
+			}
+			m.Parts = append(m.Parts, raw)
+		}
+		raw, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		out.Messages = append(out.Messages, raw)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// rawJSON returns the JSON a value of the SDK was decoded from, or encodes it
+// if it was built locally, like the user message added while a prompt is
+// being sent.
+func rawJSON(v any) (json.RawMessage, error) {
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Struct {
+		if field := value.FieldByName("JSON"); field.IsValid() && field.CanInterface() {
+			if raw, ok := field.Interface().(interface{ RawJSON() string }); ok && raw.RawJSON() != "" {
+				return json.RawMessage(raw.RawJSON()), nil
+			}
+		}
+	}
+	return json.Marshal(v)
+}
+
+// ReadFile reads a session exported in the JSON format.
+func ReadFile(path string) (*Document, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// Read reads a session exported in the JSON format.
+func Read(r io.Reader) (*Document, error) {
+	var in struct {
+		Version    int                                `json:"version"`
+		ExportedAt time.Time                          `json:"exportedAt"`
+		Session    opencode.Session                   `json:"session"`
+		Messages   []opencode.SessionMessagesResponse `json:"messages"`
+	}
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("invalid session export: %w", err)
+	}
+	if in.Version < 1 || in.Version > Version {
+		return nil, fmt.Errorf("unsupported session export version %d", in.Version)
+	}
+
+	doc := &Document{
+		Version:    in.Version,
+		ExportedAt: in.ExportedAt,
+		Session:    in.Session,
+		Messages:   []app.Message{},
+	}
+	for _, message := range in.Messages {
+		msg := app.Message{
+			Info:  message.Info.AsUnion(),
+			Parts: []opencode.PartUnion{},
+		}
+		for _, part := range message.Parts {
+			msg.Parts = append(msg.Parts, part.AsUnion())
+		}
+		doc.Messages = append(doc.Messages, msg)
+	}
+	return doc, nil
+}
+
+// DefaultFilename returns a file name for exporting the session, based on its
+// title.
+func DefaultFilename(session opencode.Session, format Format) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(session.Title) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+		if b.Len() >= 50 {
+			break
+		}
+	}
+	name := strings.Trim(b.String(), "-")
+	if name == "" {
+		name = session.ID
+	}
+	if name == "" {
+		name = "session"
+	}
+	return name + format.Extension()
+}
@@ -0,0 +1,237 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/option"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/theme"
+)
+
+const exported = `{
+  "version": 1,
+  "exportedAt": "2025-01-02T03:04:05Z",
+  "session": {"id":"ses_1","directory":"/repo","projectID":"p","time":{"created":1735786800000,"updated":1735786800000},"title":"Fix the build","version":"0.1.0","future":"kept"},
+  "messages": [
+    {
+      "info": {"id":"msg_1","role":"user","sessionID":"ses_1","time":{"created":1735786800000}},
+      "parts": [{"id":"prt_1","messageID":"msg_1","sessionID":"ses_1","type":"text","text":"Why does it fail?"}]
+    },
+    {
+      "info": {"id":"msg_2","role":"assistant","sessionID":"ses_1","cost":0.25,"mode":"build","modelID":"claude","providerID":"anthropic","path":{"cwd":"/repo","root":"/repo"},"system":[],"time":{"created":1735786801000},"tokens":{"input":100,"output":20,"reasoning":5,"cache":{"read":7,"write":3}}},
+      "parts": [
+        {"id":"prt_2","messageID":"msg_2","sessionID":"ses_1","type":"reasoning","text":"Let me look","time":{"start":1}},
+        {"id":"prt_3","messageID":"msg_2","sessionID":"ses_1","type":"tool","callID":"c","tool":"edit","state":{"status":"completed","title":"main.go","input":{"filePath":"main.go"},"output":"","metadata":{"diff":"@@ -1 +1 @@\n-old\n+new\n"},"time":{"start":1,"end":2}}},
+        {"id":"prt_4","messageID":"msg_2","sessionID":"ses_1","type":"text","text":"Fixed, see ` + "```go" + ` block"}
+      ]
+    }
+  ]
+}`
+
+func TestJSONRoundTrip(t *testing.T) {
+	doc, err := Read(strings.NewReader(exported))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Session.Title != "Fix the build" || len(doc.Messages) != 2 || len(doc.Messages[1].Parts) != 3 {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+	if _, ok := doc.Messages[1].Parts[1].(opencode.ToolPart); !ok {
+		t.Fatalf("expected a tool part, got %T", doc.Messages[1].Parts[1])
+	}
+
+	// A message built locally has no raw JSON to keep.
+	messages := append(doc.Messages, app.Message{
+		Info:  opencode.UserMessage{ID: "msg_3", Role: opencode.UserMessageRoleUser, SessionID: "ses_1"},
+		Parts: []opencode.PartUnion{opencode.TextPart{ID: "prt_5", Type: opencode.TextPartTypeText, Text: "thanks"}},
+	})
+
+	var out bytes.Buffer
+	if err := Write(&out, FormatJSON, doc.Session, messages, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"future": "kept"`) {
+		t.Errorf("expected unknown fields to be kept, got %s", out.String())
+	}
+
+	again, err := Read(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(again.Messages))
+	}
+	for i, msg := range doc.Messages {
+		for j, part := range msg.Parts {
+			before, _ := json.Marshal(part)
+			after, _ := json.Marshal(again.Messages[i].Parts[j])
+			if !bytes.Equal(before, after) {
+				t.Errorf("part %d of message %d changed:\n%s\n%s", j, i, before, after)
+			}
+		}
+	}
+	if text, ok := again.Messages[2].Parts[0].(opencode.TextPart); !ok || text.Text != "thanks" {
+		t.Errorf("unexpected local part %#v", again.Messages[2].Parts[0])
+	}
+}
+
+func TestImport(t *testing.T) {
+	var body struct {
+		Title    string `json:"title"`
+		Messages []struct {
+			Info  map[string]any   `json:"info"`
+			Parts []map[string]any `json:"parts"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/session/import" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("invalid body %s: %v", data, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"ses_2","title":"Fix the build","directory":"/repo","projectID":"p","version":"0.1.0","time":{"created":1,"updated":1}}`))
+	}))
+	defer server.Close()
+
+	doc, err := Read(strings.NewReader(exported))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := opencode.NewClient(option.WithBaseURL(server.URL))
+	session, err := Import(context.Background(), client, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.ID != "ses_2" {
+		t.Errorf("unexpected session %+v", session)
+	}
+	if body.Title != "Fix the build" || len(body.Messages) != 2 || len(body.Messages[1].Parts) != 3 {
+		t.Fatalf("unexpected body %+v", body)
+	}
+	if body.Messages[1].Info["modelID"] != "claude" || body.Messages[1].Parts[1]["tool"] != "edit" {
+		t.Errorf("expected the messages to be sent as exported, got %+v", body.Messages[1])
+	}
+}
+
+func TestReadRejectsUnknownVersion(t *testing.T) {
+	if _, err := Read(strings.NewReader(`{"version":2,"session":{},"messages":[]}`)); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	doc, err := Read(strings.NewReader(exported))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := Markdown(*doc)
+	for _, expected := range []string{
+		"# Fix the build",
+		"- **Tokens:** 100 input · 20 output · 5 reasoning · 7 cache read · 3 cache write",
+		"- **Cost:** $0.2500",
+		"## Assistant · claude (anthropic) · build",
+		"<details>\n<summary>Thinking</summary>\n\nLet me look\n\n</details>",
+		"<summary>edit: main.go</summary>",
+		"```diff\n@@ -1 +1 @@\n-old\n+new\n```",
+		"\"filePath\": \"main.go\"",
+	} {
+		if !strings.Contains(md, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, md)
+		}
+	}
+}
+
+func TestWriteFenced(t *testing.T) {
+	var b strings.Builder
+	writeFenced(&b, "", "a ```` b")
+	if b.String() != "`````\na ```` b\n`````\n\n" {
+		t.Errorf("unexpected fence %q", b.String())
+	}
+}
+
+func TestHTML(t *testing.T) {
+	if err := theme.LoadThemesFromJSON(); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := Read(strings.NewReader(exported))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "export", DefaultFilename(doc.Session, FormatHTML))
+	if filepath.Base(path) != "fix-the-build.html" {
+		t.Errorf("unexpected file name %s", filepath.Base(path))
+	}
+	if err := WriteFile(path, FormatHTML, doc.Session, doc.Messages, theme.GetTheme("opencode")); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, FormatHTML, doc.Session, doc.Messages, theme.GetTheme("opencode")); err != nil {
+		t.Fatal(err)
+	}
+	page := out.String()
+	for _, expected := range []string{
+		"<title>Fix the build</title>",
+		"@media (prefers-color-scheme: dark)",
+		`<span class="removed">-old</span>`,
+		`<span class="added">+new</span>`,
+		"<details>",
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("expected page to contain %q", expected)
+		}
+	}
+}
+
+func TestHTMLEscapesRawHTML(t *testing.T) {
+	doc := Document{
+		Session: opencode.Session{ID: "ses_1", Title: "xss"},
+		Messages: []app.Message{{
+			Info: opencode.AssistantMessage{ID: "msg_1", ModelID: "m"},
+			Parts: []opencode.PartUnion{
+				opencode.ReasoningPart{Type: opencode.ReasoningPartTypeReasoning, Text: "</details><script>alert(1)</script>"},
+				opencode.TextPart{Type: opencode.TextPartTypeText, Text: "see <img src=x onerror=alert(2)>\n\n<div onclick=alert(3)>\nhi\n</div>"},
+			},
+		}},
+	}
+	var out bytes.Buffer
+	if err := writeHTML(&out, doc, nil); err != nil {
+		t.Fatal(err)
+	}
+	page := out.String()
+	for _, unexpected := range []string{"<script>", "<img", "<div"} {
+		if strings.Contains(page, unexpected) {
+			t.Errorf("expected %q to be escaped, got:\n%s", unexpected, page)
+		}
+	}
+	if strings.Count(page, "<details>") != strings.Count(page, "</details>") {
+		t.Errorf("expected balanced details blocks, got:\n%s", page)
+	}
+	if !strings.Contains(page, "&lt;/details&gt;&lt;script&gt;") {
+		t.Errorf("expected the reasoning to be kept as text, got:\n%s", page)
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	cases := map[string]Format{"a.json": FormatJSON, "a.md": FormatMarkdown, "a.HTML": FormatHTML}
+	for path, expected := range cases {
+		if format, ok := FormatForPath(path); !ok || format != expected {
+			t.Errorf("expected %s for %s, got %s", expected, path, format)
+		}
+	}
+	if _, ok := FormatForPath("a.txt"); ok {
+		t.Error("expected no format for a.txt")
+	}
+}
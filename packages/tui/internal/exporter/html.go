@@ -0,0 +1,261 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss/v2/compat"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// cssColors maps the CSS variables used by the page to the theme colors they
+// are taken from.
+var cssColors = []struct {
+	name  string
+	color func(theme.Theme) compat.AdaptiveColor
+}{
+	{"background", theme.Theme.Background},
+	{"background-panel", theme.Theme.BackgroundPanel},
+	{"background-element", theme.Theme.BackgroundElement},
+	{"border", theme.Theme.Border},
+	{"primary", theme.Theme.Primary},
+	{"accent", theme.Theme.Accent},
+	{"text", theme.Theme.Text},
+	{"text-muted", theme.Theme.TextMuted},
+	{"error", theme.Theme.Error},
+	{"heading", theme.Theme.MarkdownHeading},
+	{"link", theme.Theme.MarkdownLink},
+	{"code", theme.Theme.MarkdownCode},
+	{"block-quote", theme.Theme.MarkdownBlockQuote},
+	{"diff-added", theme.Theme.DiffAdded},
+	{"diff-removed", theme.Theme.DiffRemoved},
+	{"diff-context", theme.Theme.DiffContext},
+	{"diff-hunk-header", theme.Theme.DiffHunkHeader},
+	{"diff-added-bg", theme.Theme.DiffAddedBg},
+	{"diff-removed-bg", theme.Theme.DiffRemovedBg},
+}
+
+const style = `:root {
+  --background: #ffffff; --background-panel: #f6f6f6; --background-element: #eeeeee;
+  --border: #d0d0d0; --primary: #3b7dd8; --accent: #d68c27; --text: #1a1a1a;
+  --text-muted: #6a6a6a; --error: #d1383d; --heading: #3b7dd8; --link: #3b7dd8;
+  --code: #b35900; --block-quote: #6a6a6a; --diff-added: #1e7f3c; --diff-removed: #c0392b;
+  --diff-context: #6a6a6a; --diff-hunk-header: #6a6a6a; --diff-added-bg: #e6f4ea;
+  --diff-removed-bg: #fbe9e7;
+}
+body { margin: 0; background: var(--background); color: var(--text);
+  font: 15px/1.6 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; }
+main { max-width: 860px; margin: 0 auto; padding: 2rem 1rem; }
+h1, h2, h3 { color: var(--heading); line-height: 1.3; }
+h2 { font-size: 1.1rem; margin-top: 0; }
+a { color: var(--link); }
+hr { border: 0; border-top: 1px solid var(--border); margin: 2rem 0; }
+blockquote { margin: 0; padding-left: 1rem; border-left: 3px solid var(--border); color: var(--block-quote); }
+code { color: var(--code); font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 0.9em; }
+pre { background: var(--background-element); padding: 0.75rem 1rem; overflow-x: auto; border-radius: 4px; }
+pre code { color: var(--text); }
+pre.diff code span { display: block; }
+pre.diff .added { color: var(--diff-added); background: var(--diff-added-bg); }
+pre.diff .removed { color: var(--diff-removed); background: var(--diff-removed-bg); }
+pre.diff .hunk { color: var(--diff-hunk-header); }
+pre.diff .context { color: var(--diff-context); }
+details { background: var(--background-panel); border: 1px solid var(--border); border-radius: 4px;
+  padding: 0.5rem 1rem; margin: 1rem 0; }
+summary { cursor: pointer; color: var(--text-muted); }
+details[open] summary { margin-bottom: 0.5rem; }
+em { color: var(--text-muted); }
+`
+
+func writeHTML(w io.Writer, doc Document, t theme.Theme) error {
+	// Raw HTML is never passed through: the collapsible blocks are written
+	// here rather than as HTML inside the Markdown, and any HTML in the
+	// conversation itself is escaped by rawHTMLRenderer.
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(
+			renderer.WithNodeRenderers(
+				util.Prioritized(diffRenderer{}, 100),
+				util.Prioritized(rawHTMLRenderer{}, 100),
+			),
+		),
+	)
+
+	var body bytes.Buffer
+	var pending strings.Builder
+	var err error
+	flush := func() {
+		if err == nil {
+			err = md.Convert([]byte(pending.String()), &body)
+		}
+		pending.Reset()
+	}
+	page := transcript{b: &pending}
+	page.details = func(summary string, content func()) {
+		flush()
+		fmt.Fprintf(&body, "<details>\n<summary>%s</summary>\n", html.EscapeString(summary))
+		content()
+		flush()
+		body.WriteString("</details>\n")
+	}
+	page.write(doc)
+	flush()
+	if err != nil {
+		return err
+	}
+
+	title := doc.Session.Title
+	if title == "" {
+		title = "Conversation History"
+	}
+	_, err = fmt.Fprintf(w, `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<style>
+%s%s</style>
+</head>
+<body>
+<main>
+%s</main>
+</body>
+</html>
+`, html.EscapeString(title), style, themeCSS(t), body.String())
+	return err
+}
+
+// themeCSS overrides the default colors with the theme's, using its dark
+// variants when the browser prefers a dark color scheme.
+func themeCSS(t theme.Theme) string {
+	if t == nil {
+		return ""
+	}
+	var light, dark strings.Builder
+	for _, c := range cssColors {
+		color := c.color(t)
+		if value, ok := hex(color.Light); ok {
+			fmt.Fprintf(&light, "  --%s: %s;\n", c.name, value)
+		}
+		if value, ok := hex(color.Dark); ok {
+			fmt.Fprintf(&dark, "    --%s: %s;\n", c.name, value)
+		}
+	}
+	return fmt.Sprintf(
+		":root {\n%s}\n@media (prefers-color-scheme: dark) {\n  :root {\n%s  }\n}\n",
+		light.String(),
+		dark.String(),
+	)
+}
+
+// hex returns the color as a CSS hex color. Transparent colors, which themes
+// use for "no color", are skipped.
+func hex(c color.Color) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8), true
+}
+
+// diffRenderer renders code blocks fenced as diff with a class per line, so
+// that added and removed lines can be colored.
+type diffRenderer struct{}
+
+func (r diffRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.render)
+}
+
+func (r diffRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.FencedCodeBlock)
+	language := string(n.Language(source))
+	if !entering {
+		w.WriteString("</code></pre>\n")
+		return ast.WalkContinue, nil
+	}
+	if language != "diff" {
+		w.WriteString("<pre><code")
+		if language != "" {
+			fmt.Fprintf(w, ` class="language-%s"`, html.EscapeString(language))
+		}
+		w.WriteString(">")
+	} else {
+		w.WriteString(`<pre class="diff"><code class="language-diff">`)
+	}
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		text := string(line.Value(source))
+		if language != "diff" {
+			w.WriteString(html.EscapeString(text))
+			continue
+		}
+		class := "context"
+		switch {
+		case strings.HasPrefix(text, "+++"), strings.HasPrefix(text, "---"), strings.HasPrefix(text, "@@"):
+			class = "hunk"
+		case strings.HasPrefix(text, "+"):
+			class = "added"
+		case strings.HasPrefix(text, "-"):
+			class = "removed"
+		}
+		text = strings.TrimRight(text, "\n")
+		if text == "" {
+			// Keep the height of empty lines.
+			text = " "
+		}
+		fmt.Fprintf(w, `<span class="%s">%s</span>`, class, html.EscapeString(text))
+	}
+	return ast.WalkContinue, nil
+}
+
+// rawHTMLRenderer renders HTML found in the Markdown as text, so that messages
+// and tool output cannot inject markup or scripts into the page.
+type rawHTMLRenderer struct{}
+
+func (r rawHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHTMLBlock, r.renderBlock)
+	reg.Register(ast.KindRawHTML, r.renderInline)
+}
+
+func (r rawHTMLRenderer) renderBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.HTMLBlock)
+	w.WriteString("<p>")
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		w.WriteString(html.EscapeString(string(line.Value(source))))
+	}
+	if n.HasClosure() {
+		w.WriteString(html.EscapeString(string(n.ClosureLine.Value(source))))
+	}
+	w.WriteString("</p>\n")
+	return ast.WalkContinue, nil
+}
+
+func (r rawHTMLRenderer) renderInline(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.RawHTML)
+	for i := 0; i < n.Segments.Len(); i++ {
+		segment := n.Segments.At(i)
+		w.WriteString(html.EscapeString(string(segment.Value(source))))
+	}
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,37 @@
+package exporter
+
+import (
+	"context"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// Import creates a new session on the server holding a copy of the document's
+// messages. The messages and parts are given new IDs.
+func Import(ctx context.Context, client *opencode.Client, doc *Document) (*opencode.Session, error) {
+	messages := []opencode.SessionImportParamsMessage{}
+	for _, msg := range doc.Messages {
+		info, err := rawJSON(msg.Info)
+		if err != nil {
+			return nil, err
+		}
+		parts := []interface{}{}
+		for _, part := range msg.Parts {
+			raw, err := rawJSON(part)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, raw)
+		}
+		messages = append(messages, opencode.SessionImportParamsMessage{
+			Info:  opencode.F[interface{}](info),
+			Parts: opencode.F(parts),
+		})
+	}
+
+	params := opencode.SessionImportParams{Messages: opencode.F(messages)}
+	if doc.Session.Title != "" {
+		params.Title = opencode.F(doc.Session.Title)
+	}
+	return client.Session.Import(ctx, params)
+}
@@ -0,0 +1,231 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// Markdown renders the document as a Markdown transcript. Reasoning and tool
+// calls are wrapped in <details> blocks, and the diffs of edits are fenced as
+// diff.
+func Markdown(doc Document) string {
+	var b strings.Builder
+	t := transcript{b: &b}
+	// The blank lines around the content let it be parsed as Markdown.
+	t.details = func(summary string, content func()) {
+		fmt.Fprintf(&b, "<details>\n<summary>%s</summary>\n\n", html.EscapeString(summary))
+		content()
+		b.WriteString("</details>\n\n")
+	}
+	t.write(doc)
+	return b.String()
+}
+
+// transcript writes a document as Markdown to b, calling details for every
+// collapsible block so that the HTML export can render those itself instead
+// of passing raw HTML through Markdown.
+type transcript struct {
+	b       *strings.Builder
+	details func(summary string, content func())
+}
+
+func (t transcript) write(doc Document) {
+	b := t.b
+
+	title := doc.Session.Title
+	if title == "" {
+		title = "Conversation History"
+	}
+	fmt.Fprintf(b, "# %s\n\n", title)
+
+	var total usage
+	for _, msg := range doc.Messages {
+		if info, ok := msg.Info.(opencode.AssistantMessage); ok {
+			total.add(info)
+		}
+	}
+	if doc.Session.ID != "" {
+		fmt.Fprintf(b, "- **Session:** `%s`\n", doc.Session.ID)
+	}
+	if doc.Session.Directory != "" {
+		fmt.Fprintf(b, "- **Directory:** `%s`\n", doc.Session.Directory)
+	}
+	if doc.Session.Time.Created > 0 {
+		fmt.Fprintf(b, "- **Created:** %s\n", formatTime(doc.Session.Time.Created))
+	}
+	if !doc.ExportedAt.IsZero() {
+		fmt.Fprintf(b, "- **Exported:** %s\n", doc.ExportedAt.Format(timeLayout))
+	}
+	fmt.Fprintf(b, "- **Tokens:** %s\n", total)
+	fmt.Fprintf(b, "- **Cost:** %s\n\n", formatCost(total.cost))
+
+	for _, msg := range doc.Messages {
+		switch info := msg.Info.(type) {
+		case opencode.UserMessage:
+			b.WriteString("---\n\n")
+			fmt.Fprintf(b, "## User · %s\n\n", formatTime(info.Time.Created))
+		case opencode.AssistantMessage:
+			b.WriteString("---\n\n")
+			fmt.Fprintf(b, "## Assistant · %s", info.ModelID)
+			if info.ProviderID != "" {
+				fmt.Fprintf(b, " (%s)", info.ProviderID)
+			}
+			if info.Mode != "" {
+				fmt.Fprintf(b, " · %s", info.Mode)
+			}
+			fmt.Fprintf(b, " · %s\n\n", formatTime(info.Time.Created))
+		default:
+			continue
+		}
+
+		for _, part := range msg.Parts {
+			t.writePart(part)
+		}
+
+		if info, ok := msg.Info.(opencode.AssistantMessage); ok {
+			if err := info.Error.AsError(); err != nil {
+				fmt.Fprintf(b, "> **Error:** %s\n\n", err)
+			}
+			var u usage
+			u.add(info)
+			fmt.Fprintf(b, "*Tokens: %s · Cost: %s*\n\n", u, formatCost(u.cost))
+		}
+	}
+
+}
+
+func (t transcript) writePart(part opencode.PartUnion) {
+	b := t.b
+	switch p := part.(type) {
+	case opencode.TextPart:
+		if p.Synthetic {
+			t.details("Context", func() {
+				writeFenced(b, "", p.Text)
+			})
+			return
+		}
+		b.WriteString(strings.TrimSpace(p.Text) + "\n\n")
+
+	case opencode.ReasoningPart:
+		if strings.TrimSpace(p.Text) == "" {
+			return
+		}
+		t.details("Thinking", func() {
+			b.WriteString(strings.TrimSpace(p.Text) + "\n\n")
+		})
+
+	case opencode.FilePart:
+		name := p.Filename
+		if p.Source.Path != "" {
+			name = p.Source.Path
+		}
+		fmt.Fprintf(b, "**File:** `%s` (%s)\n\n", name, p.Mime)
+
+	case opencode.AgentPart:
+		fmt.Fprintf(b, "**Agent:** @%s\n\n", p.Name)
+
+	case opencode.ToolPart:
+		t.writeTool(p)
+
+	case opencode.PartPatchPart:
+		if len(p.Files) == 0 {
+			return
+		}
+		b.WriteString("**Changed files:**\n\n")
+		for _, file := range p.Files {
+			fmt.Fprintf(b, "- `%s`\n", file)
+		}
+		b.WriteString("\n")
+	}
+}
+
+func (t transcript) writeTool(p opencode.ToolPart) {
+	b := t.b
+	summary := p.Tool
+	if p.State.Title != "" {
+		summary += ": " + p.State.Title
+	}
+	if p.State.Status != opencode.ToolPartStateStatusCompleted {
+		summary += fmt.Sprintf(" (%s)", p.State.Status)
+	}
+
+	t.details(summary, func() {
+		if p.State.Input != nil {
+			if input, err := json.MarshalIndent(p.State.Input, "", "  "); err == nil && string(input) != "{}" {
+				b.WriteString("**Input**\n\n")
+				writeFenced(b, "json", string(input))
+			}
+		}
+		if metadata, ok := p.State.Metadata.(map[string]any); ok {
+			if patch, ok := metadata["diff"].(string); ok && patch != "" {
+				b.WriteString("**Diff**\n\n")
+				writeFenced(b, "diff", patch)
+			}
+		}
+		if p.State.Output != "" {
+			b.WriteString("**Output**\n\n")
+			writeFenced(b, "", p.State.Output)
+		}
+		if p.State.Error != "" {
+			b.WriteString("**Error**\n\n")
+			writeFenced(b, "", p.State.Error)
+		}
+	})
+}
+
+// writeFenced writes a fenced code block, using a fence longer than any run of
+// backticks in the content.
+func writeFenced(b *strings.Builder, lang string, content string) {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	content = strings.TrimRight(content, "\n")
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n\n", fence, lang, content, fence)
+}
+
+type usage struct {
+	input, output, reasoning, cacheRead, cacheWrite float64
+	cost                                            float64
+}
+
+func (u *usage) add(info opencode.AssistantMessage) {
+	u.input += info.Tokens.Input
+	u.output += info.Tokens.Output
+	u.reasoning += info.Tokens.Reasoning
+	u.cacheRead += info.Tokens.Cache.Read
+	u.cacheWrite += info.Tokens.Cache.Write
+	u.cost += info.Cost
+}
+
+func (u usage) String() string {
+	s := fmt.Sprintf("%.0f input · %.0f output", u.input, u.output)
+	if u.reasoning > 0 {
+		s += fmt.Sprintf(" · %.0f reasoning", u.reasoning)
+	}
+	if u.cacheRead > 0 || u.cacheWrite > 0 {
+		s += fmt.Sprintf(" · %.0f cache read · %.0f cache write", u.cacheRead, u.cacheWrite)
+	}
+	return s
+}
+
+const timeLayout = "2006-01-02 15:04:05"
+
+func formatTime(ms float64) string {
+	return time.UnixMilli(int64(ms)).Format(timeLayout)
+}
+
+func formatCost(cost float64) string {
+	return fmt.Sprintf("$%.4f", cost)
+}
@@ -0,0 +1,321 @@
+// Package headless runs a single prompt without the TUI, streaming the reply to
+// stdout, for use in shell scripts and git hooks.
+package headless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/packages/ssestream"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/exporter"
+	"github.com/sst/opencode/internal/theme"
+)
+
+const abortTimeout = 5 * time.Second
+
+// reconnectAttempts is how many times in a row the event stream may fail to
+// reconnect before the run gives up.
+const reconnectAttempts = 5
+
+// Exit codes returned by Run.
+const (
+	ExitOK = 0
+	// ExitSessionError means the server reported an error while processing
+	// the prompt, or the prompt could not be sent.
+	ExitSessionError = 1
+	// ExitSetupError means the run could not start, for example because no
+	// model is configured or the session does not exist.
+	ExitSetupError = 2
+	// ExitExportError means the session could not be exported to the path
+	// given in [Options.Export].
+	ExitExportError = 3
+	// ExitInterrupted means the context was cancelled before the session went
+	// idle. The session is aborted.
+	ExitInterrupted = 130
+)
+
+type Format string
+
+const (
+	// FormatText writes the assistant's text as it streams in, with a line
+	// per tool call.
+	FormatText Format = "text"
+	// FormatJSON writes one JSON object per line. See [Record].
+	FormatJSON Format = "json"
+)
+
+// Approval is how permission requests are answered.
+type Approval string
+
+const (
+	ApproveNever  Approval = "never"
+	ApproveOnce   Approval = "once"
+	ApproveAlways Approval = "always"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case FormatText, FormatJSON:
+		return Format(value), nil
+	}
+	return "", fmt.Errorf("invalid format %q, expected text or json", value)
+}
+
+func ParseApproval(value string) (Approval, error) {
+	switch Approval(value) {
+	case ApproveNever, ApproveOnce, ApproveAlways:
+		return Approval(value), nil
+	}
+	return "", fmt.Errorf("invalid approval %q, expected never, once or always", value)
+}
+
+func (a Approval) response() opencode.SessionPermissionRespondParamsResponse {
+	switch a {
+	case ApproveOnce:
+		return opencode.SessionPermissionRespondParamsResponseOnce
+	case ApproveAlways:
+		return opencode.SessionPermissionRespondParamsResponseAlways
+	}
+	return opencode.SessionPermissionRespondParamsResponseReject
+}
+
+type Options struct {
+	Format  Format
+	Approve Approval
+	// Output receives the reply. Defaults to os.Stdout.
+	Output io.Writer
+	// Errors receives failures in the text format. Defaults to os.Stderr.
+	Errors io.Writer
+	// Export is a path to export the session to once it goes idle. With no
+	// prompt, the session given with --session is exported right away.
+	Export       string
+	ExportFormat exporter.Format
+}
+
+// Run sends prompt to the app's initial session, or a new one, and streams the
+// reply until the session goes idle. It returns the process exit code.
+//
+// If opts.Export is set, the session is then exported. With an empty prompt
+// the initial session is only exported.
+func Run(ctx context.Context, a *app.App, prompt string, opts Options) int {
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	if opts.Errors == nil {
+		opts.Errors = os.Stderr
+	}
+	out := newRenderer(opts)
+
+	if prompt == "" && opts.Export == "" {
+		out.error("", errors.New("no prompt given, pass --prompt or pipe it to stdin"))
+		return ExitSetupError
+	}
+	if a.InitialSession != nil && *a.InitialSession != "" {
+		session, err := a.Client.Session.Get(ctx, *a.InitialSession, opencode.SessionGetParams{})
+		if err != nil {
+			out.error("", fmt.Errorf("failed to load session %s: %w", *a.InitialSession, err))
+			return ExitSetupError
+		}
+		a.Session = session
+	}
+	if prompt == "" {
+		if a.Session.ID == "" {
+			out.error("", errors.New("no session to export, pass --session"))
+			return ExitSetupError
+		}
+		exitCode := export(ctx, a, out, opts)
+		out.done(a.Session.ID, exitCode)
+		return exitCode
+	}
+	if err := a.InitializeModel(ctx); err != nil {
+		out.error("", err)
+		return ExitSetupError
+	}
+
+	// Subscribe before sending the prompt so that no events are missed. The
+	// server replays the events missed while reconnecting.
+	ctx, cancel := context.WithCancel(ctx)
+	connected := make(chan struct{})
+	events := a.Client.Event.ListStreamingResumable(ctx, opencode.EventListParams{}, ssestream.ReconnectPolicy{
+		MaxAttempts: reconnectAttempts,
+		OnConnect:   sync.OnceFunc(func() { close(connected) }),
+		OnReconnect: func(attempt int, err error) {
+			slog.Warn("Event stream disconnected, reconnecting", "attempt", attempt, "error", err)
+		},
+	})
+	incoming := make(chan opencode.EventListResponseUnion)
+	stopped := make(chan struct{})
+	// The stream may only be closed once the reader has stopped using it.
+	defer func() {
+		cancel()
+		<-stopped
+		events.Close()
+	}()
+	go func() {
+		defer close(stopped)
+		defer close(incoming)
+		for events.Next() {
+			select {
+			case incoming <- events.Current().AsUnion():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	select {
+	case <-connected:
+	case <-stopped:
+		out.error("", fmt.Errorf("failed to subscribe to events: %w", events.Err()))
+		return ExitSetupError
+	case <-ctx.Done():
+		out.error("", errors.New("interrupted"))
+		return ExitInterrupted
+	}
+
+	_, cmd := a.SendPrompt(ctx, app.Prompt{Text: prompt})
+	if a.Session.ID == "" {
+		// SendPrompt reports a failure to create the session as a toast.
+		err := runCmd(cmd)
+		if err == nil {
+			err = errors.New("failed to create session")
+		}
+		out.error("", err)
+		return ExitSetupError
+	}
+	sessionID := a.Session.ID
+	promptMessageID := a.Messages[len(a.Messages)-1].Info.(opencode.UserMessage).ID
+
+	sessions := map[string]bool{sessionID: true}
+
+	sent := make(chan error, 1)
+	go func() { sent <- runCmd(cmd) }()
+
+	interrupt := func() int {
+		// ctx is done, so the abort needs a context of its own.
+		abortCtx, cancel := context.WithTimeout(context.Background(), abortTimeout)
+		defer cancel()
+		a.Cancel(abortCtx, sessionID)
+		out.error(sessionID, errors.New("interrupted"))
+		return ExitInterrupted
+	}
+
+	exitCode := ExitOK
+	for {
+		select {
+		case <-ctx.Done():
+			return interrupt()
+
+		case err := <-sent:
+			if ctx.Err() != nil {
+				return interrupt()
+			}
+			if err != nil {
+				out.error(sessionID, err)
+				return ExitSessionError
+			}
+
+		case event, ok := <-incoming:
+			if !ok {
+				if ctx.Err() != nil {
+					return interrupt()
+				}
+				if err := events.Err(); err != nil {
+					out.error(sessionID, fmt.Errorf("event stream failed: %w", err))
+				}
+				return ExitSessionError
+			}
+			// Subagents run in child sessions, whose permission requests
+			// have to be answered too.
+			if e, ok := event.(opencode.EventListResponseEventSessionUpdated); ok && sessions[e.Properties.Info.ParentID] {
+				sessions[e.Properties.Info.ID] = true
+			}
+			id, ok := opencode.EventSessionID(event)
+			if !ok || !sessions[id] {
+				continue
+			}
+			if e, ok := event.(opencode.EventListResponseEventPermissionUpdated); ok {
+				response := opts.Approve.response()
+				out.permission(e.Properties, response)
+				_, err := a.Client.Session.Permissions.Respond(
+					ctx,
+					id,
+					e.Properties.ID,
+					opencode.SessionPermissionRespondParams{Response: opencode.F(response)},
+				)
+				if err != nil {
+					slog.Error("Failed to respond to permission request", "error", err)
+				}
+				continue
+			}
+			if id != sessionID {
+				continue
+			}
+
+			switch e := event.(type) {
+			case opencode.EventListResponseEventMessagePartUpdated:
+				if e.Properties.Part.MessageID > promptMessageID {
+					out.part(e.Properties.Part)
+				}
+			case opencode.EventListResponseEventSessionError:
+				err := e.Properties.AsError()
+				if err == nil {
+					continue
+				}
+				out.error(sessionID, err)
+				exitCode = ExitSessionError
+			case opencode.EventListResponseEventSessionIdle:
+				if opts.Export != "" {
+					if code := export(ctx, a, out, opts); code != ExitOK {
+						exitCode = code
+					}
+				}
+				out.done(sessionID, exitCode)
+				return exitCode
+			}
+		}
+	}
+}
+
+// export writes the app's session to opts.Export, returning ExitOK or
+// ExitExportError.
+func export(ctx context.Context, a *app.App, out *renderer, opts Options) int {
+	messages, err := a.ListMessages(ctx, a.Session.ID)
+	if err == nil {
+		err = exporter.WriteFile(opts.Export, opts.ExportFormat, *a.Session, messages, theme.CurrentTheme())
+	}
+	if err != nil {
+		out.error(a.Session.ID, fmt.Errorf("failed to export session: %w", err))
+		return ExitExportError
+	}
+	out.exported(a.Session.ID, opts.Export)
+	return ExitOK
+}
+
+// runCmd runs a command returned by the app, and the commands it batches,
+// returning the first error reported as a toast.
+func runCmd(cmd tea.Cmd) error {
+	if cmd == nil {
+		return nil
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			if err := runCmd(cmd); err != nil {
+				return err
+			}
+		}
+	case toast.ShowToastMsg:
+		return errors.New(msg.Message)
+	}
+	return nil
+}
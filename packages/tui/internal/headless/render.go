@@ -0,0 +1,175 @@
+package headless
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// Record is a line of the JSON output format.
+type Record struct {
+	// Type is one of "text", "reasoning", "tool", "permission", "error",
+	// "export" or "done".
+	Type      string `json:"type"`
+	SessionID string `json:"sessionID,omitempty"`
+	MessageID string `json:"messageID,omitempty"`
+	PartID    string `json:"partID,omitempty"`
+	// Delta is the text appended to a text or reasoning part.
+	Delta string `json:"delta,omitempty"`
+	// Tool, Status and Title describe a tool call whose status changed.
+	Tool   string `json:"tool,omitempty"`
+	Status string `json:"status,omitempty"`
+	Title  string `json:"title,omitempty"`
+	// PermissionID and Response describe how a permission request was
+	// answered.
+	PermissionID string `json:"permissionID,omitempty"`
+	Response     string `json:"response,omitempty"`
+	// Path is the file the session was exported to.
+	Path string `json:"path,omitempty"`
+	// Error is set for errors, and for tool calls which failed.
+	Error string `json:"error,omitempty"`
+	// ExitCode is set on the final "done" record.
+	ExitCode *int `json:"exitCode,omitempty"`
+}
+
+// renderer turns the updates of a run into output, remembering what it has
+// already written so that repeated part updates only write what changed.
+type renderer struct {
+	opts  Options
+	json  *json.Encoder
+	texts map[string]string
+	tools map[string]opencode.ToolPartStateStatus
+	// midLine is true when the text output doesn't end with a newline.
+	midLine bool
+}
+
+func newRenderer(opts Options) *renderer {
+	return &renderer{
+		opts:  opts,
+		json:  json.NewEncoder(opts.Output),
+		texts: map[string]string{},
+		tools: map[string]opencode.ToolPartStateStatus{},
+	}
+}
+
+func (r *renderer) part(part opencode.Part) {
+	switch part.Type {
+	case opencode.PartTypeText, opencode.PartTypeReasoning:
+		previous := r.texts[part.ID]
+		r.texts[part.ID] = part.Text
+		delta := part.Text
+		if strings.HasPrefix(part.Text, previous) {
+			delta = part.Text[len(previous):]
+		}
+		if delta == "" {
+			return
+		}
+		if r.opts.Format == FormatJSON {
+			r.json.Encode(Record{
+				Type:      string(part.Type),
+				SessionID: part.SessionID,
+				MessageID: part.MessageID,
+				PartID:    part.ID,
+				Delta:     delta,
+			})
+			return
+		}
+		// Reasoning is left out of the plain text output.
+		if part.Type == opencode.PartTypeText {
+			r.write(delta)
+		}
+
+	case opencode.PartTypeTool:
+		tool, ok := part.AsUnion().(opencode.ToolPart)
+		if !ok {
+			return
+		}
+		status := tool.State.Status
+		if r.tools[tool.ID] == status || status == opencode.ToolPartStateStatusPending {
+			return
+		}
+		r.tools[tool.ID] = status
+		if r.opts.Format == FormatJSON {
+			r.json.Encode(Record{
+				Type:      "tool",
+				SessionID: tool.SessionID,
+				MessageID: tool.MessageID,
+				PartID:    tool.ID,
+				Tool:      tool.Tool,
+				Status:    string(status),
+				Title:     tool.State.Title,
+				Error:     tool.State.Error,
+			})
+			return
+		}
+		switch status {
+		case opencode.ToolPartStateStatusRunning:
+			r.line(fmt.Sprintf("→ %s %s", tool.Tool, tool.State.Title))
+		case opencode.ToolPartStateStatusCompleted:
+			r.line(fmt.Sprintf("✓ %s %s", tool.Tool, tool.State.Title))
+		case opencode.ToolPartStateStatusError:
+			r.line(fmt.Sprintf("✗ %s: %s", tool.Tool, tool.State.Error))
+		}
+	}
+}
+
+func (r *renderer) permission(permission opencode.Permission, response opencode.SessionPermissionRespondParamsResponse) {
+	if r.opts.Format == FormatJSON {
+		r.json.Encode(Record{
+			Type:         "permission",
+			SessionID:    permission.SessionID,
+			MessageID:    permission.MessageID,
+			PermissionID: permission.ID,
+			Title:        permission.Title,
+			Response:     string(response),
+		})
+		return
+	}
+	r.line(fmt.Sprintf("? %s (%s)", permission.Title, response))
+}
+
+func (r *renderer) error(sessionID string, err error) {
+	if r.opts.Format == FormatJSON {
+		r.json.Encode(Record{Type: "error", SessionID: sessionID, Error: err.Error()})
+		return
+	}
+	r.endLine()
+	fmt.Fprintf(r.opts.Errors, "error: %s\n", err)
+}
+
+func (r *renderer) exported(sessionID string, path string) {
+	if r.opts.Format == FormatJSON {
+		r.json.Encode(Record{Type: "export", SessionID: sessionID, Path: path})
+		return
+	}
+	// The reply goes to the output, so the path is reported with the errors.
+	r.endLine()
+	fmt.Fprintf(r.opts.Errors, "exported to %s\n", path)
+}
+
+func (r *renderer) done(sessionID string, exitCode int) {
+	if r.opts.Format == FormatJSON {
+		r.json.Encode(Record{Type: "done", SessionID: sessionID, ExitCode: &exitCode})
+		return
+	}
+	r.endLine()
+}
+
+func (r *renderer) write(text string) {
+	io.WriteString(r.opts.Output, text)
+	r.midLine = !strings.HasSuffix(text, "\n")
+}
+
+func (r *renderer) line(text string) {
+	r.endLine()
+	r.write(text + "\n")
+}
+
+func (r *renderer) endLine() {
+	if r.midLine {
+		r.write("\n")
+	}
+}
@@ -0,0 +1,74 @@
+package headless
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/util"
+)
+
+func part(t *testing.T, raw string) opencode.Part {
+	t.Helper()
+	var p opencode.Part
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		t.Fatalf("failed to parse part: %v", err)
+	}
+	return p
+}
+
+func feed(t *testing.T, r *renderer) {
+	r.part(part(t, `{"id":"prt_1","messageID":"msg_2","sessionID":"ses_1","type":"text","text":"Hel"}`))
+	r.part(part(t, `{"id":"prt_1","messageID":"msg_2","sessionID":"ses_1","type":"text","text":"Hello"}`))
+	r.part(part(t, `{"id":"prt_2","messageID":"msg_2","sessionID":"ses_1","type":"reasoning","text":"thinking"}`))
+	r.part(part(t, `{"id":"prt_3","messageID":"msg_2","sessionID":"ses_1","type":"tool","callID":"c","tool":"bash","state":{"status":"pending"}}`))
+	r.part(part(t, `{"id":"prt_3","messageID":"msg_2","sessionID":"ses_1","type":"tool","callID":"c","tool":"bash","state":{"status":"running","title":"ls"}}`))
+	r.part(part(t, `{"id":"prt_3","messageID":"msg_2","sessionID":"ses_1","type":"tool","callID":"c","tool":"bash","state":{"status":"running","title":"ls"}}`))
+	r.part(part(t, `{"id":"prt_3","messageID":"msg_2","sessionID":"ses_1","type":"tool","callID":"c","tool":"bash","state":{"status":"completed","title":"ls"}}`))
+	r.done("ses_1", ExitOK)
+}
+
+func TestRenderText(t *testing.T) {
+	var out bytes.Buffer
+	r := newRenderer(Options{Format: FormatText, Output: &out})
+	feed(t, r)
+
+	expected := "Hello\n→ bash ls\n✓ bash ls\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	var out bytes.Buffer
+	r := newRenderer(Options{Format: FormatJSON, Output: &out})
+	feed(t, r)
+
+	var types []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record Record
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid line %q: %v", line, err)
+		}
+		types = append(types, record.Type+":"+record.Delta+record.Status)
+	}
+	expected := []string{"text:Hel", "text:lo", "reasoning:thinking", "tool:running", "tool:completed", "done:"}
+	if strings.Join(types, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, types)
+	}
+}
+
+func TestRunCmdReportsToasts(t *testing.T) {
+	if err := runCmd(tea.Batch(util.CmdHandler("ok"), nil)); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	failed := util.CmdHandler(toast.ShowToastMsg{Message: "failed to send message"})
+	err := runCmd(tea.Batch(util.CmdHandler("ok"), failed))
+	if err == nil || err.Error() != "failed to send message" {
+		t.Errorf("expected the toast to be reported, got %v", err)
+	}
+}
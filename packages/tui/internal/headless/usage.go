@@ -0,0 +1,130 @@
+package headless
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/usage"
+)
+
+type UsageOptions struct {
+	Format Format
+	// Days is how many days the report covers, up to today. Zero covers all
+	// time.
+	Days int
+	// CSV is a path to export the aggregated rows to, "-" writes them to
+	// Output in place of the report.
+	CSV string
+	// Output receives the report. Defaults to os.Stdout.
+	Output io.Writer
+	// Errors receives failures. Defaults to os.Stderr.
+	Errors io.Writer
+}
+
+// Usage reports the tokens and cost of the assistant messages of every
+// session by day, model, provider and agent. It returns the process exit
+// code.
+func Usage(ctx context.Context, client *opencode.Client, opts UsageOptions) int {
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	if opts.Errors == nil {
+		opts.Errors = os.Stderr
+	}
+
+	since := time.Time{}
+	if opts.Days > 0 {
+		now := time.Now()
+		since = time.Date(now.Year(), now.Month(), now.Day()-opts.Days+1, 0, 0, 0, 0, now.Location())
+	}
+	report, err := usage.Collect(ctx, client, since)
+	if err != nil {
+		fmt.Fprintln(opts.Errors, "error: failed to collect usage:", err)
+		return ExitSetupError
+	}
+
+	if opts.CSV != "" {
+		if err := writeUsageCSV(opts.CSV, opts.Output, report); err != nil {
+			fmt.Fprintln(opts.Errors, "error: failed to export usage:", err)
+			return ExitExportError
+		}
+		if opts.CSV == "-" {
+			return ExitOK
+		}
+	}
+
+	if opts.Format == FormatJSON {
+		encoder := json.NewEncoder(opts.Output)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintln(opts.Errors, "error:", err)
+			return ExitSessionError
+		}
+		return ExitOK
+	}
+	writeUsage(opts.Output, report, opts.Days)
+	return ExitOK
+}
+
+func writeUsageCSV(path string, stdout io.Writer, report *usage.Report) error {
+	if path == "-" {
+		return usage.WriteCSV(stdout, report)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := usage.WriteCSV(file, report); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeUsage writes the report as a table per grouping, after the totals and
+// the daily cost trend
+func writeUsage(w io.Writer, report *usage.Report, days int) {
+	period := "all time"
+	if days > 0 {
+		period = fmt.Sprintf("the last %d days", days)
+	}
+	fmt.Fprintf(w, "Usage over %s: %d sessions, %d replies, $%.2f\n", period, report.Sessions, report.Total.Messages, report.Total.Cost)
+	if report.Total.Messages == 0 {
+		return
+	}
+	if days > 0 {
+		values := make([]float64, days)
+		for i, day := range report.Daily(usage.ByDay, "", days, time.Now()) {
+			values[i] = day.Cost
+		}
+		fmt.Fprintf(w, "Daily cost %s\n", usage.Sparkline(values, 60))
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, grouping := range usage.Groupings {
+		fmt.Fprintln(table)
+		fmt.Fprintf(table, "%s\treplies\tinput\toutput\treasoning\tcache read\tcache write\tcost\t\n", strings.ToUpper(string(grouping)))
+		for _, row := range report.Groups[grouping] {
+			fmt.Fprintf(
+				table,
+				"%s\t%d\t%s\t%s\t%s\t%s\t%s\t$%.2f\t\n",
+				row.Key,
+				row.Messages,
+				usage.FormatTokens(row.Input),
+				usage.FormatTokens(row.Output),
+				usage.FormatTokens(row.Reasoning),
+				usage.FormatTokens(row.CacheRead),
+				usage.FormatTokens(row.CacheWrite),
+				row.Cost,
+			)
+		}
+	}
+	table.Flush()
+}
@@ -0,0 +1,132 @@
+// Package notify sends desktop and terminal notifications for the events of
+// the sessions, while the terminal is not focused.
+package notify
+
+import (
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+)
+
+// Event is a kind of event notified
+type Event string
+
+const (
+	EventIdle       Event = "idle"
+	EventError      Event = "error"
+	EventPermission Event = "permission"
+)
+
+// Method is how a notification is delivered
+type Method = opencode.NotificationMethodConfig
+
+const (
+	MethodOSC9    = opencode.NotificationMethodConfigOsc9
+	MethodOSC777  = opencode.NotificationMethodConfigOsc777
+	MethodBell    = opencode.NotificationMethodConfigBell
+	MethodCommand = opencode.NotificationMethodConfigCommand
+	MethodNone    = opencode.NotificationMethodConfigNone
+)
+
+// Notifier sends the notifications of the events with the method configured
+// for each, unless the terminal has the focus
+type Notifier struct {
+	methods map[Event]Method
+	command string
+	// focused is whether the terminal has the focus, as last reported. Until
+	// it reports it, notifications are always sent.
+	focused bool
+}
+
+// New creates a notifier from the configuration, notifying every event by
+// OSC 9 unless configured otherwise
+func New(config opencode.ConfigTuiNotifications) *Notifier {
+	n := &Notifier{
+		methods: map[Event]Method{
+			EventIdle:       config.Idle,
+			EventError:      config.Error,
+			EventPermission: config.Permission,
+		},
+		command: config.Command,
+	}
+	for event, method := range n.methods {
+		if !method.IsKnown() {
+			n.methods[event] = MethodOSC9
+		}
+	}
+	return n
+}
+
+// SetFocused records whether the terminal has the focus, from its focus and
+// blur reports
+func (n *Notifier) SetFocused(focused bool) {
+	n.focused = focused
+}
+
+// Notify sends the notification of the event
+func (n *Notifier) Notify(event Event, title, body string) tea.Cmd {
+	if n == nil || n.focused {
+		return nil
+	}
+	method := n.methods[event]
+	switch method {
+	case MethodNone, "":
+		return nil
+	case MethodCommand:
+		if n.command == "" {
+			return nil
+		}
+		return n.run(title, body)
+	}
+	return tea.Raw(Sequence(method, title, body, os.Getenv("TMUX") != ""))
+}
+
+// run runs the command of the command method, with the title and body as its
+// last arguments
+func (n *Notifier) run(title, body string) tea.Cmd {
+	return func() tea.Msg {
+		args := strings.Fields(n.command)
+		args = append(args, title, body)
+		if err := exec.Command(args[0], args[1:]...).Run(); err != nil {
+			slog.Error("Failed to run notification command", "command", n.command, "error", err)
+		}
+		return nil
+	}
+}
+
+// Sequence returns the escape sequence delivering the notification with the
+// method, wrapped to pass through tmux
+func Sequence(method Method, title, body string, tmux bool) string {
+	title, body = sanitize(title), sanitize(body)
+	var sequence string
+	switch method {
+	case MethodOSC777:
+		sequence = "\x1b]777;notify;" + strings.ReplaceAll(title, ";", ",") + ";" + body + "\x07"
+	case MethodBell:
+		return "\a"
+	default:
+		sequence = "\x1b]9;" + title + ": " + body + "\x07"
+	}
+	if tmux {
+		sequence = "\x1bPtmux;" + strings.ReplaceAll(sequence, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return sequence
+}
+
+// sanitize keeps the text on a line, without the control characters that
+// would end the sequence
+func sanitize(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return ' '
+		case r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0):
+			return -1
+		}
+		return r
+	}, text)
+}
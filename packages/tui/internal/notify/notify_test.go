@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+func TestSequence(t *testing.T) {
+	tests := []struct {
+		method Method
+		tmux   bool
+		want   string
+	}{
+		{MethodOSC9, false, "\x1b]9;opencode: done here\x07"},
+		{MethodOSC777, false, "\x1b]777;notify;opencode;done here\x07"},
+		{MethodBell, true, "\a"},
+		{MethodOSC9, true, "\x1bPtmux;\x1b\x1b]9;opencode: done here\x07\x1b\\"},
+	}
+	for _, test := range tests {
+		if got := Sequence(test.method, "opencode", "done\n\x1bhere", test.tmux); got != test.want {
+			t.Errorf("Sequence(%s, tmux %v) = %q, want %q", test.method, test.tmux, got, test.want)
+		}
+	}
+}
+
+func TestNotify(t *testing.T) {
+	n := New(opencode.ConfigTuiNotifications{Error: MethodNone, Permission: MethodCommand})
+	if n.methods[EventIdle] != MethodOSC9 {
+		t.Errorf("expected OSC 9 by default, got %q", n.methods[EventIdle])
+	}
+	if n.Notify(EventIdle, "opencode", "done") == nil {
+		t.Error("expected a notification before the terminal reports its focus")
+	}
+	if n.Notify(EventError, "opencode", "failed") != nil {
+		t.Error("expected no notification for a disabled event")
+	}
+	if n.Notify(EventPermission, "opencode", "asked") != nil {
+		t.Error("expected no notification without a command")
+	}
+	n.SetFocused(true)
+	if n.Notify(EventIdle, "opencode", "done") != nil {
+		t.Error("expected no notification while focused")
+	}
+}
@@ -0,0 +1,240 @@
+// Package search indexes the messages of a project's sessions, so that they
+// can be searched without fetching every session again.
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sst/opencode-sdk-go"
+	"golang.org/x/sync/errgroup"
+)
+
+// Version is the version of the cache format, a cache with another version is
+// rebuilt
+const Version = 1
+
+// concurrency is how many sessions are fetched at once when updating
+const concurrency = 4
+
+// Index holds the searchable text of each session
+type Index struct {
+	Version  int                 `json:"version"`
+	Sessions map[string]*Session `json:"sessions"`
+
+	path string
+	mu   sync.RWMutex
+}
+
+// Session is an indexed session
+type Session struct {
+	Title    string    `json:"title"`
+	Updated  float64   `json:"updated"`
+	Messages []Message `json:"messages"`
+}
+
+// Message is the searchable text of a message
+type Message struct {
+	ID   string `json:"id"`
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+// CachePath returns where the index of a project is cached
+func CachePath(stateDir string, projectID string) string {
+	return filepath.Join(stateDir, "search", projectID+".json")
+}
+
+// New creates an empty index cached at path
+func New(path string) *Index {
+	return &Index{Version: Version, Sessions: map[string]*Session{}, path: path}
+}
+
+// Open loads the index cached at path. A missing or outdated cache gives an
+// empty index.
+func Open(path string) (*Index, error) {
+	index := New(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cached Index
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, fmt.Errorf("failed to decode search index %s: %w", path, err)
+	}
+	if cached.Version == Version && cached.Sessions != nil {
+		index.Sessions = cached.Sessions
+	}
+	return index, nil
+}
+
+// Save writes the index to its cache file
+func (i *Index) Save() error {
+	i.mu.RLock()
+	data, err := json.Marshal(i)
+	i.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	// The index holds the text of every conversation, keep it private
+	if err := os.MkdirAll(filepath.Dir(i.path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(i.path, data, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of a cache written by an earlier version
+	return os.Chmod(i.path, 0600)
+}
+
+// Update indexes the sessions that changed since they were last indexed, and
+// drops the ones that were deleted. It reports whether the index changed.
+func (i *Index) Update(ctx context.Context, client *opencode.Client, sessions []opencode.Session) (bool, error) {
+	live := map[string]bool{}
+	var stale []opencode.Session
+	i.mu.RLock()
+	for _, session := range sessions {
+		// Child sessions, such as those of subagents, are indexed on their
+		// own and a hit opens the child session itself
+		live[session.ID] = true
+		if cached, ok := i.Sessions[session.ID]; !ok || cached.Updated != session.Time.Updated {
+			stale = append(stale, session)
+		}
+	}
+	i.mu.RUnlock()
+
+	changed := len(stale) > 0
+	i.mu.Lock()
+	for id := range i.Sessions {
+		if !live[id] {
+			delete(i.Sessions, id)
+			changed = true
+		}
+	}
+	i.mu.Unlock()
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(concurrency)
+	for _, session := range stale {
+		group.Go(func() error {
+			response, err := client.Session.Messages(ctx, session.ID, opencode.SessionMessagesParams{})
+			if err != nil {
+				return fmt.Errorf("failed to index session %s: %w", session.ID, err)
+			}
+			indexed := &Session{Title: session.Title, Updated: session.Time.Updated}
+			if response != nil {
+				for _, message := range *response {
+					indexed.Messages = appendMessage(indexed.Messages, message)
+				}
+			}
+			i.mu.Lock()
+			i.Sessions[session.ID] = indexed
+			i.mu.Unlock()
+			return nil
+		})
+	}
+	return changed, group.Wait()
+}
+
+// appendMessage appends the searchable text of the message, which is the
+// text written by the user and the assistant along with the titles of tool
+// calls. Synthetic text, such as attached files, is left out.
+func appendMessage(messages []Message, message opencode.SessionMessagesResponse) []Message {
+	var text []string
+	for _, part := range message.Parts {
+		switch part := part.AsUnion().(type) {
+		case opencode.TextPart:
+			if !part.Synthetic && strings.TrimSpace(part.Text) != "" {
+				text = append(text, strings.TrimSpace(part.Text))
+			}
+		case opencode.ToolPart:
+			if part.State.Title != "" {
+				text = append(text, part.State.Title)
+			}
+		}
+	}
+	if len(text) == 0 {
+		return messages
+	}
+	role := "user"
+	if _, ok := message.Info.AsUnion().(opencode.AssistantMessage); ok {
+		role = "assistant"
+	}
+	return append(messages, Message{ID: message.Info.ID, Role: role, Text: strings.Join(text, "\n")})
+}
+
+// Hit is a message matching a query
+type Hit struct {
+	SessionID string
+	Title     string
+	MessageID string
+	Role      string
+	// Snippet is the text around the first match, on a single line
+	Snippet string
+	// Matches are the byte ranges of the query terms in the snippet
+	Matches [][]int
+}
+
+// Search returns the messages holding every term of the query, in the most
+// recently updated sessions first, up to limit hits.
+func (i *Index) Search(query string, limit int) []Hit {
+	terms := compileTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	ids := make([]string, 0, len(i.Sessions))
+	for id := range i.Sessions {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		return i.Sessions[ids[a]].Updated > i.Sessions[ids[b]].Updated
+	})
+
+	var hits []Hit
+	for _, id := range ids {
+		session := i.Sessions[id]
+		for _, message := range session.Messages {
+			first := -1
+			for _, term := range terms {
+				loc := term.FindStringIndex(message.Text)
+				if loc == nil {
+					first = -1
+					break
+				}
+				if first < 0 || loc[0] < first {
+					first = loc[0]
+				}
+			}
+			if first < 0 {
+				continue
+			}
+			snippet := snippet(message.Text, first)
+			hits = append(hits, Hit{
+				SessionID: id,
+				Title:     session.Title,
+				MessageID: message.ID,
+				Role:      message.Role,
+				Snippet:   snippet,
+				Matches:   findTerms(snippet, terms),
+			})
+			if len(hits) == limit {
+				return hits
+			}
+		}
+	}
+	return hits
+}
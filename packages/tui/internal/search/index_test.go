@@ -0,0 +1,140 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/option"
+)
+
+const messages = `[
+  {
+    "info": {"id":"msg_1","role":"user","sessionID":"ses_1","time":{"created":1}},
+    "parts": [
+      {"id":"prt_1","messageID":"msg_1","sessionID":"ses_1","type":"text","text":"The migration fails on\nstartup"},
+      {"id":"prt_2","messageID":"msg_1","sessionID":"ses_1","type":"text","text":"migration secret","synthetic":true}
+    ]
+  },
+  {
+    "info": {"id":"msg_2","role":"assistant","sessionID":"ses_1","cost":0,"mode":"build","modelID":"m","providerID":"p","path":{"cwd":"/","root":"/"},"system":[],"time":{"created":2},"tokens":{"input":0,"output":0,"reasoning":0,"cache":{"read":0,"write":0}}},
+    "parts": [
+      {"id":"prt_3","messageID":"msg_2","sessionID":"ses_1","type":"tool","callID":"c","tool":"edit","state":{"status":"completed","title":"db/migrate.go","input":{},"output":"","metadata":{},"time":{"start":1,"end":2}}},
+      {"id":"prt_4","messageID":"msg_2","sessionID":"ses_1","type":"text","text":"Fixed the Migration bug"}
+    ]
+  }
+]`
+
+const childMessages = `[
+  {
+    "info": {"id":"msg_3","role":"user","sessionID":"ses_2","time":{"created":3}},
+    "parts": [{"id":"prt_5","messageID":"msg_3","sessionID":"ses_2","type":"text","text":"Find the flaky test"}]
+  }
+]`
+
+func TestUpdateAndSearch(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/session/ses_1/message":
+			w.Write([]byte(messages))
+		case "/session/ses_2/message":
+			w.Write([]byte(childMessages))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client := opencode.NewClient(option.WithBaseURL(server.URL))
+
+	path := CachePath(t.TempDir(), "project")
+	index, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index.Sessions["ses_gone"] = &Session{Title: "Deleted"}
+	sessions := []opencode.Session{
+		{ID: "ses_1", Title: "Fix the migration", Time: opencode.SessionTime{Updated: 10}},
+		{ID: "ses_2", ParentID: "ses_1", Title: "Subagent"},
+	}
+	changed, err := index.Update(context.Background(), client, sessions)
+	if err != nil || !changed {
+		t.Fatalf("expected the index to change, got %v %v", changed, err)
+	}
+	if _, ok := index.Sessions["ses_gone"]; ok {
+		t.Error("expected deleted sessions to be dropped")
+	}
+	if err := index.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("expected the cache to be private, got %v", info.Mode())
+	}
+
+	// The cached session is up to date
+	index, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err = index.Update(context.Background(), client, sessions)
+	if err != nil || changed || requests.Load() != 2 {
+		t.Fatalf("expected no update, got %v %v after %d requests", changed, err, requests.Load())
+	}
+
+	hits := index.Search("MIGRATION", 10)
+	if len(hits) != 2 || hits[0].MessageID != "msg_1" || hits[1].Role != "assistant" {
+		t.Fatalf("unexpected hits %+v", hits)
+	}
+	if hits[0].Snippet != "The migration fails on startup" {
+		t.Errorf("unexpected snippet %q", hits[0].Snippet)
+	}
+	if len(hits[0].Matches) != 1 || hits[0].Snippet[hits[0].Matches[0][0]:hits[0].Matches[0][1]] != "migration" {
+		t.Errorf("unexpected matches %v", hits[0].Matches)
+	}
+
+	if hits := index.Search("flaky", 10); len(hits) != 1 || hits[0].SessionID != "ses_2" {
+		t.Errorf("expected child sessions to be indexed, got %+v", hits)
+	}
+	if hits := index.Search("secret", 10); len(hits) != 0 {
+		t.Errorf("expected synthetic text to be left out, got %+v", hits)
+	}
+	if hits := index.Search("migrate bug", 10); len(hits) != 1 || hits[0].MessageID != "msg_2" {
+		t.Errorf("expected every term to match, got %+v", hits)
+	}
+	if hits := index.Search("migration", 1); len(hits) != 1 {
+		t.Errorf("expected the hits to be limited, got %d", len(hits))
+	}
+}
+
+func TestOpenOutdatedCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	index := &Index{Version: Version + 1, Sessions: map[string]*Session{"ses_1": {}}, path: path}
+	if err := index.Save(); err != nil {
+		t.Fatal(err)
+	}
+	index, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Sessions) != 0 {
+		t.Errorf("expected an outdated cache to be dropped, got %v", index.Sessions)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := strings.Repeat("word ", 20) + "needle" + strings.Repeat(" tail", 100)
+	s := snippet(text, strings.Index(text, "needle"))
+	if !strings.HasPrefix(s, "…word ") || !strings.Contains(s, "needle") || len(s) > snippetLength+len("…") {
+		t.Errorf("unexpected snippet %q", s)
+	}
+}
@@ -0,0 +1,75 @@
+package search
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// snippetBefore is how much text is kept before the first match
+	snippetBefore = 40
+	// snippetLength is the most text kept in a snippet
+	snippetLength = 240
+)
+
+// compileTerms splits the query into terms, matched case insensitively
+func compileTerms(query string) []*regexp.Regexp {
+	var terms []*regexp.Regexp
+	for _, field := range strings.Fields(query) {
+		terms = append(terms, regexp.MustCompile("(?i)"+regexp.QuoteMeta(field)))
+	}
+	return terms
+}
+
+// findTerms returns the byte ranges of the terms in text, in order and
+// merged where they overlap
+func findTerms(text string, terms []*regexp.Regexp) [][]int {
+	var matches [][]int
+	for _, term := range terms {
+		matches = append(matches, term.FindAllStringIndex(text, -1)...)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i][0] < matches[j][0] })
+
+	var merged [][]int
+	for _, match := range matches {
+		if last := len(merged) - 1; last >= 0 && match[0] <= merged[last][1] {
+			merged[last][1] = max(merged[last][1], match[1])
+			continue
+		}
+		merged = append(merged, match)
+	}
+	return merged
+}
+
+// snippet cuts the text around the byte offset at, starting at a word
+// boundary, and puts it on a single line
+func snippet(text string, at int) string {
+	start := max(0, at-snippetBefore)
+	if start > 0 {
+		// Don't start in the middle of a word
+		if space := strings.IndexFunc(text[start:at], unicode.IsSpace); space >= 0 {
+			start += space + 1
+		}
+		for start < at && !utf8.RuneStart(text[start]) {
+			start++
+		}
+	}
+	end := min(len(text), start+snippetLength)
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	result := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		return r
+	}, text[start:end])
+	if start > 0 {
+		result = "…" + result
+	}
+	return result
+}
@@ -3,6 +3,7 @@ package tui
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -20,13 +21,18 @@ import (
 	"github.com/sst/opencode/internal/app"
 	"github.com/sst/opencode/internal/commands"
 	"github.com/sst/opencode/internal/completions"
+	"github.com/sst/opencode/internal/components/changes"
 	"github.com/sst/opencode/internal/components/chat"
 	cmdcomp "github.com/sst/opencode/internal/components/commands"
+	"github.com/sst/opencode/internal/components/diagnostics"
 	"github.com/sst/opencode/internal/components/dialog"
 	"github.com/sst/opencode/internal/components/modal"
 	"github.com/sst/opencode/internal/components/status"
 	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/components/todos"
+	"github.com/sst/opencode/internal/exporter"
 	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/notify"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
 	"github.com/sst/opencode/internal/util"
@@ -57,6 +63,15 @@ const (
 const interruptDebounceTimeout = 1 * time.Second
 const exitDebounceTimeout = 1 * time.Second
 
+const (
+	// sidebarWidth is the width of the sidebar right of the messages, gap
+	// included
+	sidebarWidth = 34
+	// sidebarMinWidth is the width of the terminal below which the sidebar
+	// is hidden
+	sidebarMinWidth = 110
+)
+
 type Model struct {
 	tea.Model
 	tea.CursorModel
@@ -66,17 +81,24 @@ type Model struct {
 	status               status.StatusComponent
 	editor               chat.EditorComponent
 	messages             chat.MessagesComponent
+	diagnostics          diagnostics.DiagnosticsComponent
+	todos                todos.TodosComponent
+	changes              changes.ChangesComponent
 	completions          dialog.CompletionDialog
 	commandProvider      completions.CompletionProvider
 	fileProvider         completions.CompletionProvider
 	symbolsProvider      completions.CompletionProvider
 	agentsProvider       completions.CompletionProvider
+	snippetsProvider     completions.CompletionProvider
 	showCompletionDialog bool
 	leaderBinding        *key.Binding
 	toastManager         *toast.ToastManager
 	interruptKeyState    InterruptKeyState
 	exitKeyState         ExitKeyState
 	messagesRight        bool
+	// messagesSize is the size the messages were last given
+	messagesSize tea.WindowSizeMsg
+	notifier     *notify.Notifier
 }
 
 func (a Model) Init() tea.Cmd {
@@ -86,6 +108,8 @@ func (a Model) Init() tea.Cmd {
 	if !util.IsWsl() {
 		cmds = append(cmds, tea.RequestBackgroundColor)
 	}
+	// Focus and blur events are handled in Update
+	cmds = append(cmds, tea.EnableReportFocus)
 	cmds = append(cmds, a.app.InitializeProvider())
 	cmds = append(cmds, a.editor.Init())
 	cmds = append(cmds, a.messages.Init())
@@ -104,17 +128,8 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyPressMsg:
 		keyString := msg.String()
 
-		if a.app.CurrentPermission.ID != "" {
+		if a.app.CurrentPermission.ID != "" && a.modal == nil {
 			if keyString == "enter" || keyString == "esc" || keyString == "a" {
-				sessionID := a.app.CurrentPermission.SessionID
-				permissionID := a.app.CurrentPermission.ID
-				a.editor.Focus()
-				a.app.Permissions = a.app.Permissions[1:]
-				if len(a.app.Permissions) > 0 {
-					a.app.CurrentPermission = a.app.Permissions[0]
-				} else {
-					a.app.CurrentPermission = opencode.Permission{}
-				}
 				response := opencode.SessionPermissionRespondParamsResponseOnce
 				switch keyString {
 				case "enter":
@@ -124,21 +139,8 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				case "esc":
 					response = opencode.SessionPermissionRespondParamsResponseReject
 				}
-
-				return a, func() tea.Msg {
-					resp, err := a.app.Client.Session.Permissions.Respond(
-						context.Background(),
-						sessionID,
-						permissionID,
-						opencode.SessionPermissionRespondParams{Response: opencode.F(response)},
-					)
-					if err != nil {
-						slog.Error("Failed to respond to permission request", "error", err)
-						return toast.NewErrorToast("Failed to respond to permission request")()
-					}
-					slog.Debug("Responded to permission request", "response", resp)
-					return nil
-				}
+				a.editor.Focus()
+				return a, a.app.RespondPermission(a.app.CurrentPermission, response, "")
 			}
 		}
 
@@ -185,6 +187,48 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, cmd
 		}
 
+		// Handle the messages search bar, it keeps the focus until closed
+		if a.messages.Searching() {
+			updated, cmd := a.messages.Update(msg)
+			a.messages = updated.(chat.MessagesComponent)
+			cmds = append(cmds, cmd)
+			if !a.messages.Searching() {
+				_, cmd := a.editor.Focus()
+				cmds = append(cmds, cmd)
+			}
+			return a, tea.Batch(cmds...)
+		}
+
+		// Handle the diagnostics panel while it has the focus
+		if a.diagnostics.Focused() && a.app.Session.ID != "" {
+			switch keyString {
+			case "up", "down", "k", "j", "ctrl+p", "ctrl+n", "space", "enter", "esc", "tab":
+				updated, cmd := a.diagnostics.Update(msg)
+				a.diagnostics = updated.(diagnostics.DiagnosticsComponent)
+				cmds = append(cmds, cmd)
+				if !a.diagnostics.Focused() {
+					_, cmd := a.editor.Focus()
+					cmds = append(cmds, cmd)
+				}
+				return a, tea.Batch(cmds...)
+			}
+		}
+
+		// Handle the changed files panel while it has the focus
+		if a.changes.Focused() && a.sidebarWidth() > 0 {
+			switch keyString {
+			case "up", "down", "k", "j", "ctrl+p", "ctrl+n", "enter", "esc", "tab":
+				updated, cmd := a.changes.Update(msg)
+				a.changes = updated.(changes.ChangesComponent)
+				cmds = append(cmds, cmd)
+				if !a.changes.Focused() {
+					_, cmd := a.editor.Focus()
+					cmds = append(cmds, cmd)
+				}
+				return a, tea.Batch(cmds...)
+			}
+		}
+
 		// 2. Check for commands that require leader
 		if a.app.IsLeaderSequence {
 			matches := a.app.Commands.Matches(msg, a.app.IsLeaderSequence)
@@ -198,6 +242,7 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if keyString == "/" &&
 			!a.showCompletionDialog &&
 			a.editor.Value() == "" &&
+			a.editor.Inserting() &&
 			!a.app.IsBashMode {
 			a.showCompletionDialog = true
 
@@ -217,6 +262,7 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Handle file completions trigger
 		if keyString == "@" &&
 			!a.showCompletionDialog &&
+			a.editor.Inserting() &&
 			!a.app.IsBashMode {
 			a.showCompletionDialog = true
 
@@ -233,7 +279,27 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, tea.Sequence(cmds...)
 		}
 
-		if keyString == "!" && a.editor.Value() == "" {
+		// Handle snippet completions trigger
+		if keyString == "#" &&
+			!a.showCompletionDialog &&
+			len(a.app.State.Snippets) > 0 &&
+			a.editor.Inserting() &&
+			!a.app.IsBashMode {
+			a.showCompletionDialog = true
+
+			updated, cmd := a.editor.Update(msg)
+			a.editor = updated.(chat.EditorComponent)
+			cmds = append(cmds, cmd)
+
+			a.completions = dialog.NewCompletionDialogComponent("#", a.snippetsProvider)
+			updated, cmd = a.completions.Update(msg)
+			a.completions = updated.(dialog.CompletionDialog)
+			cmds = append(cmds, cmd)
+
+			return a, tea.Sequence(cmds...)
+		}
+
+		if keyString == "!" && a.editor.Value() == "" && a.editor.Inserting() {
 			a.app.IsBashMode = true
 			return a, nil
 		}
@@ -258,6 +324,21 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, tea.Batch(cmds...)
 		}
 
+		// In vim mode esc leaves the insert and visual modes, and cancels
+		// pending commands, before it interrupts the session
+		if keyString == "esc" && a.editor.VimPending() {
+			updated, cmd := a.editor.Update(msg)
+			a.editor = updated.(chat.EditorComponent)
+			return a, cmd
+		}
+
+		// Tab moves through the tab-stops of an inserted snippet, before it
+		// cycles agents
+		if keyString == "tab" && a.editor.InTabStops() {
+			a.editor.NextTabStop()
+			return a, nil
+		}
+
 		// 4. Maximize editor responsiveness for printable characters
 		if msg.Text != "" {
 			updated, cmd := a.editor.Update(msg)
@@ -468,171 +549,71 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				)
 		*/
 	case opencode.EventListResponseEventSessionDeleted:
-		if a.app.Session != nil && msg.Properties.Info.ID == a.app.Session.ID {
+		if index := a.app.TabIndex(msg.Properties.Info.ID); index > -1 && len(a.app.Tabs) > 1 {
+			active := index == a.app.ActiveTab()
+			a.app.CloseTab(index)
+			if active {
+				cmds = append(cmds, util.CmdHandler(app.SessionLoadedMsg{}))
+			}
+		} else if a.app.Session != nil && msg.Properties.Info.ID == a.app.Session.ID {
 			a.app.Session = &opencode.Session{}
 			a.app.Messages = []app.Message{}
 		}
-		return a, toast.NewSuccessToast("Session deleted successfully")
+		cmds = append(cmds, toast.NewSuccessToast("Session deleted successfully"))
+		return a, tea.Batch(cmds...)
 	case opencode.EventListResponseEventSessionUpdated:
 		if msg.Properties.Info.ID == a.app.Session.ID {
 			a.app.Session = &msg.Properties.Info
+		} else if tab := a.app.Tab(msg.Properties.Info.ID); tab != nil {
+			tab.Session = &msg.Properties.Info
 		}
 	case opencode.EventListResponseEventMessagePartUpdated:
 		slog.Debug("message part updated", "message", msg.Properties.Part.MessageID, "part", msg.Properties.Part.ID)
-		if msg.Properties.Part.SessionID == a.app.Session.ID {
-			messageIndex := slices.IndexFunc(a.app.Messages, func(m app.Message) bool {
-				switch casted := m.Info.(type) {
-				case opencode.UserMessage:
-					return casted.ID == msg.Properties.Part.MessageID
-				case opencode.AssistantMessage:
-					return casted.ID == msg.Properties.Part.MessageID
-				}
-				return false
-			})
-			if messageIndex > -1 {
-				message := a.app.Messages[messageIndex]
-				partIndex := slices.IndexFunc(message.Parts, func(p opencode.PartUnion) bool {
-					switch casted := p.(type) {
-					case opencode.TextPart:
-						return casted.ID == msg.Properties.Part.ID
-					case opencode.ReasoningPart:
-						return casted.ID == msg.Properties.Part.ID
-					case opencode.FilePart:
-						return casted.ID == msg.Properties.Part.ID
-					case opencode.ToolPart:
-						return casted.ID == msg.Properties.Part.ID
-					case opencode.StepStartPart:
-						return casted.ID == msg.Properties.Part.ID
-					case opencode.StepFinishPart:
-						return casted.ID == msg.Properties.Part.ID
-					}
-					return false
-				})
-				if partIndex > -1 {
-					message.Parts[partIndex] = msg.Properties.Part.AsUnion()
-				}
-				if partIndex == -1 {
-					message.Parts = append(message.Parts, msg.Properties.Part.AsUnion())
-				}
-				a.app.Messages[messageIndex] = message
-			}
+		part := msg.Properties.Part
+		if part.SessionID == a.app.Session.ID {
+			a.app.Messages = app.UpdatePart(a.app.Messages, part.MessageID, part.AsUnion())
+		} else if tab := a.app.Tab(part.SessionID); tab != nil {
+			tab.Messages = app.UpdatePart(tab.Messages, part.MessageID, part.AsUnion())
 		}
 	case opencode.EventListResponseEventMessagePartRemoved:
 		slog.Debug("message part removed", "session", msg.Properties.SessionID, "message", msg.Properties.MessageID, "part", msg.Properties.PartID)
 		if msg.Properties.SessionID == a.app.Session.ID {
-			messageIndex := slices.IndexFunc(a.app.Messages, func(m app.Message) bool {
-				switch casted := m.Info.(type) {
-				case opencode.UserMessage:
-					return casted.ID == msg.Properties.MessageID
-				case opencode.AssistantMessage:
-					return casted.ID == msg.Properties.MessageID
-				}
-				return false
-			})
-			if messageIndex > -1 {
-				message := a.app.Messages[messageIndex]
-				partIndex := slices.IndexFunc(message.Parts, func(p opencode.PartUnion) bool {
-					switch casted := p.(type) {
-					case opencode.TextPart:
-						return casted.ID == msg.Properties.PartID
-					case opencode.ReasoningPart:
-						return casted.ID == msg.Properties.PartID
-					case opencode.FilePart:
-						return casted.ID == msg.Properties.PartID
-					case opencode.ToolPart:
-						return casted.ID == msg.Properties.PartID
-					case opencode.StepStartPart:
-						return casted.ID == msg.Properties.PartID
-					case opencode.StepFinishPart:
-						return casted.ID == msg.Properties.PartID
-					}
-					return false
-				})
-				if partIndex > -1 {
-					// Remove the part at partIndex
-					message.Parts = append(message.Parts[:partIndex], message.Parts[partIndex+1:]...)
-					a.app.Messages[messageIndex] = message
-				}
-			}
+			a.app.Messages = app.RemovePart(a.app.Messages, msg.Properties.MessageID, msg.Properties.PartID)
+		} else if tab := a.app.Tab(msg.Properties.SessionID); tab != nil {
+			tab.Messages = app.RemovePart(tab.Messages, msg.Properties.MessageID, msg.Properties.PartID)
 		}
 	case opencode.EventListResponseEventMessageRemoved:
 		slog.Debug("message removed", "session", msg.Properties.SessionID, "message", msg.Properties.MessageID)
 		if msg.Properties.SessionID == a.app.Session.ID {
-			messageIndex := slices.IndexFunc(a.app.Messages, func(m app.Message) bool {
-				switch casted := m.Info.(type) {
-				case opencode.UserMessage:
-					return casted.ID == msg.Properties.MessageID
-				case opencode.AssistantMessage:
-					return casted.ID == msg.Properties.MessageID
-				}
-				return false
-			})
-			if messageIndex > -1 {
-				a.app.Messages = append(a.app.Messages[:messageIndex], a.app.Messages[messageIndex+1:]...)
-			}
+			a.app.Messages = app.RemoveMessage(a.app.Messages, msg.Properties.MessageID)
+		} else if tab := a.app.Tab(msg.Properties.SessionID); tab != nil {
+			tab.Messages = app.RemoveMessage(tab.Messages, msg.Properties.MessageID)
 		}
 	case opencode.EventListResponseEventMessageUpdated:
 		if msg.Properties.Info.SessionID == a.app.Session.ID {
-			matchIndex := slices.IndexFunc(a.app.Messages, func(m app.Message) bool {
-				switch casted := m.Info.(type) {
-				case opencode.UserMessage:
-					return casted.ID == msg.Properties.Info.ID
-				case opencode.AssistantMessage:
-					return casted.ID == msg.Properties.Info.ID
-				}
-				return false
-			})
-
-			if matchIndex > -1 {
-				match := a.app.Messages[matchIndex]
-				a.app.Messages[matchIndex] = app.Message{
-					Info:  msg.Properties.Info.AsUnion(),
-					Parts: match.Parts,
-				}
-			}
-
-			if matchIndex == -1 {
-				// Extract the new message ID
-				var newMessageID string
-				switch casted := msg.Properties.Info.AsUnion().(type) {
-				case opencode.UserMessage:
-					newMessageID = casted.ID
-				case opencode.AssistantMessage:
-					newMessageID = casted.ID
-				}
-
-				// Find the correct insertion index by scanning backwards
-				// Most messages are added to the end, so start from the end
-				insertIndex := len(a.app.Messages)
-				for i := len(a.app.Messages) - 1; i >= 0; i-- {
-					var existingID string
-					switch casted := a.app.Messages[i].Info.(type) {
-					case opencode.UserMessage:
-						existingID = casted.ID
-					case opencode.AssistantMessage:
-						existingID = casted.ID
-					}
-					if existingID < newMessageID {
-						insertIndex = i + 1
-						break
-					}
-				}
-
-				// Create the new message
-				newMessage := app.Message{
-					Info:  msg.Properties.Info.AsUnion(),
-					Parts: []opencode.PartUnion{},
-				}
-
-				// Insert at the correct position
-				a.app.Messages = append(a.app.Messages[:insertIndex], append([]app.Message{newMessage}, a.app.Messages[insertIndex:]...)...)
+			a.app.Messages = app.UpdateMessage(a.app.Messages, msg.Properties.Info.AsUnion())
+		} else if tab := a.app.Tab(msg.Properties.Info.SessionID); tab != nil {
+			busy := tab.Busy()
+			tab.Messages = app.UpdateMessage(tab.Messages, msg.Properties.Info.AsUnion())
+			if busy && !tab.Busy() {
+				tab.Unread++
 			}
 		}
 	case opencode.EventListResponseEventPermissionUpdated:
 		slog.Debug("permission updated", "session", msg.Properties.SessionID, "permission", msg.Properties.ID)
+		if rule, ok := app.MatchPermission(a.app.State.PermissionRules, msg.Properties, a.app.Project.Worktree); ok {
+			slog.Debug("permission answered by rule", "pattern", rule.Pattern, "action", rule.Action)
+			response := opencode.SessionPermissionRespondParamsResponseOnce
+			if rule.Action == app.PermissionDeny {
+				response = opencode.SessionPermissionRespondParamsResponseReject
+			}
+			cmds = append(cmds, a.app.RespondPermission(msg.Properties, response, rule.Pattern))
+			break
+		}
 		a.app.Permissions = append(a.app.Permissions, msg.Properties)
 		a.app.CurrentPermission = a.app.Permissions[0]
 		a.editor.Blur()
+		cmds = append(cmds, a.notifier.Notify(notify.EventPermission, "opencode", "Permission requested: "+msg.Properties.Title))
 	case opencode.EventListResponseEventPermissionReplied:
 		index := slices.IndexFunc(a.app.Permissions, func(p opencode.Permission) bool {
 			return p.ID == msg.Properties.PermissionID
@@ -647,16 +628,109 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				a.app.CurrentPermission = opencode.Permission{}
 			}
 		}
+		// The queue may have been answered from the permissions dialog
+		if a.app.CurrentPermission.ID == "" && !a.messages.Searching() {
+			a.editor.Focus()
+		}
 	case opencode.EventListResponseEventSessionError:
-		switch err := msg.Properties.Error.AsUnion().(type) {
-		case nil:
-		case opencode.ProviderAuthError:
-			slog.Error("Failed to authenticate with provider", "error", err.Data.Message)
-			return a, toast.NewErrorToast("Provider error: " + err.Data.Message)
-		case opencode.UnknownError:
-			slog.Error("Server error", "name", err.Name, "message", err.Data.Message)
-			return a, toast.NewErrorToast(err.Data.Message, toast.WithTitle(string(err.Name)))
+		var err *opencode.SessionError
+		switch {
+		case !errors.As(msg.Properties.AsError(), &err):
+		case errors.Is(err, opencode.ErrAborted), errors.Is(err, opencode.ErrOutputLength):
+			// Shown on the message itself.
+		case errors.Is(err, opencode.ErrAuth):
+			slog.Error("Failed to authenticate with provider", "error", err.Message)
+			return a, tea.Batch(
+				toast.NewErrorToast("Provider error: "+err.Message),
+				a.notifier.Notify(notify.EventError, "opencode", "Provider error: "+err.Message),
+			)
+		default:
+			slog.Error("Server error", "name", err.Name, "message", err.Message)
+			return a, tea.Batch(
+				toast.NewErrorToast(err.Message, toast.WithTitle(err.Name)),
+				a.notifier.Notify(notify.EventError, "opencode", err.Name+": "+err.Message),
+			)
 		}
+	case opencode.EventListResponseEventSessionIdle:
+		// Only the sessions open in tabs are notified, not the ones of
+		// subagents
+		if index := a.app.TabIndex(msg.Properties.SessionID); index > -1 {
+			title := a.app.Session.Title
+			if index != a.app.ActiveTab() {
+				title = a.app.Tabs[index].Session.Title
+			}
+			cmds = append(cmds, a.notifier.Notify(notify.EventIdle, "opencode", "Session finished: "+title))
+		}
+	case tea.FocusMsg:
+		a.notifier.SetFocused(true)
+	case tea.BlurMsg:
+		a.notifier.SetFocused(false)
+	case opencode.EventListResponseEventLspClientDiagnostics:
+		a.app.UpdateDiagnostics(msg.Properties)
+	case opencode.EventListResponseEventTodoUpdated:
+		a.app.UpdateTodos(msg.Properties)
+	case app.ReviewHunksMsg:
+		if a.app.Session.ID != msg.SessionID {
+			break
+		}
+		if msg.Err != nil {
+			slog.Error("Failed to fetch changes", "error", msg.Err)
+			return a, toast.NewErrorToast("Failed to fetch changes")
+		}
+		a.app.UpdateFileChanges(app.FileChangesMsg{SessionID: msg.SessionID, Changes: msg.Changes})
+		if len(msg.Hunks) == 0 {
+			return a, toast.NewErrorToast("No changes to review")
+		}
+		a.changes.Blur()
+		a.modal = dialog.NewReviewDialog(a.app, msg.Hunks)
+	case dialog.ReviewAppliedMsg:
+		rejected := 0
+		for _, hunk := range msg.Hunks {
+			if hunk.Rejected {
+				rejected++
+			}
+		}
+		switch {
+		case msg.Err != nil:
+			slog.Error("Failed to revert rejected hunks", "error", msg.Err)
+			reverted := map[string]bool{}
+			for _, hunk := range msg.Reverted {
+				reverted[hunk.Path] = true
+			}
+			var failed []string
+			for _, hunk := range msg.Hunks {
+				if hunk.Rejected && !reverted[hunk.Path] && !slices.Contains(failed, hunk.Path) {
+					failed = append(failed, hunk.Path)
+				}
+			}
+			cmds = append(cmds, toast.NewErrorToast(fmt.Sprintf(
+				"Reverted %d of %d rejected hunks, failed to revert %s",
+				len(msg.Reverted),
+				rejected,
+				strings.Join(failed, ", "),
+			)))
+		case rejected == 0:
+			return a, toast.NewSuccessToast("All changes accepted")
+		default:
+			cmds = append(cmds, toast.NewSuccessToast(fmt.Sprintf("Reverted %d rejected hunks", rejected)))
+		}
+		// Only tell the agent about the hunks that are really gone
+		if len(msg.Reverted) > 0 {
+			cmds = append(cmds, util.CmdHandler(app.SendPrompt(app.Prompt{Text: app.FormatRejected(msg.Reverted)})))
+		}
+	case changes.OpenFileDiffMsg:
+		a.changes.Blur()
+		a.modal = dialog.NewFileDiffDialog(msg.Changes, msg.Index)
+		_, cmd := a.editor.Focus()
+		cmds = append(cmds, cmd)
+	case diagnostics.InsertDiagnosticsMsg:
+		existing := a.editor.Value()
+		if existing != "" && !strings.HasSuffix(existing, "\n") {
+			existing += "\n"
+		}
+		a.editor.SetValueWithAttachments(existing + msg.Text)
+		_, cmd := a.editor.Focus()
+		cmds = append(cmds, cmd)
 	case opencode.EventListResponseEventSessionCompacted:
 		if msg.Properties.SessionID == a.app.Session.ID {
 			return a, toast.NewSuccessToast("Session compacted successfully")
@@ -675,6 +749,11 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			},
 		}
 	case app.SessionSelectedMsg:
+		// A session open in another tab is switched to
+		if index := a.app.TabIndex(msg.ID); index > -1 && index != a.app.ActiveTab() {
+			a.app.SwitchTab(index)
+			return a, util.CmdHandler(app.SessionLoadedMsg{})
+		}
 		updated, cmd := a.messages.Update(msg)
 		a.messages = updated.(chat.MessagesComponent)
 		cmds = append(cmds, cmd)
@@ -690,6 +769,76 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, tea.Batch(cmds...)
 	case app.SessionCreatedMsg:
 		a.app.Session = msg.Session
+	case app.OpenTabMsg:
+		var messages []app.Message
+		if msg.Session != nil {
+			if index := a.app.TabIndex(msg.Session.ID); index > -1 {
+				a.app.SwitchTab(index)
+				return a, util.CmdHandler(app.SessionLoadedMsg{})
+			}
+			var err error
+			messages, err = a.app.ListMessages(context.Background(), msg.Session.ID)
+			if err != nil {
+				slog.Error("Failed to list messages", "error", err.Error())
+				return a, toast.NewErrorToast("Failed to open session")
+			}
+		}
+		a.app.OpenTab(msg.Session, messages)
+		return a, util.CmdHandler(app.SessionLoadedMsg{})
+	case dialog.ExportSessionMsg:
+		a.app.State.ExportFormat = string(msg.Format)
+		session := *a.app.Session
+		cmds = append(cmds, a.app.SaveState(), func() tea.Msg {
+			// Reload the messages so the export has everything the server knows
+			messages, err := a.app.ListMessages(context.Background(), session.ID)
+			if err != nil {
+				slog.Error("Failed to load messages for export", "error", err)
+				return toast.NewErrorToast("Failed to load messages")()
+			}
+			err = exporter.WriteFile(msg.Path, msg.Format, session, messages, theme.CurrentTheme())
+			if err != nil {
+				slog.Error("Failed to export session", "error", err)
+				return toast.NewErrorToast("Failed to export session: " + err.Error())()
+			}
+			return toast.NewSuccessToast("Exported to " + util.Relative(msg.Path))()
+		})
+	case dialog.ImportSessionMsg:
+		cmds = append(cmds, func() tea.Msg {
+			doc, err := exporter.ReadFile(msg.Path)
+			if err != nil {
+				slog.Error("Failed to read session export", "error", err)
+				return toast.NewErrorToast("Failed to import session: " + err.Error())()
+			}
+			session, err := exporter.Import(context.Background(), a.app.Client, doc)
+			if err != nil {
+				slog.Error("Failed to import session", "error", err)
+				return toast.NewErrorToast("Failed to import session")()
+			}
+			return tea.BatchMsg{
+				util.CmdHandler(app.SessionSelectedMsg(session)),
+				toast.NewSuccessToast("Imported " + session.Title),
+			}
+		})
+	case dialog.ForkFromMessageMsg:
+		// Keep the selected message and the replies to it
+		var nextMessageID string
+		for i := msg.Index + 1; i < len(a.app.Messages); i++ {
+			if userMsg, ok := a.app.Messages[i].Info.(opencode.UserMessage); ok {
+				nextMessageID = userMsg.ID
+				break
+			}
+		}
+		cmds = append(cmds, func() tea.Msg {
+			session, err := a.app.ForkSession(context.Background(), nextMessageID)
+			if err != nil {
+				slog.Error("Failed to fork session", "error", err)
+				return toast.NewErrorToast("Failed to fork session")()
+			}
+			return tea.BatchMsg{
+				util.CmdHandler(app.SessionSelectedMsg(session)),
+				toast.NewSuccessToast("Forked into " + session.Title),
+			}
+		})
 	case dialog.ScrollToMessageMsg:
 		updated, cmd := a.messages.ScrollToMessage(msg.MessageID)
 		a.messages = updated.(chat.MessagesComponent)
@@ -883,9 +1032,27 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	a.editor = updatedEditor.(chat.EditorComponent)
 	cmds = append(cmds, cmd)
 
-	updatedMessages, cmd := a.messages.Update(msg)
+	updatedDiagnostics, cmd := a.diagnostics.Update(msg)
+	a.diagnostics = updatedDiagnostics.(diagnostics.DiagnosticsComponent)
+	cmds = append(cmds, cmd)
+
+	updatedTodos, cmd := a.todos.Update(msg)
+	a.todos = updatedTodos.(todos.TodosComponent)
+	cmds = append(cmds, cmd)
+
+	updatedChanges, cmd := a.changes.Update(msg)
+	a.changes = updatedChanges.(changes.ChangesComponent)
+	cmds = append(cmds, cmd)
+
+	messagesMsg := msg
+	if _, ok := msg.(tea.WindowSizeMsg); ok {
+		a.messagesSize = a.messagesBounds()
+		messagesMsg = a.messagesSize
+	}
+	updatedMessages, cmd := a.messages.Update(messagesMsg)
 	a.messages = updatedMessages.(chat.MessagesComponent)
 	cmds = append(cmds, cmd)
+	cmds = append(cmds, a.resizeMessages())
 
 	if a.modal != nil {
 		updatedModal, cmd := a.modal.Update(msg)
@@ -1078,12 +1245,78 @@ func (a Model) home() (string, int, int) {
 	return mainLayout, editorX + 5, editorY + editorYDelta
 }
 
+// sidebarWidth returns the width of the sidebar right of the messages, zero
+// when it is hidden
+func (a Model) sidebarWidth() int {
+	if a.app.Session.ID == "" || a.width < sidebarMinWidth {
+		return 0
+	}
+	if !a.todos.Visible() && !a.changes.Visible() {
+		return 0
+	}
+	return sidebarWidth
+}
+
+// messagesBounds returns the size of the messages, making room for the
+// sidebar right of them and the diagnostics panel below them
+func (a Model) messagesBounds() tea.WindowSizeMsg {
+	return tea.WindowSizeMsg{
+		Width:  a.width - a.sidebarWidth(),
+		Height: a.height + 2 - a.diagnostics.Height(),
+	}
+}
+
+// resizeMessages resizes the messages when the panels around them changed
+func (a *Model) resizeMessages() tea.Cmd {
+	size := a.messagesBounds()
+	if a.width == 0 || size == a.messagesSize {
+		return nil
+	}
+	a.messagesSize = size
+	updated, cmd := a.messages.Update(size)
+	a.messages = updated.(chat.MessagesComponent)
+	return cmd
+}
+
+// sidebar renders the panels right of the messages, as high as them: the
+// todos, then the changed files in the room left below them
+func (a Model) sidebar(height int) string {
+	t := theme.CurrentTheme()
+	a.todos.SetWidth(sidebarWidth - 2)
+	var panels []string
+	if view := a.todos.View(); view != "" {
+		panels = append(panels, view)
+	}
+	used := lipgloss.Height(strings.Join(panels, "\n\n"))
+	if len(panels) > 0 {
+		used++
+	}
+	a.changes.SetSize(sidebarWidth-2, height-used)
+	if view := a.changes.View(); view != "" {
+		panels = append(panels, view)
+	}
+	return styles.NewStyle().
+		Background(t.Background()).
+		PaddingLeft(2).
+		Width(sidebarWidth).
+		Height(height).
+		MaxHeight(height).
+		Render(strings.Join(panels, "\n\n"))
+}
+
 func (a Model) chat() (string, int, int) {
 	effectiveWidth := a.width - 4
 	t := theme.CurrentTheme()
 	editorView := a.editor.View()
 	lines := a.editor.Lines()
 	messagesView := a.messages.View()
+	if a.sidebarWidth() > 0 {
+		messagesView = lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			messagesView,
+			a.sidebar(lipgloss.Height(messagesView)),
+		)
+	}
 
 	editorWidth := lipgloss.Width(editorView)
 	editorHeight := max(lines, 5)
@@ -1095,6 +1328,15 @@ func (a Model) chat() (string, int, int) {
 	)
 
 	mainLayout := messagesView + "\n" + editorView
+	if a.diagnostics.Visible() {
+		diagnosticsView := lipgloss.PlaceHorizontal(
+			effectiveWidth,
+			lipgloss.Center,
+			a.diagnostics.View(),
+			styles.WhitespaceStyle(t.Background()),
+		)
+		mainLayout = messagesView + "\n" + diagnosticsView + "\n" + editorView
+	}
 	editorX := max(0, (effectiveWidth-editorWidth)/2)
 	editorY := a.height - editorHeight
 
@@ -1131,7 +1373,6 @@ func (a Model) chat() (string, int, int) {
 }
 
 func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
 	cmds := []tea.Cmd{
 		util.CmdHandler(commands.CommandExecutedMsg(command)),
 	}
@@ -1139,6 +1380,10 @@ func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 	case commands.AppHelpCommand:
 		helpDialog := dialog.NewHelpDialog(a.app)
 		a.modal = helpDialog
+	case commands.AppUsageCommand:
+		usageDialog := dialog.NewUsageDialog(a.app)
+		a.modal = usageDialog
+		cmds = append(cmds, usageDialog.Init())
 	case commands.AgentCycleCommand:
 		updated, cmd := a.app.SwitchAgent()
 		a.app = updated
@@ -1203,6 +1448,24 @@ func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 	case commands.SessionListCommand:
 		sessionDialog := dialog.NewSessionDialog(a.app)
 		a.modal = sessionDialog
+	case commands.TabNewCommand:
+		cmds = append(cmds, util.CmdHandler(app.OpenTabMsg{}))
+	case commands.TabCloseCommand:
+		if len(a.app.Tabs) < 2 {
+			return a, nil
+		}
+		a.app.CloseTab(a.app.ActiveTab())
+		cmds = append(cmds, util.CmdHandler(app.SessionLoadedMsg{}))
+	case commands.TabNextCommand, commands.TabPreviousCommand:
+		if len(a.app.Tabs) < 2 {
+			return a, nil
+		}
+		a.app.CycleTab(command.Name == commands.TabNextCommand)
+		cmds = append(cmds, util.CmdHandler(app.SessionLoadedMsg{}))
+	case commands.SessionSearchCommand:
+		findDialog := dialog.NewFindDialog(a.app)
+		a.modal = findDialog
+		cmds = append(cmds, findDialog.Init())
 	case commands.SessionTimelineCommand:
 		if a.app.Session.ID == "" {
 			return a, toast.NewErrorToast("No active session")
@@ -1388,53 +1651,13 @@ func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 		if a.app.Session.ID == "" {
 			return a, toast.NewErrorToast("No active session to export.")
 		}
-
-		// Use current conversation history
-		messages := a.app.Messages
-		if len(messages) == 0 {
-			return a, toast.NewInfoToast("No messages to export.")
-		}
-
-		// Format to Markdown
-		markdownContent := formatConversationToMarkdown(messages)
-
-		// Check if EDITOR is set
-		editor := os.Getenv("EDITOR")
-		if editor == "" {
-			return a, toast.NewErrorToast("No EDITOR set, can't open editor")
-		}
-
-		// Create and write to temp file
-		tmpfile, err := os.CreateTemp("", "conversation-*.md")
-		if err != nil {
-			slog.Error("Failed to create temp file", "error", err)
-			return a, toast.NewErrorToast("Failed to create temporary file.")
-		}
-
-		_, err = tmpfile.WriteString(markdownContent)
-		if err != nil {
-			slog.Error("Failed to write to temp file", "error", err)
-			tmpfile.Close()
-			os.Remove(tmpfile.Name())
-			return a, toast.NewErrorToast("Failed to write conversation to file.")
-		}
-		tmpfile.Close()
-
-		// Open in editor
-		parts := strings.Fields(editor)
-		c := exec.Command(parts[0], append(parts[1:], tmpfile.Name())...) //nolint:gosec
-		c.Stdin = os.Stdin
-		c.Stdout = os.Stdout
-		c.Stderr = os.Stderr
-		cmd = tea.ExecProcess(c, func(err error) tea.Msg {
-			if err != nil {
-				slog.Error("Failed to open editor for conversation", "error", err)
-			}
-			// Clean up the file after editor closes
-			os.Remove(tmpfile.Name())
-			return nil
-		})
-		cmds = append(cmds, cmd)
+		exportDialog := dialog.NewExportDialog(a.app)
+		a.modal = exportDialog
+		cmds = append(cmds, exportDialog.Init())
+	case commands.SessionImportCommand:
+		importDialog := dialog.NewImportDialog()
+		a.modal = importDialog
+		cmds = append(cmds, importDialog.Init())
 	case commands.ToolDetailsCommand:
 		message := "Tool details are now visible"
 		if a.messages.ToolDetailsVisible() {
@@ -1449,6 +1672,62 @@ func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 		}
 		cmds = append(cmds, util.CmdHandler(chat.ToggleThinkingBlocksMsg{}))
 		cmds = append(cmds, toast.NewInfoToast(message))
+	case commands.SnippetListCommand:
+		snippetsDialog := dialog.NewSnippetsDialog(a.app, a.editor.Value())
+		a.modal = snippetsDialog
+	case commands.DiagnosticsCommand:
+		if a.app.Session.ID == "" {
+			return a, toast.NewErrorToast("No active session")
+		}
+		a.diagnostics.Toggle()
+		if a.diagnostics.Focused() {
+			a.changes.Blur()
+			a.editor.Blur()
+		} else {
+			_, cmd := a.editor.Focus()
+			cmds = append(cmds, cmd)
+		}
+		cmds = append(cmds, a.resizeMessages())
+	case commands.ChangesCommand:
+		if !a.changes.Visible() {
+			return a, toast.NewErrorToast("No files changed in this session")
+		}
+		// Without room for the sidebar, the diffs are shown right away
+		if a.sidebarWidth() == 0 {
+			a.modal = dialog.NewFileDiffDialog(a.app.FileChanges(), 0)
+			break
+		}
+		a.diagnostics.Blur()
+		a.changes.Focus()
+		a.editor.Blur()
+	case commands.ReviewCommand:
+		if a.app.Session.ID == "" {
+			return a, toast.NewErrorToast("No active session")
+		}
+		if a.app.IsBusy() {
+			return a, toast.NewErrorToast("Wait for the agent to finish before reviewing its changes")
+		}
+		cmds = append(cmds, a.app.FetchReviewHunks(a.app.Session.ID))
+	case commands.TodosCommand:
+		if !a.todos.Visible() {
+			return a, toast.NewErrorToast("No todos in this session")
+		}
+		message := "Todos collapsed"
+		if a.app.State.TodosCollapsed {
+			message = "Todos expanded"
+		}
+		cmds = append(cmds, util.CmdHandler(todos.ToggleTodosMsg{}))
+		cmds = append(cmds, toast.NewInfoToast(message))
+	case commands.VimModeCommand:
+		message := "Vim mode enabled"
+		if a.app.State.VimMode {
+			message = "Vim mode disabled"
+		}
+		cmds = append(cmds, util.CmdHandler(chat.ToggleVimModeMsg{}))
+		cmds = append(cmds, toast.NewInfoToast(message))
+	case commands.PermissionListCommand:
+		permissionsDialog := dialog.NewPermissionsDialog(a.app)
+		a.modal = permissionsDialog
 	case commands.ModelListCommand:
 		modelDialog := dialog.NewModelDialog(a.app)
 		a.modal = modelDialog
@@ -1525,6 +1804,14 @@ func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 		updated, cmd := a.messages.RedoLastMessage()
 		a.messages = updated.(chat.MessagesComponent)
 		cmds = append(cmds, cmd)
+	case commands.MessagesSearchCommand:
+		if a.app.Session.ID == "" {
+			return a, nil
+		}
+		updated, cmd := a.messages.Search()
+		a.messages = updated.(chat.MessagesComponent)
+		a.editor.Blur()
+		cmds = append(cmds, cmd)
 	case commands.AppExitCommand:
 		return a, tea.Quit
 	}
@@ -1536,6 +1823,7 @@ func NewModel(app *app.App) tea.Model {
 	fileProvider := completions.NewFileContextGroup(app)
 	symbolsProvider := completions.NewSymbolsContextGroup(app)
 	agentsProvider := completions.NewAgentsContextGroup(app)
+	snippetsProvider := completions.NewSnippetsContextGroup(app)
 
 	messages := chat.NewMessagesComponent(app)
 	editor := chat.NewEditorComponent(app)
@@ -1552,11 +1840,16 @@ func NewModel(app *app.App) tea.Model {
 		app:                  app,
 		editor:               editor,
 		messages:             messages,
+		diagnostics:          diagnostics.NewDiagnosticsComponent(app),
+		todos:                todos.NewTodosComponent(app),
+		changes:              changes.NewChangesComponent(app),
+		notifier:             notify.New(app.Config.Tui.Notifications),
 		completions:          completions,
 		commandProvider:      commandProvider,
 		fileProvider:         fileProvider,
 		symbolsProvider:      symbolsProvider,
 		agentsProvider:       agentsProvider,
+		snippetsProvider:     snippetsProvider,
 		leaderBinding:        leaderBinding,
 		showCompletionDialog: false,
 		toastManager:         toast.NewToastManager(),
@@ -1566,44 +1859,3 @@ func NewModel(app *app.App) tea.Model {
 
 	return model
 }
-
-func formatConversationToMarkdown(messages []app.Message) string {
-	var builder strings.Builder
-
-	builder.WriteString("# Conversation History\n\n")
-
-	for _, msg := range messages {
-		builder.WriteString("---\n\n")
-
-		var role string
-		var timestamp time.Time
-
-		switch info := msg.Info.(type) {
-		case opencode.UserMessage:
-			role = "User"
-			timestamp = time.UnixMilli(int64(info.Time.Created))
-		case opencode.AssistantMessage:
-			role = "Assistant"
-			timestamp = time.UnixMilli(int64(info.Time.Created))
-		default:
-			continue
-		}
-
-		builder.WriteString(
-			fmt.Sprintf("**%s** (*%s*)\n\n", role, timestamp.Format("2006-01-02 15:04:05")),
-		)
-
-		for _, part := range msg.Parts {
-			switch p := part.(type) {
-			case opencode.TextPart:
-				builder.WriteString(p.Text + "\n\n")
-			case opencode.FilePart:
-				builder.WriteString(fmt.Sprintf("[File: %s]\n\n", p.Filename))
-			case opencode.ToolPart:
-				builder.WriteString(fmt.Sprintf("[Tool: %s]\n\n", p.Tool))
-			}
-		}
-	}
-
-	return builder.String()
-}
@@ -0,0 +1,288 @@
+// Package usage aggregates the tokens and cost of the assistant messages of
+// every session by day, model, provider and agent.
+package usage
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+	"golang.org/x/sync/errgroup"
+)
+
+// concurrency is how many sessions are fetched at once
+const concurrency = 4
+
+// dayLayout is the layout of the keys of the daily rows
+const dayLayout = "2006-01-02"
+
+type Grouping string
+
+const (
+	ByDay      Grouping = "day"
+	ByModel    Grouping = "model"
+	ByProvider Grouping = "provider"
+	ByAgent    Grouping = "agent"
+)
+
+// Groupings are the ways messages are aggregated, in the order they are shown
+var Groupings = []Grouping{ByDay, ByModel, ByProvider, ByAgent}
+
+// Totals are the tokens and cost of a set of assistant messages
+type Totals struct {
+	Messages   int     `json:"messages"`
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	Reasoning  float64 `json:"reasoning"`
+	CacheRead  float64 `json:"cacheRead"`
+	CacheWrite float64 `json:"cacheWrite"`
+	Cost       float64 `json:"cost"`
+}
+
+// Tokens returns every token counted, cache reads and writes included
+func (t Totals) Tokens() float64 {
+	return t.Input + t.Output + t.Reasoning + t.CacheRead + t.CacheWrite
+}
+
+func (t *Totals) add(message opencode.AssistantMessage) {
+	t.Messages++
+	t.Input += message.Tokens.Input
+	t.Output += message.Tokens.Output
+	t.Reasoning += message.Tokens.Reasoning
+	t.CacheRead += message.Tokens.Cache.Read
+	t.CacheWrite += message.Tokens.Cache.Write
+	t.Cost += message.Cost
+}
+
+// Row is the totals of a day, model, provider or agent
+type Row struct {
+	Key string `json:"key"`
+	Totals
+}
+
+// Report is the usage of the sessions of a project
+type Report struct {
+	// Since is the start of the period covered, zero for all time
+	Since    time.Time          `json:"since"`
+	Sessions int                `json:"sessions"`
+	Total    Totals             `json:"total"`
+	Groups   map[Grouping][]Row `json:"groups"`
+	messages []opencode.AssistantMessage
+}
+
+// Collect fetches the messages of the sessions updated since the given time,
+// or of every session when it's zero, and aggregates them.
+func Collect(ctx context.Context, client *opencode.Client, since time.Time) (*Report, error) {
+	sessions, err := client.Session.List(ctx, opencode.SessionListParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu       sync.Mutex
+		messages []opencode.AssistantMessage
+	)
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(concurrency)
+	for _, session := range *sessions {
+		if !since.IsZero() && time.UnixMilli(int64(session.Time.Updated)).Before(since) {
+			continue
+		}
+		group.Go(func() error {
+			response, err := client.Session.Messages(ctx, session.ID, opencode.SessionMessagesParams{})
+			if err != nil {
+				return fmt.Errorf("failed to get messages of session %s: %w", session.ID, err)
+			}
+			if response == nil {
+				return nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, message := range *response {
+				if assistant, ok := message.Info.AsUnion().(opencode.AssistantMessage); ok {
+					messages = append(messages, assistant)
+				}
+			}
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return Aggregate(messages, since), nil
+}
+
+// Aggregate totals the messages created since the given time, or all of them
+// when it's zero
+func Aggregate(messages []opencode.AssistantMessage, since time.Time) *Report {
+	report := &Report{Since: since, Groups: map[Grouping][]Row{}}
+	totals := map[Grouping]map[string]*Totals{}
+	for _, grouping := range Groupings {
+		totals[grouping] = map[string]*Totals{}
+	}
+	sessions := map[string]bool{}
+
+	for _, message := range messages {
+		if !since.IsZero() && created(message).Before(since) {
+			continue
+		}
+		report.messages = append(report.messages, message)
+		report.Total.add(message)
+		sessions[message.SessionID] = true
+		for _, grouping := range Groupings {
+			key := Key(grouping, message)
+			if totals[grouping][key] == nil {
+				totals[grouping][key] = &Totals{}
+			}
+			totals[grouping][key].add(message)
+		}
+	}
+	report.Sessions = len(sessions)
+
+	for _, grouping := range Groupings {
+		rows := make([]Row, 0, len(totals[grouping]))
+		for key, t := range totals[grouping] {
+			rows = append(rows, Row{Key: key, Totals: *t})
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			if grouping == ByDay {
+				return rows[i].Key > rows[j].Key
+			}
+			if rows[i].Cost != rows[j].Cost {
+				return rows[i].Cost > rows[j].Cost
+			}
+			if rows[i].Tokens() != rows[j].Tokens() {
+				return rows[i].Tokens() > rows[j].Tokens()
+			}
+			return rows[i].Key < rows[j].Key
+		})
+		report.Groups[grouping] = rows
+	}
+	return report
+}
+
+// Key returns the row of the grouping a message is counted in
+func Key(grouping Grouping, message opencode.AssistantMessage) string {
+	key := ""
+	switch grouping {
+	case ByDay:
+		key = created(message).Format(dayLayout)
+	case ByModel:
+		key = message.ProviderID + "/" + message.ModelID
+	case ByProvider:
+		key = message.ProviderID
+	case ByAgent:
+		key = message.Mode
+	}
+	if key == "" || key == "/" {
+		return "unknown"
+	}
+	return key
+}
+
+func created(message opencode.AssistantMessage) time.Time {
+	return time.UnixMilli(int64(message.Time.Created))
+}
+
+// Daily returns the totals of each of the days up to now, oldest first, of the
+// messages in the row of the grouping, or of every message when key is empty
+func (r *Report) Daily(grouping Grouping, key string, days int, now time.Time) []Totals {
+	daily := make([]Totals, days)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, message := range r.messages {
+		if key != "" && Key(grouping, message) != key {
+			continue
+		}
+		t := created(message)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location())
+		index := days - 1 - int(today.Sub(day).Hours()/24+0.5)
+		if index >= 0 && index < days {
+			daily[index].add(message)
+		}
+	}
+	return daily
+}
+
+// WriteCSV writes the rows of every grouping, one grouping after the other
+func WriteCSV(w io.Writer, report *Report) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{
+		"group", "key", "messages", "input", "output", "reasoning", "cache_read", "cache_write", "cost",
+	})
+	number := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	for _, grouping := range Groupings {
+		for _, row := range report.Groups[grouping] {
+			writer.Write([]string{
+				string(grouping),
+				row.Key,
+				strconv.Itoa(row.Messages),
+				number(row.Input),
+				number(row.Output),
+				number(row.Reasoning),
+				number(row.CacheRead),
+				number(row.CacheWrite),
+				strconv.FormatFloat(row.Cost, 'f', 6, 64),
+			})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws the values as a line of bars, up to width bars. Values are
+// summed into buckets when there are more of them than bars.
+func Sparkline(values []float64, width int) string {
+	if width <= 0 || len(values) == 0 {
+		return ""
+	}
+	if len(values) > width {
+		buckets := make([]float64, width)
+		for i, value := range values {
+			buckets[i*width/len(values)] += value
+		}
+		values = buckets
+	}
+
+	peak := 0.0
+	for _, value := range values {
+		peak = max(peak, value)
+	}
+	var b strings.Builder
+	for _, value := range values {
+		if peak == 0 || value <= 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(sparks[min(len(sparks)-1, int(value/peak*float64(len(sparks)-1)+0.5))])
+	}
+	return b.String()
+}
+
+// FormatTokens formats a token count in a short human-readable form, such as
+// 110K or 1.2M
+func FormatTokens(tokens float64) string {
+	var formatted string
+	switch {
+	case tokens >= 1_000_000:
+		formatted = fmt.Sprintf("%.1fM", tokens/1_000_000)
+	case tokens >= 1_000:
+		formatted = fmt.Sprintf("%.1fK", tokens/1_000)
+	default:
+		formatted = fmt.Sprintf("%d", int(tokens))
+	}
+	// Remove .0 suffix if present
+	formatted = strings.Replace(formatted, ".0K", "K", 1)
+	formatted = strings.Replace(formatted, ".0M", "M", 1)
+	return formatted
+}
@@ -0,0 +1,94 @@
+package usage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+func message(session, provider, model, agent string, created time.Time, cost float64) opencode.AssistantMessage {
+	return opencode.AssistantMessage{
+		SessionID:  session,
+		ProviderID: provider,
+		ModelID:    model,
+		Mode:       agent,
+		Cost:       cost,
+		Time:       opencode.AssistantMessageTime{Created: float64(created.UnixMilli())},
+		Tokens: opencode.AssistantMessageTokens{
+			Input:     100,
+			Output:    10,
+			Reasoning: 5,
+			Cache:     opencode.AssistantMessageTokensCache{Read: 1000, Write: 50},
+		},
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 0, 0, 0, time.Local)
+	messages := []opencode.AssistantMessage{
+		message("ses_1", "anthropic", "sonnet", "build", now, 0.5),
+		message("ses_1", "anthropic", "sonnet", "plan", now.Add(-time.Hour), 0.25),
+		message("ses_2", "openai", "gpt", "build", now.AddDate(0, 0, -2), 1),
+		message("ses_3", "openai", "gpt", "build", now.AddDate(0, 0, -40), 4),
+	}
+
+	report := Aggregate(messages, now.AddDate(0, 0, -29))
+	if report.Sessions != 2 || report.Total.Messages != 3 || report.Total.Cost != 1.75 {
+		t.Fatalf("unexpected totals %+v in %d sessions", report.Total, report.Sessions)
+	}
+	if report.Total.CacheRead != 3000 || report.Total.Tokens() != 3*1165 {
+		t.Errorf("expected cache tokens to be counted, got %+v", report.Total)
+	}
+
+	days := report.Groups[ByDay]
+	if len(days) != 2 || days[0].Key != "2025-03-10" || days[0].Messages != 2 {
+		t.Errorf("expected the latest day first, got %+v", days)
+	}
+	models := report.Groups[ByModel]
+	if len(models) != 2 || models[0].Key != "openai/gpt" || models[1].Cost != 0.75 {
+		t.Errorf("expected models by cost, got %+v", models)
+	}
+	if agents := report.Groups[ByAgent]; len(agents) != 2 || agents[0].Key != "build" || agents[0].Messages != 2 {
+		t.Errorf("unexpected agents %+v", agents)
+	}
+
+	daily := report.Daily(ByDay, "", 7, now)
+	if len(daily) != 7 || daily[6].Messages != 2 || daily[4].Messages != 1 {
+		t.Errorf("unexpected daily totals %+v", daily)
+	}
+	if daily := report.Daily(ByProvider, "anthropic", 7, now); daily[4].Messages != 0 || daily[6].Cost != 0.75 {
+		t.Errorf("unexpected daily totals of a provider %+v", daily)
+	}
+
+	var csv strings.Builder
+	if err := WriteCSV(&csv, report); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 1+2+2+2+2 || lines[1] != "day,2025-03-10,2,200,20,10,2000,100,0.750000" {
+		t.Errorf("unexpected csv:\n%s", csv.String())
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if line := Sparkline([]float64{0, 1, 2, 4, 8}, 10); line != " ▂▃▅█" {
+		t.Errorf("unexpected sparkline %q", line)
+	}
+	if line := Sparkline([]float64{1, 1, 1, 1, 2, 2}, 3); line != "▅▅█" {
+		t.Errorf("expected values to be bucketed, got %q", line)
+	}
+	if line := Sparkline(make([]float64, 3), 3); line != "   " {
+		t.Errorf("expected blanks without values, got %q", line)
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	cases := map[float64]string{999: "999", 1000: "1K", 1500: "1.5K", 2_000_000: "2M", 1_250_000: "1.2M"}
+	for tokens, expected := range cases {
+		if formatted := FormatTokens(tokens); formatted != expected {
+			t.Errorf("expected %s for %v, got %s", expected, tokens, formatted)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package viewport
+
+import "testing"
+
+func TestParseMatchesStyledContent(t *testing.T) {
+	content := "\x1b[1mfoo\x1b[0m bar\nbaz \x1b[31mbar\x1b[0m"
+	// matches are made against the text without styles
+	highlights := parseMatches(content, [][]int{{4, 7}, {12, 15}})
+	if len(highlights) != 2 {
+		t.Fatalf("expected 2 highlights, got %d", len(highlights))
+	}
+	expected := []struct{ line, start, end int }{{0, 4, 7}, {1, 4, 7}}
+	for i, hi := range highlights {
+		line, start, end := hi.coords()
+		if line != expected[i].line || start != expected[i].start || end != expected[i].end {
+			t.Errorf("highlight %d: expected %v, got %d %d-%d", i, expected[i], line, start, end)
+		}
+	}
+}
+
+func TestRestoreHighlights(t *testing.T) {
+	m := New(WithWidth(20), WithHeight(2))
+	m.SetContent("one\ntwo\none\ntwo\none")
+	m.SetYOffset(3)
+
+	m.RestoreHighlights([][]int{{0, 3}, {8, 11}, {16, 19}}, 1)
+	if m.YOffset != 3 {
+		t.Errorf("expected the offset to be kept, got %d", m.YOffset)
+	}
+	if m.HighlightCount() != 3 || m.HighlightIndex() != 1 || m.HighlightLine() != 2 {
+		t.Errorf("unexpected highlight %d/%d at line %d", m.HighlightIndex(), m.HighlightCount(), m.HighlightLine())
+	}
+
+	m.RestoreHighlights([][]int{{0, 3}}, 2)
+	if m.HighlightIndex() != 0 {
+		t.Errorf("expected the index to be clamped, got %d", m.HighlightIndex())
+	}
+}
@@ -654,6 +654,43 @@ func (m *Model) SetHighlights(matches [][]int) {
 	m.memo.Invalidate()
 }
 
+// RestoreHighlights sets the highlight ranges like [Model.SetHighlights], but
+// selects the given index and doesn't scroll. It's meant to keep highlights
+// across content updates, which clear them.
+func (m *Model) RestoreHighlights(matches [][]int, index int) {
+	m.ClearHighlights()
+	if len(matches) == 0 || len(m.lines) == 0 {
+		return
+	}
+	m.highlights = parseMatches(m.GetContent(), matches)
+	if index < 0 {
+		index = m.findNearedtMatch()
+	}
+	m.hiIdx = min(index, len(m.highlights)-1)
+	m.memo.Invalidate()
+}
+
+// HighlightCount returns the number of highlight ranges.
+func (m Model) HighlightCount() int {
+	return len(m.highlights)
+}
+
+// HighlightIndex returns the index of the selected highlight, or -1 if none
+// is selected.
+func (m Model) HighlightIndex() int {
+	return m.hiIdx
+}
+
+// HighlightLine returns the line the selected highlight starts at, or -1 if
+// none is selected.
+func (m Model) HighlightLine() int {
+	if m.hiIdx < 0 || m.hiIdx >= len(m.highlights) {
+		return -1
+	}
+	line, _, _ := m.highlights[m.hiIdx].coords()
+	return line
+}
+
 // ClearHighlights clears previously set highlights.
 func (m *Model) ClearHighlights() {
 	m.highlights = nil